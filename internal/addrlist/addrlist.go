@@ -23,6 +23,8 @@ type AddrList struct {
 	blocklist  *blocklist.Blocklist
 
 	countBySource map[peersource.Source]int
+	// Boost values of the addresses that are pushed with PushBoosted.
+	boosts map[peerpriority.Priority]int64
 }
 
 // New returns a new AddrList.
@@ -35,6 +37,7 @@ func New(maxItems int, blocklist *blocklist.Blocklist, listenPort int, clientIP
 		clientIP:      clientIP,
 		blocklist:     blocklist,
 		countBySource: make(map[peersource.Source]int),
+		boosts:        make(map[peerpriority.Priority]int64),
 	}
 }
 
@@ -43,6 +46,7 @@ func (d *AddrList) Reset() {
 	d.peerByTime = nil
 	d.peerByPriority.Clear(false)
 	d.countBySource = make(map[peersource.Source]int)
+	d.boosts = make(map[peerpriority.Priority]int64)
 }
 
 // Len returns the number of addresses in the list.
@@ -62,13 +66,48 @@ func (d *AddrList) Pop() (*net.TCPAddr, peersource.Source) {
 		return nil, 0
 	}
 	p := item.(*peerAddr)
+	d.remove(p)
+	return p.addr, p.source
+}
+
+// PopAllowed is like Pop but skips the addresses from the sources that are not allowed.
+// Skipped addresses are kept in the list.
+func (d *AddrList) PopAllowed(allowed func(peersource.Source) bool) (*net.TCPAddr, peersource.Source) {
+	var p *peerAddr
+	d.peerByPriority.Descend(func(i btree.Item) bool {
+		if pa := i.(*peerAddr); allowed(pa.source) {
+			p = pa
+			return false
+		}
+		return true
+	})
+	if p == nil {
+		return nil, 0
+	}
+	d.peerByPriority.Delete(p)
+	d.remove(p)
+	return p.addr, p.source
+}
+
+func (d *AddrList) remove(p *peerAddr) {
 	d.peerByTime[p.index] = nil
 	d.countBySource[p.source]--
-	return p.addr, p.source
+	delete(d.boosts, p.priority)
 }
 
 // Push adds a new address to the list. Does nothing if the address is already in the list.
-func (d *AddrList) Push(addrs []*net.TCPAddr, source peersource.Source) {
+// Returns the addresses that are discarded because they are in the blocklist.
+func (d *AddrList) Push(addrs []*net.TCPAddr, source peersource.Source) (blocked []*net.TCPAddr) {
+	return d.push(addrs, source, 0)
+}
+
+// PushBoosted is like Push but addresses with higher boost value are returned before others from Pop.
+// If an address is already in the list, its boost value is increased, never decreased.
+func (d *AddrList) PushBoosted(addrs []*net.TCPAddr, source peersource.Source, boost int64) (blocked []*net.TCPAddr) {
+	return d.push(addrs, source, boost)
+}
+
+func (d *AddrList) push(addrs []*net.TCPAddr, source peersource.Source, boost int64) (blocked []*net.TCPAddr) {
 	now := time.Now()
 	var added int
 	for _, ad := range addrs {
@@ -86,15 +125,27 @@ func (d *AddrList) Push(addrs []*net.TCPAddr, source peersource.Source) {
 			continue
 		}
 		if d.blocklist != nil && d.blocklist.Blocked(ad.IP) {
+			blocked = append(blocked, ad)
 			continue
 		}
+		priority := peerpriority.Calculate(ad, d.clientAddr())
+		oldBoost := d.boosts[priority]
 		p := &peerAddr{
 			addr:      ad,
 			timestamp: now,
 			source:    source,
-			priority:  peerpriority.Calculate(ad, d.clientAddr()),
+			priority:  priority,
+			boost:     max(oldBoost, boost),
+		}
+		var item btree.Item
+		if p.boost != oldBoost {
+			// Boost is a part of the key. Existing item must be removed explicitly.
+			item = d.peerByPriority.Delete(&peerAddr{priority: priority, boost: oldBoost})
+			d.peerByPriority.ReplaceOrInsert(p)
+			d.boosts[priority] = p.boost
+		} else {
+			item = d.peerByPriority.ReplaceOrInsert(p)
 		}
-		item := d.peerByPriority.ReplaceOrInsert(p)
 		if item != nil {
 			prev := item.(*peerAddr)
 			d.peerByTime[prev.index] = p
@@ -114,11 +165,11 @@ func (d *AddrList) Push(addrs []*net.TCPAddr, source peersource.Source) {
 	if delta > 0 {
 		d.removeExcessItems(delta)
 		d.filterNils()
-		d.countBySource[source] -= delta
 	}
 	if len(d.peerByTime) != d.peerByPriority.Len() {
 		panic("addr list data structures not in sync")
 	}
+	return blocked
 }
 
 func (d *AddrList) filterNils() {
@@ -135,7 +186,7 @@ func (d *AddrList) filterNils() {
 func (d *AddrList) removeExcessItems(delta int) {
 	for i := 0; i < delta; i++ {
 		d.peerByPriority.Delete(d.peerByTime[i])
-		d.peerByTime[i] = nil
+		d.remove(d.peerByTime[i])
 	}
 }
 
@@ -56,3 +56,61 @@ func TestAddrList(t *testing.T) {
 func newAddr(ip string) *net.TCPAddr {
 	return &net.TCPAddr{IP: net.ParseIP(ip), Port: 1}
 }
+
+func TestAddrListBoost(t *testing.T) {
+	clientIP := net.IPv4(1, 2, 3, 4)
+	al := New(10, nil, 5000, &clientIP)
+
+	al.Push([]*net.TCPAddr{newAddr("1.1.1.1"), newAddr("2.2.2.2")}, peersource.Tracker)
+	al.PushBoosted([]*net.TCPAddr{newAddr("3.3.3.3")}, peersource.Cache, 10)
+	al.PushBoosted([]*net.TCPAddr{newAddr("2.2.2.2")}, peersource.Cache, 20)
+	assert.Equal(t, 3, al.Len())
+
+	// Pushing again without boost must not lower the boost or add a duplicate.
+	al.Push([]*net.TCPAddr{newAddr("2.2.2.2")}, peersource.Tracker)
+	assert.Equal(t, 3, al.Len())
+
+	addr, _ := al.Pop()
+	assert.Equal(t, "2.2.2.2:1", addr.String())
+	addr, src := al.Pop()
+	assert.Equal(t, "3.3.3.3:1", addr.String())
+	assert.Equal(t, peersource.Cache, src)
+	addr, _ = al.Pop()
+	assert.Equal(t, "1.1.1.1:1", addr.String())
+	assert.Equal(t, 0, al.Len())
+}
+
+func TestAddrListPopAllowed(t *testing.T) {
+	clientIP := net.IPv4(1, 2, 3, 4)
+	al := New(10, nil, 5000, &clientIP)
+
+	al.Push([]*net.TCPAddr{newAddr("1.1.1.1")}, peersource.Tracker)
+	al.PushBoosted([]*net.TCPAddr{newAddr("2.2.2.2")}, peersource.PEX, 10)
+	assert.Equal(t, 1, al.LenSource(peersource.PEX))
+
+	notPEX := func(s peersource.Source) bool { return s != peersource.PEX }
+	addr, src := al.PopAllowed(notPEX)
+	assert.Equal(t, "1.1.1.1:1", addr.String())
+	assert.Equal(t, peersource.Tracker, src)
+	addr, _ = al.PopAllowed(notPEX)
+	assert.Nil(t, addr)
+
+	// Skipped address stays in the list.
+	assert.Equal(t, 1, al.Len())
+	assert.Equal(t, 0, al.LenSource(peersource.Tracker))
+	addr, src = al.Pop()
+	assert.Equal(t, "2.2.2.2:1", addr.String())
+	assert.Equal(t, peersource.PEX, src)
+	assert.Equal(t, 0, al.LenSource(peersource.PEX))
+}
+
+func TestAddrListExcessItemsBySource(t *testing.T) {
+	clientIP := net.IPv4(1, 2, 3, 4)
+	al := New(1, nil, 5000, &clientIP)
+
+	al.Push([]*net.TCPAddr{newAddr("1.1.1.1")}, peersource.Tracker)
+	al.Push([]*net.TCPAddr{newAddr("2.2.2.2")}, peersource.PEX)
+	assert.Equal(t, 1, al.Len())
+	assert.Equal(t, 0, al.LenSource(peersource.Tracker))
+	assert.Equal(t, 1, al.LenSource(peersource.PEX))
+}
@@ -15,6 +15,7 @@ type peerAddr struct {
 	timestamp time.Time
 	source    peersource.Source
 	priority  peerpriority.Priority
+	boost     int64
 
 	// index in AddrList.peerByTime slice
 	index int
@@ -23,7 +24,11 @@ type peerAddr struct {
 var _ btree.Item = (*peerAddr)(nil)
 
 func (p *peerAddr) Less(than btree.Item) bool {
-	return p.priority < than.(*peerAddr).priority
+	q := than.(*peerAddr)
+	if p.boost != q.boost {
+		return p.boost < q.boost
+	}
+	return p.priority < q.priority
 }
 
 type byTimestamp []*peerAddr
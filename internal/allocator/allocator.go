@@ -1,10 +1,17 @@
 package allocator
 
 import (
+	"errors"
+
 	"github.com/cenkalti/rain/internal/metainfo"
 	"github.com/cenkalti/rain/internal/storage"
 )
 
+// preallocateChunkSize is the number of bytes reserved on disk before reporting progress.
+const preallocateChunkSize = 64 << 20
+
+var errClosed = errors.New("allocator closed")
+
 // Allocator allocates files on the disk.
 type Allocator struct {
 	Files       []File
@@ -60,6 +67,10 @@ func (a *Allocator) Run(info *metainfo.Info, sto storage.Storage, progressC chan
 		}
 	}()
 
+	if is, ok := sto.(storage.InfoSetter); ok {
+		is.SetInfo(info)
+	}
+
 	var allocatedSize int64
 	a.Files = make([]File, len(info.Files))
 	for i, f := range info.Files {
@@ -79,11 +90,35 @@ func (a *Allocator) Run(info *metainfo.Info, sto storage.Storage, progressC chan
 			}
 		}
 		a.Files[i] = File{Storage: sf, Name: f.Path, Padding: f.Padding}
+		if pa, ok := sf.(storage.Preallocator); ok {
+			a.Error = a.preallocate(pa, f.Length, allocatedSize, progressC)
+			if a.Error != nil {
+				return
+			}
+		}
 		allocatedSize += f.Length
 		a.sendProgress(progressC, allocatedSize)
 	}
 }
 
+// preallocate reserves disk space for the file in chunks so that progress can be reported for large files.
+func (a *Allocator) preallocate(pa storage.Preallocator, length, allocatedSize int64, progressC chan Progress) error {
+	for offset := int64(0); offset < length; offset += preallocateChunkSize {
+		select {
+		case <-a.closeC:
+			return errClosed
+		default:
+		}
+		n := min(preallocateChunkSize, length-offset)
+		err := pa.Preallocate(offset, n)
+		if err != nil {
+			return err
+		}
+		a.sendProgress(progressC, allocatedSize+offset+n)
+	}
+	return nil
+}
+
 func (a *Allocator) sendProgress(progressC chan Progress, size int64) {
 	select {
 	case progressC <- Progress{AllocatedSize: size}:
@@ -49,6 +49,7 @@ type PeriodicalAnnouncer struct {
 	newPeers      chan []*net.TCPAddr
 	backoff       backoff.BackOff
 	getTorrent    func() tracker.Torrent
+	onError       func(*AnnounceError)
 	lastAnnounce  time.Time
 	nextAnnounce  time.Time
 	HasAnnounced  bool
@@ -63,7 +64,8 @@ type PeriodicalAnnouncer struct {
 }
 
 // NewPeriodicalAnnouncer returns a new PeriodicalAnnouncer.
-func NewPeriodicalAnnouncer(trk tracker.Tracker, numWant int, minInterval time.Duration, getTorrent func() tracker.Torrent, completedC chan struct{}, newPeers chan []*net.TCPAddr, l logger.Logger) *PeriodicalAnnouncer {
+// onError is called from the announcer goroutine when an announce fails. It may be nil.
+func NewPeriodicalAnnouncer(trk tracker.Tracker, numWant int, minInterval time.Duration, getTorrent func() tracker.Torrent, completedC chan struct{}, newPeers chan []*net.TCPAddr, onError func(*AnnounceError), l logger.Logger) *PeriodicalAnnouncer {
 	return &PeriodicalAnnouncer{
 		Tracker:        trk,
 		status:         NotContactedYet,
@@ -74,6 +76,7 @@ func NewPeriodicalAnnouncer(trk tracker.Tracker, numWant int, minInterval time.D
 		completedC:     completedC,
 		newPeers:       newPeers,
 		getTorrent:     getTorrent,
+		onError:        onError,
 		needMorePeersC: make(chan struct{}, 1),
 		responseC:      make(chan *tracker.AnnounceResponse),
 		errC:           make(chan error),
@@ -193,6 +196,9 @@ func (a *PeriodicalAnnouncer) Run() {
 			} else {
 				a.log.Debugln("announce error:", a.lastError.Err.Error())
 			}
+			if a.onError != nil {
+				a.onError(a.lastError)
+			}
 			interval := a.getNextIntervalFromError(a.lastError)
 			resetTimer(interval)
 		case <-a.needMorePeersC:
@@ -57,6 +57,7 @@ func Accept(
 				return
 			})
 		if err != nil {
+			err = &EncryptionError{err}
 			return
 		}
 		log.Debugf("Encryption handshake is successful. Selected cipher: %s", cipher)
@@ -68,12 +69,12 @@ func Accept(
 	}
 
 	if forceEncryption && !isEncrypted {
-		err = errNotEncrypted
+		err = ErrNotEncrypted
 		return
 	}
 
 	if !hasInfoHash(infoHash) {
-		err = errInvalidInfoHash
+		err = ErrInvalidInfoHash
 		return
 	}
 	err = writeHandshake(conn, infoHash, ourID, ourExtensions)
@@ -85,7 +86,7 @@ func Accept(
 		return
 	}
 	if peerID == ourID {
-		err = errOwnConnection
+		err = ErrOwnConnection
 		return
 	}
 	encConn = conn
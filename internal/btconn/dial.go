@@ -90,7 +90,7 @@ func Dial(
 			log.Debugln("Encrytpion handshake has failed:", err)
 			if forceEncryption {
 				log.Debug("Will not try again because ougoing encryption is forced.")
-				err = errNotEncrypted
+				err = ErrNotEncrypted
 				return
 			}
 
@@ -141,7 +141,7 @@ func Dial(
 		return
 	}
 	if ihRead != ih {
-		err = errInvalidInfoHash
+		err = ErrInvalidInfoHash
 		return
 	}
 
@@ -150,7 +150,7 @@ func Dial(
 		return
 	}
 	if peerID == ourID {
-		err = errOwnConnection
+		err = ErrOwnConnection
 		return
 	}
 	return
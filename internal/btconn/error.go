@@ -1,9 +1,13 @@
 package btconn
 
 var (
-	errInvalidInfoHash = &HandshakeError{"invalid info hash"}
-	errOwnConnection   = &HandshakeError{"dropped own connection"}
-	errNotEncrypted    = &HandshakeError{"connection is not encrypted"}
+	// ErrInvalidInfoHash is returned when the info hash in the handshake does not match.
+	ErrInvalidInfoHash = &HandshakeError{"invalid info hash"}
+	// ErrOwnConnection is returned when the peer ID in the handshake is our own ID.
+	ErrOwnConnection = &HandshakeError{"dropped own connection"}
+	// ErrNotEncrypted is returned when the encryption is forced but the peer does not support it.
+	ErrNotEncrypted = &HandshakeError{"connection is not encrypted"}
+
 	errInvalidProtocol = &HandshakeError{"invalid protocol"}
 )
 
@@ -15,3 +19,16 @@ type HandshakeError struct {
 func (e *HandshakeError) Error() string {
 	return e.message
 }
+
+// EncryptionError is an error while doing the encryption handshake.
+type EncryptionError struct {
+	Err error
+}
+
+func (e *EncryptionError) Error() string {
+	return "encryption handshake failed: " + e.Err.Error()
+}
+
+func (e *EncryptionError) Unwrap() error {
+	return e.Err
+}
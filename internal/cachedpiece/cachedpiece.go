@@ -34,12 +34,7 @@ func (c *CachedPiece) ReadAt(p []byte, off int64) (n int, err error) {
 		blkEnd = c.pi.Length
 	}
 
-	key := make([]byte, 20+4+4)
-	copy(key, c.peerID)
-	binary.BigEndian.PutUint32(key[20:24], c.pi.Index)
-	binary.BigEndian.PutUint32(key[24:28], blk)
-
-	buf, err := c.cache.Get(string(key), func() ([]byte, error) {
+	buf, err := c.cache.Get(cacheKey(c.peerID, c.pi.Index, blk), func() ([]byte, error) {
 		b := make([]byte, blkEnd-blkBegin)
 		_, err = c.pi.Data.ReadAt(b, int64(blkBegin))
 		return b, err
@@ -51,3 +46,28 @@ func (c *CachedPiece) ReadAt(p []byte, off int64) (n int, err error) {
 	begin := off - int64(blkBegin)
 	return copy(p, buf[begin:]), nil
 }
+
+// CachedPieces returns the indexes of the pieces that have at least one block in the cache.
+// Most recently read pieces come first.
+func CachedPieces(cache *piececache.Cache, peerID [20]byte) []uint32 {
+	keys := cache.Keys(string(peerID[:]))
+	ret := make([]uint32, 0, len(keys))
+	seen := make(map[uint32]struct{}, len(keys))
+	for _, key := range keys {
+		index := binary.BigEndian.Uint32([]byte(key[20:24]))
+		if _, ok := seen[index]; ok {
+			continue
+		}
+		seen[index] = struct{}{}
+		ret = append(ret, index)
+	}
+	return ret
+}
+
+func cacheKey(peerID []byte, index, blk uint32) string {
+	key := make([]byte, 20+4+4)
+	copy(key, peerID)
+	binary.BigEndian.PutUint32(key[20:24], index)
+	binary.BigEndian.PutUint32(key[24:28], blk)
+	return string(key)
+}
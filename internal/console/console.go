@@ -34,9 +34,9 @@ const (
 
 // Console is for drawing a text user interface for a remote Session.
 type Console struct {
-	client    *rainrpc.Client
-	columns   []string
-	needStats bool
+	client  *rainrpc.Client
+	columns []string
+	fields  []string
 
 	// protects global state in client
 	m sync.Mutex
@@ -58,12 +58,13 @@ type Console struct {
 	tabAdjust int
 
 	// fields to hold responsed from rpc requests
-	torrents     []Torrent
-	stats        rpctypes.Stats
-	sessionStats rpctypes.SessionStats
-	trackers     []rpctypes.Tracker
-	peers        []rpctypes.Peer
-	webseeds     []rpctypes.Webseed
+	torrents          []Torrent
+	stats             rpctypes.Stats
+	sessionStats      rpctypes.SessionStats
+	trackers          []rpctypes.Tracker
+	peers             []rpctypes.Peer
+	disconnectedPeers []rpctypes.Peer
+	webseeds          []rpctypes.Webseed
 
 	// whether details tab is currently updating state
 	updatingDetails bool
@@ -83,8 +84,7 @@ type Console struct {
 
 // Torrent in the ssession.
 type Torrent struct {
-	rpctypes.Torrent
-	Stats *rpctypes.Stats
+	rpctypes.TorrentWithStats
 }
 
 // New returns a new Console object that uses a RPC client to get information from a torrent.Session.
@@ -92,22 +92,30 @@ func New(clt *rainrpc.Client, columns []string) *Console {
 	return &Console{
 		client:          clt,
 		columns:         columns,
-		needStats:       columnsNeedStats(columns),
+		fields:          columnFields(columns),
 		updateTorrentsC: make(chan struct{}, 1),
 		updateDetailsC:  make(chan struct{}, 1),
 	}
 }
 
-func columnsNeedStats(columns []string) bool {
-	l := []string{"ID", "Name", "InfoHash", "Port"}
+// columnFields returns the fields to request from ListTorrentsWithStats for displaying the columns.
+func columnFields(columns []string) []string {
+	m := map[string][]string{
+		"Name":     {"Name"},
+		"InfoHash": {"InfoHash"},
+		"Port":     {"Port"},
+		"Status":   {"Status"},
+		"Speed":    {"Status", "SpeedDownload", "SpeedUpload"},
+		"ETA":      {"ETA"},
+		"Progress": {"Progress"},
+		"Ratio":    {"BytesDownloaded", "BytesUploaded"},
+		"Size":     {"BytesTotal"},
+	}
+	fields := []string{"ID"}
 	for _, c := range columns {
-		for _, d := range l {
-			if c != d {
-				return true
-			}
-		}
+		fields = append(fields, m[c]...)
 	}
-	return false
+	return fields
 }
 
 // Run the UI loop.
@@ -390,7 +398,6 @@ func getRow(columns []string, t Torrent, index int) string {
 		if i != 0 {
 			row += " "
 		}
-		stats := t.Stats
 		switch column {
 		case "#":
 			row += fmt.Sprintf("%3d", index+1)
@@ -403,48 +410,36 @@ func getRow(columns []string, t Torrent, index int) string {
 		case "Port":
 			row += fmt.Sprintf("%5d", t.Port)
 		case "Status":
-			if stats == nil {
-				row += fmt.Sprintf("%-11s", "")
-			} else {
-				status := stats.Status
-				if status == "Downloading Metadata" {
-					status = "Downloading"
-				}
-				row += fmt.Sprintf("%-11s", status)
+			status := t.Status
+			switch status {
+			case "Downloading Metadata":
+				status = "Downloading"
+			case "Low Disk Space":
+				status = "No Space"
 			}
+			row += fmt.Sprintf("%-11s", status)
 		case "Speed":
-			switch {
-			case stats == nil:
-				row += fmt.Sprintf("%8s", "")
-			case stats.Status == "Seeding":
-				row += fmt.Sprintf("%6d K", stats.Speed.Upload/1024)
-			default:
-				row += fmt.Sprintf("%6d K", stats.Speed.Download/1024)
+			if t.Status == "Seeding" {
+				row += fmt.Sprintf("%6d K", t.SpeedUpload/1024)
+			} else {
+				row += fmt.Sprintf("%6d K", t.SpeedDownload/1024)
 			}
 		case "ETA":
-			if stats == nil {
-				row += fmt.Sprintf("%8s", "")
-			} else {
-				row += fmt.Sprintf("%8s", getETA(stats))
+			var eta string
+			if t.ETA != nil && *t.ETA != -1 {
+				eta = (time.Duration(*t.ETA) * time.Second).String()
 			}
+			row += fmt.Sprintf("%8s", eta)
 		case "Progress":
-			if stats == nil {
-				row += fmt.Sprintf("%8s", "")
-			} else {
-				row += fmt.Sprintf("%8d", getProgress(stats))
-			}
+			row += fmt.Sprintf("%8d", t.Progress)
 		case "Ratio":
-			if stats == nil {
-				row += fmt.Sprintf("%5s", "")
-			} else {
-				row += fmt.Sprintf("%5.2f", getRatio(stats))
+			var ratio float64
+			if t.BytesDownloaded > 0 {
+				ratio = float64(t.BytesUploaded) / float64(t.BytesDownloaded)
 			}
+			row += fmt.Sprintf("%5.2f", ratio)
 		case "Size":
-			if stats == nil {
-				row += fmt.Sprintf("%8s", "")
-			} else {
-				row += fmt.Sprintf("%6d M", stats.Bytes.Total/(1<<20))
-			}
+			row += fmt.Sprintf("%6d M", t.BytesTotal/(1<<20))
 		default:
 			panic(fmt.Sprintf("unsupported column %s", column))
 		}
@@ -587,8 +582,8 @@ func (c *Console) drawDetails(g *gocui.Gui) error {
 				fmt.Fprintf(v, "    Last announce: %s, Next announce: %s\n", t.LastAnnounce.Time.Format(time.RFC3339), nextAnnounce)
 			}
 		case peers:
-			format := "%2s %21s %7s %8s %6s %s\n"
-			fmt.Fprintf(v, format, "#", "Addr", "Flags", "Download", "Upload", "Client")
+			format := "%2s %21s %7s %8s %6s %8s %8s %7s %4s %4s %5s %s\n"
+			fmt.Fprintf(v, format, "#", "Addr", "Flags", "Download", "Upload", "Received", "Sent", "Req", "Reqq", "Have", "Last", "Client")
 			for i, p := range c.peers {
 				num := fmt.Sprintf("%d", i+1)
 				var dl string
@@ -599,7 +594,29 @@ func (c *Console) drawDetails(g *gocui.Gui) error {
 				if p.UploadSpeed > 0 {
 					ul = fmt.Sprintf("%d", p.UploadSpeed/1024)
 				}
-				fmt.Fprintf(v, format, num, p.Addr, flags(p), dl, ul, p.Client)
+				var reqq string
+				if p.RequestQueue > 0 {
+					reqq = fmt.Sprintf("%d", p.RequestQueue)
+				}
+				var last string
+				if !p.LastMessageAt.IsZero() {
+					last = fmt.Sprintf("%ds", int(time.Since(p.LastMessageAt.Time).Seconds()))
+				}
+				req := fmt.Sprintf("%d/%d", p.RequestsIn, p.RequestsOut)
+				have := fmt.Sprintf("%d%%", p.Completion)
+				fmt.Fprintf(v, format, num, p.Addr, flags(p), dl, ul, formatKiB(p.BytesDownloaded), formatKiB(p.BytesUploaded), req, reqq, have, last, p.Client)
+			}
+			if len(c.disconnectedPeers) > 0 {
+				fmt.Fprintln(v)
+				fmt.Fprintln(v, "Recently disconnected:")
+				format = "%2s %21s %8s %8s %8s %s\n"
+				fmt.Fprintf(v, format, "#", "Addr", "Received", "Sent", "Ago", "Reason")
+				for i := len(c.disconnectedPeers) - 1; i >= 0; i-- {
+					p := c.disconnectedPeers[i]
+					num := fmt.Sprintf("%d", len(c.disconnectedPeers)-i)
+					ago := fmt.Sprintf("%ds", int(time.Since(p.DisconnectedAt.Time).Seconds()))
+					fmt.Fprintf(v, format, num, p.Addr, formatKiB(p.BytesDownloaded), formatKiB(p.BytesUploaded), ago, p.DisconnectReason)
+				}
 			}
 		case webseeds:
 			format := "%2s %40s %8s %s\n"
@@ -657,36 +674,12 @@ func (c *Console) updateSessionStatsLoop(g *gocui.Gui, stop chan struct{}) {
 }
 
 func (c *Console) updateTorrents(g *gocui.Gui) {
-	rpcTorrents, err := c.client.ListTorrents()
-
-	sort.Slice(rpcTorrents, func(i, j int) bool {
-		a, b := rpcTorrents[i], rpcTorrents[j]
-		if a.AddedAt.Equal(b.AddedAt.Time) {
-			return a.ID < b.ID
-		}
-		return a.AddedAt.Time.Before(b.AddedAt.Time)
-	})
+	// Torrents are sorted by the time they are added.
+	rpcTorrents, _, err := c.client.ListTorrentsWithStats(c.fields, 0, 0)
 
 	torrents := make([]Torrent, 0, len(rpcTorrents))
 	for _, t := range rpcTorrents {
-		torrents = append(torrents, Torrent{Torrent: t})
-	}
-
-	// Get torrent stats in parallel
-	if c.needStats {
-		inside := c.rowsInsideView(g)
-		var wg sync.WaitGroup
-		for _, i := range inside {
-			if i < len(torrents) {
-				t := &torrents[i]
-				wg.Add(1)
-				go func(t *Torrent) {
-					t.Stats, _ = c.client.GetTorrentStats(t.ID)
-					wg.Done()
-				}(t)
-			}
-		}
-		wg.Wait()
+		torrents = append(torrents, Torrent{TorrentWithStats: t})
 	}
 
 	c.m.Lock()
@@ -702,27 +695,6 @@ func (c *Console) updateTorrents(g *gocui.Gui) {
 	g.Update(c.drawTorrents)
 }
 
-func (c *Console) rowsInsideView(g *gocui.Gui) []int {
-	c.m.Lock()
-	defer c.m.Unlock()
-	v, err := g.View("torrents")
-	if err != nil {
-		return nil
-	}
-	if c.errTorrents != nil {
-		return nil
-	}
-	_, maxY := g.Size()
-	halfY := maxY / 2
-	split := halfY + c.tabAdjust
-	_, oy := v.Origin()
-	var ret []int
-	for i := oy; i < oy+split-2; i++ {
-		ret = append(ret, i)
-	}
-	return ret
-}
-
 func (c *Console) updateDetails(g *gocui.Gui) {
 	c.m.Lock()
 	selectedID := c.selectedID
@@ -755,8 +727,13 @@ func (c *Console) updateDetails(g *gocui.Gui) {
 			}
 			return a.ConnectedAt.Time.Before(b.ConnectedAt.Time)
 		})
+		var disconnectedPeers []rpctypes.Peer
+		if err == nil {
+			disconnectedPeers, err = c.client.GetTorrentDisconnectedPeers(selectedID)
+		}
 		c.m.Lock()
 		c.peers = peers
+		c.disconnectedPeers = disconnectedPeers
 		c.errDetails = err
 		c.m.Unlock()
 	case webseeds:
@@ -1092,6 +1069,14 @@ func (c *Console) triggerUpdateTorrents() {
 	}
 }
 
+// formatKiB formats bytes in KiB. Returns empty string for zero.
+func formatKiB(n int64) string {
+	if n == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d", n/1024)
+}
+
 func flags(p rpctypes.Peer) string {
 	var sb strings.Builder
 	sb.Grow(6)
@@ -1140,6 +1125,10 @@ func flags(p rpctypes.Peer) string {
 		sb.WriteString("I")
 	case "MANUAL":
 		sb.WriteString("M")
+	case "CACHE":
+		sb.WriteString("C")
+	case "HOLEPUNCH":
+		sb.WriteString("P")
 	default:
 		sb.WriteString(" ")
 	}
@@ -1219,11 +1208,46 @@ func FormatStats(stats *rpctypes.Stats, v io.Writer) {
 	fmt.Fprintf(v, "Ratio: %.2f\n", getRatio(stats))
 	fmt.Fprintf(v, "Size: %s\n", getSize(stats))
 	fmt.Fprintf(v, "Peers: %d in / %d out\n", stats.Peers.Incoming, stats.Peers.Outgoing)
+	fmt.Fprintf(v, "Connection attempts: %s\n", getConnectionAttempts(stats))
 	fmt.Fprintf(v, "Download speed: %11s\n", getDownloadSpeed(stats))
 	fmt.Fprintf(v, "Upload speed:   %11s\n", getUploadSpeed(stats))
 	fmt.Fprintf(v, "ETA: %s\n", getETA(stats))
 }
 
+func getConnectionAttempts(stats *rpctypes.Stats) string {
+	a := stats.ConnectionAttempts
+	counts := []struct {
+		name  string
+		count int
+	}{
+		{"succeeded", a.Succeeded},
+		{"blocklisted", a.Blocklisted},
+		{"banned", a.Banned},
+		{"peer limit", a.PeerLimit},
+		{"duplicate", a.Duplicate},
+		{"dial failed", a.DialFailed},
+		{"timeout", a.Timeout},
+		{"closed", a.Closed},
+		{"encryption failed", a.EncryptionFailed},
+		{"info hash mismatch", a.InfoHashMismatch},
+		{"protocol error", a.ProtocolError},
+		{"error", a.Error},
+	}
+	var parts []string
+	for _, c := range counts {
+		if c.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", c.count, c.name))
+		}
+	}
+	if a.DialLimitReached {
+		parts = append(parts, "dial limit reached")
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
+
 // FormatSessionStats returns the human readable representation of session stats object.
 func FormatSessionStats(s *rpctypes.SessionStats, v io.Writer) {
 	fmt.Fprintf(v, "Torrents: %d, Peers: %d, Uptime: %s\n", s.Torrents, s.Peers, time.Duration(s.Uptime)*time.Second)
@@ -1232,6 +1256,7 @@ func FormatSessionStats(s *rpctypes.SessionStats, v io.Writer) {
 	fmt.Fprintf(v, "Writes: %d/s, %dKB/s, Active: %d, Pending: %d\n", s.WritesPerSecond, s.SpeedWrite/1024, s.WritesActive, s.WritesPending)
 	fmt.Fprintf(v, "ReadCache Objects: %d, Size: %dMB, Utilization: %d%%\n", s.ReadCacheObjects, s.ReadCacheSize/(1<<20), s.ReadCacheUtilization)
 	fmt.Fprintf(v, "WriteCache Objects: %d, Size: %dMB, PendingKeys: %d\n", s.WriteCacheObjects, s.WriteCacheSize/(1<<20), s.WriteCachePendingKeys)
+	fmt.Fprintf(v, "SuggestPiece Sent: %d, Received: %d, Requested: %d\n", s.SuggestsSent, s.SuggestsReceived, s.SuggestedRequests)
 	fmt.Fprintf(v, "DownloadSpeed: %dKB/s, UploadSpeed: %dKB/s\n", s.SpeedDownload/1024, s.SpeedUpload/1024)
 	fmt.Fprintf(v, "BytesDownloaded: %dMB, BytesUploaded: %dMB\n", s.BytesDownloaded/1024/1024, s.BytesUploaded/1024/1024)
 	fmt.Fprintf(v, "BytesRead: %dMB, BytesWritten: %dMB\n", s.BytesRead/1024/1024, s.BytesWritten/1024/1024)
@@ -0,0 +1,31 @@
+// Package diskspace provides a function for getting free space on the disk.
+package diskspace
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Free returns the number of bytes available to unprivileged users on the filesystem that contains path.
+// If path does not exist yet, the nearest existing parent directory is used.
+func Free(path string) (int64, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return 0, err
+	}
+	for {
+		_, err = os.Stat(path)
+		if !os.IsNotExist(err) {
+			break
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			break
+		}
+		path = parent
+	}
+	if err != nil {
+		return 0, err
+	}
+	return free(path)
+}
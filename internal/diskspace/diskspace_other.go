@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !freebsd && !dragonfly && !windows
+
+package diskspace
+
+import "errors"
+
+func free(path string) (int64, error) {
+	return 0, errors.New("getting free disk space is not supported on this platform")
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin || freebsd || dragonfly
+
+package diskspace
+
+import "golang.org/x/sys/unix"
+
+func free(path string) (int64, error) {
+	var st unix.Statfs_t
+	err := unix.Statfs(path, &st)
+	if err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil // nolint: unconvert
+}
@@ -0,0 +1,16 @@
+package diskspace
+
+import "golang.org/x/sys/windows"
+
+func free(path string) (int64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var available, total, totalFree uint64
+	err = windows.GetDiskFreeSpaceEx(p, &available, &total, &totalFree)
+	if err != nil {
+		return 0, err
+	}
+	return int64(available), nil
+}
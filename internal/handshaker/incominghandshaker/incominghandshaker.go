@@ -1,6 +1,7 @@
 package incominghandshaker
 
 import (
+	"errors"
 	"io"
 	"net"
 	"time"
@@ -53,9 +54,9 @@ func (h *IncomingHandshaker) Run(peerID [20]byte, getSKeyFunc func([20]byte) []b
 	conn, cipher, peerExtensions, peerID, _, err := btconn.Accept(
 		h.Conn, timeout, getSKeyFunc, forceIncomingEncryption, checkInfoHashFunc, ourExtensions, peerID)
 	if err != nil {
-		if err == io.EOF {
+		if errors.Is(err, io.EOF) {
 			log.Debug("peer has closed the connection: EOF")
-		} else if err == io.ErrUnexpectedEOF {
+		} else if errors.Is(err, io.ErrUnexpectedEOF) {
 			log.Debug("peer has closed the connection: Unexpected EOF")
 		} else if _, ok := err.(*net.OpError); ok {
 			log.Debugln("net operation error:", err)
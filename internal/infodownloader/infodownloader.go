@@ -81,6 +81,11 @@ func (d *InfoDownloader) RequestBlocks(queueLength int) {
 	}
 }
 
+// Pending returns the number of metadata requests that are sent to the peer and waiting for a response.
+func (d *InfoDownloader) Pending() int {
+	return d.pending
+}
+
 // Done returns true if all pieces of the metadata is downloaded.
 func (d *InfoDownloader) Done() bool {
 	return d.nextBlockIndex == uint32(len(d.blocks)) && d.pending == 0
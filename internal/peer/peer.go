@@ -3,6 +3,7 @@ package peer
 import (
 	"math"
 	"net"
+	"sort"
 	"time"
 
 	"github.com/cenkalti/rain/internal/bitfield"
@@ -33,6 +34,8 @@ type Peer struct {
 	Bitfield            *bitfield.Bitfield
 	ReceivedAllowedFast sliceset.SliceSet[piece.Piece]
 	SentAllowedFast     sliceset.SliceSet[piece.Piece]
+	ReceivedSuggested   sliceset.SliceSet[piece.Piece]
+	SentSuggested       sliceset.SliceSet[piece.Piece]
 
 	ID                [20]byte
 	ExtensionsEnabled bool
@@ -189,6 +192,30 @@ func (p *Peer) StartPEX(initialPeers map[*Peer]struct{}, recentlySeen *pexlist.R
 	}
 }
 
+// PEXFlags returns the flags of the Peer to be sent to other peers in PEX messages.
+func (p *Peer) PEXFlags() byte {
+	return PEXFlags(p.Source, p.ExtensionHandshake)
+}
+
+// PEXFlags returns the flags of a peer that is found from source and sent the extension handshake.
+// Extension handshake may be nil if it is not received yet.
+func PEXFlags(source peersource.Source, extHandshake *peerprotocol.ExtensionHandshakeMessage) byte {
+	var flags byte
+	if source != peersource.Incoming && source != peersource.Holepunch {
+		// We have connected to the peer so other peers can connect too.
+		flags |= peerprotocol.PEXFlagReachable
+	}
+	if extHandshake != nil {
+		if id, ok := extHandshake.M[peerprotocol.ExtensionKeyHolepunch]; ok && id != 0 {
+			flags |= peerprotocol.PEXFlagHolepunch
+		}
+		if extHandshake.UploadOnly {
+			flags |= peerprotocol.PEXFlagSeed
+		}
+	}
+	return flags
+}
+
 // ResetSnubTimer is called when some data received from the Peer.
 func (p *Peer) ResetSnubTimer() {
 	p.snubTimer.Reset(p.snubTimeout)
@@ -209,6 +236,40 @@ func (p *Peer) UploadSpeed() int {
 	return int(p.uploadSpeed.Rate1())
 }
 
+// NumPieces returns the number of pieces that the Peer has.
+func (p *Peer) NumPieces() int {
+	if p.Bitfield == nil {
+		return 0
+	}
+	return int(p.Bitfield.Count())
+}
+
+// BytesDownloaded returns the number of piece bytes received from the Peer.
+func (p *Peer) BytesDownloaded() int64 {
+	return p.downloadSpeed.Count()
+}
+
+// BytesUploaded returns the number of piece bytes sent to the Peer.
+func (p *Peer) BytesUploaded() int64 {
+	return p.uploadSpeed.Count()
+}
+
+// Extensions returns the sorted names of the extensions that are enabled in the extension handshake of the Peer.
+func (p *Peer) Extensions() []string {
+	if p.ExtensionHandshake == nil {
+		return nil
+	}
+	names := make([]string, 0, len(p.ExtensionHandshake.M))
+	for name, id := range p.ExtensionHandshake.M {
+		// ID 0 means the extension is disabled.
+		if id != 0 {
+			names = append(names, stringutil.Printable(name))
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Choke the connected Peer by sending a "choke" protocol message.
 func (p *Peer) Choke() {
 	p.ClientChoking = true
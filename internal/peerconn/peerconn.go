@@ -85,6 +85,22 @@ func (p *Conn) CancelRequest(msg peerprotocol.CancelMessage) {
 	p.writer.CancelRequest(msg)
 }
 
+// Err returns the error that caused the connection to be closed.
+// Must be called after the channel returned from Messages is closed.
+func (p *Conn) Err() error {
+	return p.reader.Err()
+}
+
+// LastMessageAt returns the time of the last message received from the peer.
+func (p *Conn) LastMessageAt() time.Time {
+	return p.reader.LastMessageAt()
+}
+
+// QueuedRequests returns the number of requests received from the peer that are waiting to be served.
+func (p *Conn) QueuedRequests() int {
+	return p.writer.QueuedRequests()
+}
+
 // Run starts receiving messages from peer and starts sending queued messages.
 // If any error happens during receiving or sending messages,
 // the connection and the underlying net.Conn will be closed.
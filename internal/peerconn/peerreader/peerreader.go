@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/rain/internal/bufferpool"
@@ -35,8 +36,12 @@ type PeerReader struct {
 	pieceTimeout time.Duration
 	bucket       *ratelimit.Bucket
 	messages     chan any
-	stopC        chan struct{}
-	doneC        chan struct{}
+	// Unix time in nanoseconds when the last message (including keep-alive) is read.
+	lastMessageAt atomic.Int64
+	// Error that caused the read loop to end. Set before doneC is closed.
+	err   error
+	stopC chan struct{}
+	doneC chan struct{}
 }
 
 // New returns a new PeerReader by wrapping a net.Conn.
@@ -68,12 +73,29 @@ func (p *PeerReader) Done() chan struct{} {
 	return p.doneC
 }
 
+// Err returns the error that caused the read loop to end.
+// Must be called after the channel returned from Done is closed.
+func (p *PeerReader) Err() error {
+	return p.err
+}
+
+// LastMessageAt returns the time of the last message read from the connection.
+// Returns zero time if no message is read yet.
+func (p *PeerReader) LastMessageAt() time.Time {
+	ns := p.lastMessageAt.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
 // Run the read loop.
 func (p *PeerReader) Run() {
 	defer close(p.doneC)
 
 	var err error
 	defer func() {
+		p.err = err
 		if err == nil {
 			return
 		} else if err == io.EOF { // peer closed the connection
@@ -109,6 +131,7 @@ func (p *PeerReader) Run() {
 			return
 		}
 		// p.log.Debugf("Received message of length: %d", length)
+		p.lastMessageAt.Store(time.Now().UnixNano())
 
 		if length == 0 { // keep-alive message
 			p.log.Debug("Received message of type \"keep alive\"")
@@ -218,6 +241,13 @@ func (p *PeerReader) Run() {
 				return
 			}
 			msg = am
+		case peerprotocol.Suggest:
+			var sm peerprotocol.SuggestPieceMessage
+			err = binary.Read(p.r, binary.BigEndian, &sm)
+			if err != nil {
+				return
+			}
+			msg = sm
 		case peerprotocol.Port:
 			var pm peerprotocol.PortMessage
 			err = binary.Read(p.r, binary.BigEndian, &pm)
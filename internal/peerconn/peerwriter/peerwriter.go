@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"io"
 	"net"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/rain/internal/logger"
@@ -24,7 +25,7 @@ type PeerWriter struct {
 	writeQueue            *list.List
 	maxQueuedRequests     int
 	fastEnabled           bool
-	currentQueuedRequests int
+	currentQueuedRequests atomic.Int32
 	writeC                chan peerprotocol.Message
 	messages              chan any
 	servedRequests        map[peerprotocol.RequestMessage]struct{}
@@ -85,6 +86,11 @@ func (p *PeerWriter) CancelRequest(msg peerprotocol.CancelMessage) {
 	}
 }
 
+// QueuedRequests returns the number of "piece" messages that are queued for sending.
+func (p *PeerWriter) QueuedRequests() int {
+	return int(p.currentQueuedRequests.Load())
+}
+
 // Stop the writer loop.
 func (p *PeerWriter) Stop() {
 	close(p.stopC)
@@ -118,7 +124,7 @@ func (p *PeerWriter) Run() {
 		case writeC <- msg:
 			p.writeQueue.Remove(e)
 			if _, ok := msg.(Piece); ok {
-				p.currentQueuedRequests--
+				p.currentQueuedRequests.Add(-1)
 			}
 		case cm := <-p.cancelC:
 			p.cancelRequest(cm)
@@ -134,7 +140,7 @@ func (p *PeerWriter) queueMessage(msg peerprotocol.Message) {
 		p.cancelQueuedPieceMessages()
 	case Piece:
 		// Reject request if peer queued to many requests
-		if p.currentQueuedRequests >= p.maxQueuedRequests {
+		if int(p.currentQueuedRequests.Load()) >= p.maxQueuedRequests {
 			if p.fastEnabled {
 				msg = peerprotocol.RejectMessage{RequestMessage: msg2.RequestMessage}
 				break
@@ -143,7 +149,7 @@ func (p *PeerWriter) queueMessage(msg peerprotocol.Message) {
 				return
 			}
 		}
-		p.currentQueuedRequests++
+		p.currentQueuedRequests.Add(1)
 	}
 	p.writeQueue.PushBack(msg)
 }
@@ -154,7 +160,7 @@ func (p *PeerWriter) cancelQueuedPieceMessages() {
 		next = e.Next()
 		if _, ok := e.Value.(Piece); ok {
 			p.writeQueue.Remove(e)
-			p.currentQueuedRequests--
+			p.currentQueuedRequests.Add(-1)
 		}
 	}
 }
@@ -163,7 +169,7 @@ func (p *PeerWriter) cancelRequest(cm peerprotocol.CancelMessage) {
 	for e := p.writeQueue.Front(); e != nil; e = e.Next() {
 		if pi, ok := e.Value.(Piece); ok && pi.Index == cm.Index && pi.Begin == cm.Begin && pi.Length == cm.Length {
 			p.writeQueue.Remove(e)
-			p.currentQueuedRequests--
+			p.currentQueuedRequests.Add(-1)
 			break
 		}
 	}
@@ -17,6 +17,8 @@ const (
 	ExtensionIDMetadata
 	// ExtensionIDPEX is ID for PEX extension messages.
 	ExtensionIDPEX
+	// ExtensionIDHolepunch is ID for holepunch extension messages.
+	ExtensionIDHolepunch
 )
 
 const (
@@ -24,6 +26,8 @@ const (
 	ExtensionKeyMetadata = "ut_metadata"
 	// ExtensionKeyPEX is the key for the PEX extension.
 	ExtensionKeyPEX = "ut_pex"
+	// ExtensionKeyHolepunch is the key for the holepunch extension.
+	ExtensionKeyHolepunch = "ut_holepunch"
 )
 
 const (
@@ -56,6 +60,16 @@ func (m ExtensionMessage) WriteTo(w io.Writer) (n int64, err error) {
 	if err != nil {
 		return
 	}
+	if hm, ok := m.Payload.(ExtensionHolepunchMessage); ok {
+		var b []byte
+		b, err = hm.MarshalBinary()
+		if err != nil {
+			return
+		}
+		nn, err = w.Write(b)
+		n += int64(nn)
+		return
+	}
 	wc := newWriterCounter(w)
 	err = bencode.NewEncoder(wc).Encode(m.Payload)
 	n += wc.Count()
@@ -100,6 +114,10 @@ func (m *ExtensionMessage) UnmarshalBinary(data []byte) error {
 		var extMsg ExtensionPEXMessage
 		err = dec.Decode(&extMsg)
 		m.Payload = extMsg
+	case ExtensionIDHolepunch:
+		var extMsg ExtensionHolepunchMessage
+		err = extMsg.UnmarshalBinary(payload)
+		m.Payload = extMsg
 	default:
 		return fmt.Errorf("peer sent invalid extension message id: %d", m.ExtendedMessageID)
 	}
@@ -113,19 +131,23 @@ type ExtensionHandshakeMessage struct {
 	YourIP       string           `bencode:"yourip,omitempty"`
 	MetadataSize int              `bencode:"metadata_size,omitempty"`
 	RequestQueue int              `bencode:"reqq"`
+	UploadOnly   bool             `bencode:"upload_only,omitempty"`
 }
 
 // NewExtensionHandshake returns a new ExtensionHandshakeMessage by filling the struct with given values.
-func NewExtensionHandshake(metadataSize uint32, version string, yourip net.IP, requestQueueLength int) ExtensionHandshakeMessage {
+// uploadOnly tells the peer that we are not going to download any pieces (BEP 21).
+func NewExtensionHandshake(metadataSize uint32, version string, yourip net.IP, requestQueueLength int, uploadOnly bool) ExtensionHandshakeMessage {
 	return ExtensionHandshakeMessage{
 		M: map[string]uint8{
-			ExtensionKeyMetadata: ExtensionIDMetadata,
-			ExtensionKeyPEX:      ExtensionIDPEX,
+			ExtensionKeyMetadata:  ExtensionIDMetadata,
+			ExtensionKeyPEX:       ExtensionIDPEX,
+			ExtensionKeyHolepunch: ExtensionIDHolepunch,
 		},
 		V:            version,
 		YourIP:       string(truncateIP(yourip)),
 		MetadataSize: int(metadataSize),
 		RequestQueue: requestQueueLength,
+		UploadOnly:   uploadOnly,
 	}
 }
 
@@ -137,10 +159,25 @@ type ExtensionMetadataMessage struct {
 	Data      []byte `bencode:"-"`
 }
 
+// Flags of the peers in "added.f" field of PEX messages.
+const (
+	// PEXFlagPrefersEncryption is set if the peer prefers encrypted connections.
+	PEXFlagPrefersEncryption = 0x01
+	// PEXFlagSeed is set if the peer is a seed or a partial seed.
+	PEXFlagSeed = 0x02
+	// PEXFlagUTP is set if the peer supports uTP.
+	PEXFlagUTP = 0x04
+	// PEXFlagHolepunch is set if the peer supports the holepunch extension.
+	PEXFlagHolepunch = 0x08
+	// PEXFlagReachable is set if the peer accepts incoming connections.
+	PEXFlagReachable = 0x10
+)
+
 // ExtensionPEXMessage is the message for the PEX extension.
 type ExtensionPEXMessage struct {
-	Added   string `bencode:"added"`
-	Dropped string `bencode:"dropped"`
+	Added      string `bencode:"added"`
+	AddedFlags string `bencode:"added.f,omitempty"`
+	Dropped    string `bencode:"dropped"`
 }
 
 func truncateIP(ip net.IP) net.IP {
@@ -0,0 +1,106 @@
+package peerprotocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+)
+
+// Types of holepunch extension messages (BEP 55).
+const (
+	// HolepunchRendezvous is sent to a relay peer to ask for connecting to the target peer.
+	HolepunchRendezvous uint8 = iota
+	// HolepunchConnect is sent by the relay peer to both ends to make them connect to each other at the same time.
+	HolepunchConnect
+	// HolepunchError is sent by the relay peer if the rendezvous cannot be done.
+	HolepunchError
+)
+
+// Error codes of holepunch extension messages.
+const (
+	// HolepunchErrNoSuchPeer means that the target endpoint is invalid.
+	HolepunchErrNoSuchPeer uint32 = iota + 1
+	// HolepunchErrNotConnected means that the relay is not connected to the target peer.
+	HolepunchErrNotConnected
+	// HolepunchErrNoSupport means that the target peer does not support the holepunch extension.
+	HolepunchErrNoSupport
+	// HolepunchErrNoSelf means that the target endpoint belongs to the relay peer.
+	HolepunchErrNoSelf
+)
+
+const (
+	holepunchAddrIPv4 = 0
+	holepunchAddrIPv6 = 1
+)
+
+var errInvalidHolepunchMessage = errors.New("invalid holepunch message")
+
+// ExtensionHolepunchMessage is the message for the holepunch extension.
+// Unlike other extension messages, it is not bencoded.
+type ExtensionHolepunchMessage struct {
+	Type  uint8
+	Addr  *net.TCPAddr
+	Error uint32
+}
+
+// MarshalBinary encodes the message in the binary form that is sent on the wire.
+func (m ExtensionHolepunchMessage) MarshalBinary() ([]byte, error) {
+	addrType := uint8(holepunchAddrIPv4)
+	ip := m.Addr.IP.To4()
+	if ip == nil {
+		addrType = holepunchAddrIPv6
+		ip = m.Addr.IP.To16()
+	}
+	if ip == nil {
+		return nil, errInvalidHolepunchMessage
+	}
+	b := make([]byte, 0, 2+len(ip)+2+4)
+	b = append(b, m.Type, addrType)
+	b = append(b, ip...)
+	b = binary.BigEndian.AppendUint16(b, uint16(m.Addr.Port))
+	b = binary.BigEndian.AppendUint32(b, m.Error)
+	return b, nil
+}
+
+// UnmarshalBinary decodes the message from the binary form that is received from the wire.
+func (m *ExtensionHolepunchMessage) UnmarshalBinary(data []byte) error {
+	if len(data) < 2 {
+		return errInvalidHolepunchMessage
+	}
+	m.Type = data[0]
+	var ipLen int
+	switch data[1] {
+	case holepunchAddrIPv4:
+		ipLen = net.IPv4len
+	case holepunchAddrIPv6:
+		ipLen = net.IPv6len
+	default:
+		return errInvalidHolepunchMessage
+	}
+	data = data[2:]
+	if len(data) != ipLen+2+4 {
+		return errInvalidHolepunchMessage
+	}
+	ip := make(net.IP, ipLen)
+	copy(ip, data)
+	m.Addr = &net.TCPAddr{IP: ip, Port: int(binary.BigEndian.Uint16(data[ipLen:]))}
+	m.Error = binary.BigEndian.Uint32(data[ipLen+2:])
+	return nil
+}
+
+// HolepunchErrorString returns a description of the error code in holepunch error message.
+func HolepunchErrorString(code uint32) string {
+	switch code {
+	case HolepunchErrNoSuchPeer:
+		return "no such peer"
+	case HolepunchErrNotConnected:
+		return "not connected"
+	case HolepunchErrNoSupport:
+		return "no support"
+	case HolepunchErrNoSelf:
+		return "no self"
+	default:
+		return "unknown error: " + strconv.FormatUint(uint64(code), 10)
+	}
+}
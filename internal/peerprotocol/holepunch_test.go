@@ -0,0 +1,45 @@
+package peerprotocol
+
+import (
+	"bytes"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHolepunchMessage(t *testing.T) {
+	cases := []ExtensionHolepunchMessage{
+		{Type: HolepunchRendezvous, Addr: &net.TCPAddr{IP: net.IPv4(1, 2, 3, 4).To4(), Port: 6881}},
+		{Type: HolepunchError, Addr: &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 51413}, Error: HolepunchErrNoSupport},
+	}
+	for _, msg := range cases {
+		b, err := msg.MarshalBinary()
+		require.NoError(t, err)
+		var msg2 ExtensionHolepunchMessage
+		require.NoError(t, msg2.UnmarshalBinary(b))
+		assert.Equal(t, msg, msg2)
+	}
+
+	b, err := cases[0].MarshalBinary()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0, 0, 1, 2, 3, 4, 0x1a, 0xe1, 0, 0, 0, 0}, b)
+
+	var msg ExtensionHolepunchMessage
+	assert.Error(t, msg.UnmarshalBinary(b[:len(b)-1]))
+	assert.Error(t, msg.UnmarshalBinary([]byte{0, 2, 1, 2, 3, 4, 0x1a, 0xe1, 0, 0, 0, 0}))
+}
+
+func TestHolepunchExtensionMessage(t *testing.T) {
+	msg := ExtensionMessage{
+		ExtendedMessageID: ExtensionIDHolepunch,
+		Payload:           ExtensionHolepunchMessage{Type: HolepunchConnect, Addr: &net.TCPAddr{IP: net.IPv4(1, 2, 3, 4).To4(), Port: 6881}},
+	}
+	var buf bytes.Buffer
+	_, err := msg.WriteTo(&buf)
+	require.NoError(t, err)
+	var msg2 ExtensionMessage
+	require.NoError(t, msg2.UnmarshalBinary(buf.Bytes()))
+	assert.Equal(t, msg, msg2)
+}
@@ -97,6 +97,15 @@ func (m emptyMessage) Read(b []byte) (int, error) {
 // AllowedFastMessage is sent to tell a peer that it can download pieces regardless of choking status.
 type AllowedFastMessage struct{ HaveMessage }
 
+// ID returns the peer protocol message type. It must be defined, otherwise ID of HaveMessage is used.
+func (m AllowedFastMessage) ID() MessageID { return AllowedFast }
+
+// SuggestPieceMessage is sent to tell a peer that downloading the piece may be faster, e.g. it is in our read cache.
+type SuggestPieceMessage struct{ HaveMessage }
+
+// ID returns the peer protocol message type. It must be defined, otherwise ID of HaveMessage is used.
+func (m SuggestPieceMessage) ID() MessageID { return Suggest }
+
 // ChokeMessage is sent to peer that it should not request pieces.
 type ChokeMessage struct{ emptyMessage }
 
@@ -0,0 +1,37 @@
+package peerprotocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMessageIDs(t *testing.T) {
+	// Messages embedding another message type must not use the ID of the embedded type.
+	assert.Equal(t, MessageID(AllowedFast), AllowedFastMessage{}.ID())
+	assert.Equal(t, MessageID(Reject), RejectMessage{}.ID())
+	assert.Equal(t, MessageID(Cancel), CancelMessage{}.ID())
+}
+
+func TestAllowedFastMessageRoundTrip(t *testing.T) {
+	var msg Message = AllowedFastMessage{HaveMessage{Index: 42}}
+
+	// Encode as it is sent on the wire: ID followed by the payload.
+	var buf bytes.Buffer
+	buf.WriteByte(byte(msg.ID()))
+	payload := make([]byte, 4)
+	n, err := msg.Read(payload)
+	require.Equal(t, io.EOF, err)
+	buf.Write(payload[:n])
+
+	id, err := buf.ReadByte()
+	require.NoError(t, err)
+	assert.Equal(t, MessageID(AllowedFast), MessageID(id))
+	var am AllowedFastMessage
+	require.NoError(t, binary.Read(&buf, binary.BigEndian, &am))
+	assert.Equal(t, msg, am)
+}
@@ -14,6 +14,10 @@ const (
 	Manual
 	// Incoming indicates that the peer found us. We did not found the peer.
 	Incoming
+	// Cache indicates that the peer is loaded from the cache of peers that we have connected before.
+	Cache
+	// Holepunch indicates that the peer is connected with the help of another peer (BEP 55).
+	Holepunch
 )
 
 func (s Source) String() string {
@@ -28,7 +32,21 @@ func (s Source) String() string {
 		return "manual"
 	case Incoming:
 		return "incoming"
+	case Cache:
+		return "cache"
+	case Holepunch:
+		return "holepunch"
 	default:
 		panic("unhandled source")
 	}
 }
+
+// Parse returns the Source with the name that is returned from String method.
+func Parse(name string) (Source, bool) {
+	for s := Tracker; s <= Holepunch; s++ {
+		if s.String() == name {
+			return s, true
+		}
+	}
+	return 0, false
+}
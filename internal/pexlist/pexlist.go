@@ -15,8 +15,9 @@ const (
 
 // PEXList contains the list of peer address for sending them to a peer at certain interval.
 // List contains 2 separate lists for added and dropped addresses.
+// Added addresses are kept with their flags.
 type PEXList struct {
-	added   map[tracker.CompactPeer]struct{}
+	added   map[tracker.CompactPeer]byte
 	dropped map[tracker.CompactPeer]struct{}
 	flushed bool
 }
@@ -24,7 +25,7 @@ type PEXList struct {
 // New returns a new empty PEXList.
 func New() *PEXList {
 	return &PEXList{
-		added:   make(map[tracker.CompactPeer]struct{}),
+		added:   make(map[tracker.CompactPeer]byte),
 		dropped: make(map[tracker.CompactPeer]struct{}),
 	}
 }
@@ -39,9 +40,10 @@ func NewWithRecentlySeen(rs []tracker.CompactPeer) *PEXList {
 }
 
 // Add adds the address to the added part and removes from dropped part.
-func (l *PEXList) Add(addr *net.TCPAddr) {
+// Flags of the address are sent in "added.f" field of the PEX message.
+func (l *PEXList) Add(addr *net.TCPAddr, flags byte) {
 	p := tracker.NewCompactPeer(addr)
-	l.added[p] = struct{}{}
+	l.added[p] = flags
 	delete(l.dropped, p)
 }
 
@@ -53,13 +55,39 @@ func (l *PEXList) Drop(addr *net.TCPAddr) {
 }
 
 // Flush returns added and dropped parts and empty the list.
-func (l *PEXList) Flush() (added, dropped string) {
-	added = l.flush(l.added, l.flushed)
+func (l *PEXList) Flush() (added, addedFlags, dropped string) {
+	added, addedFlags = l.flushAdded(l.flushed)
 	dropped = l.flush(l.dropped, l.flushed)
 	l.flushed = true
 	return
 }
 
+func (l *PEXList) flushAdded(limit bool) (added, flags string) {
+	count := len(l.added)
+	if limit && count > maxPeers {
+		count = maxPeers
+	}
+
+	var s, f strings.Builder
+	s.Grow(count * 6)
+	f.Grow(count)
+	for p, flag := range l.added {
+		if count == 0 {
+			break
+		}
+		count--
+
+		b, err := p.MarshalBinary()
+		if err != nil {
+			panic(err)
+		}
+		s.Write(b)
+		f.WriteByte(flag)
+		delete(l.added, p)
+	}
+	return s.String(), f.String()
+}
+
 func (l *PEXList) flush(m map[tracker.CompactPeer]struct{}, limit bool) string {
 	count := len(m)
 	if limit && count > maxPeers {
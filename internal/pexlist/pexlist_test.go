@@ -0,0 +1,23 @@
+package pexlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPEXListFlags(t *testing.T) {
+	l := New()
+	l.Add(newAddr("1.1.1.1"), 0x10)
+	l.Add(newAddr("2.2.2.2"), 0x08)
+	l.Drop(newAddr("2.2.2.2"))
+	added, addedFlags, dropped := l.Flush()
+	assert.Equal(t, "\x01\x01\x01\x01\x00\x01", added)
+	assert.Equal(t, "\x10", addedFlags)
+	assert.Equal(t, "\x02\x02\x02\x02\x00\x01", dropped)
+
+	added, addedFlags, dropped = l.Flush()
+	assert.Empty(t, added)
+	assert.Empty(t, addedFlags)
+	assert.Empty(t, dropped)
+}
@@ -2,6 +2,8 @@ package piececache
 
 import (
 	"container/heap"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,6 +20,8 @@ type Cache struct {
 	accessList    accessList
 	m             sync.RWMutex
 	sem           *semaphore.Semaphore
+	// Incremented when an item is added to or removed from the cache.
+	generation uint64
 
 	NumCached      metrics.Meter
 	NumTotal       metrics.Meter
@@ -63,6 +67,7 @@ func (c *Cache) Clear() {
 	}
 	c.accessList = nil
 	c.size = 0
+	c.generation++
 	c.m.Unlock()
 }
 
@@ -99,6 +104,33 @@ func (c *Cache) Utilization() int {
 	return int((100 * c.NumCached.Rate1()) / total)
 }
 
+// Generation returns a number that changes when an item is added to or removed from the cache.
+// Callers can compare it with a previous value to find out if the result of Keys may be different.
+func (c *Cache) Generation() uint64 {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	return c.generation
+}
+
+// Keys returns the keys of the items in the cache that start with prefix.
+// Most recently accessed items come first.
+func (c *Cache) Keys(prefix string) []string {
+	c.m.RLock()
+	defer c.m.RUnlock()
+	items := make([]*item, 0)
+	for _, i := range c.accessList {
+		if strings.HasPrefix(i.key, prefix) {
+			items = append(items, i)
+		}
+	}
+	sort.Slice(items, func(a, b int) bool { return items[a].lastAccessed.After(items[b].lastAccessed) })
+	keys := make([]string, len(items))
+	for a, i := range items {
+		keys[a] = i.key
+	}
+	return keys
+}
+
 // Get item with the key from cache. If item is not in cache, load by calling `loader` func and put into the cache.
 func (c *Cache) Get(key string, loader Loader) ([]byte, error) {
 	i := c.getItem(key)
@@ -164,6 +196,7 @@ func (c *Cache) handleNewItem(i *item) ([]byte, error) {
 
 	i.lastAccessed = time.Now()
 	heap.Push(&c.accessList, i)
+	c.generation++
 
 	i.timer = time.AfterFunc(c.ttl, func() {
 		c.m.Lock()
@@ -198,4 +231,5 @@ func (c *Cache) removeItem(i *item) {
 	delete(c.items, i.key)
 	heap.Remove(&c.accessList, i.index)
 	c.size -= int64(len(i.value))
+	c.generation++
 }
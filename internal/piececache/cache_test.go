@@ -256,3 +256,54 @@ func TestClear(t *testing.T) {
 
 	time.Sleep(ttl + 10*time.Millisecond)
 }
+
+func TestKeys(t *testing.T) {
+	c := New(10, time.Minute, 1)
+	loader := func() ([]byte, error) { return []byte("x"), nil }
+	for _, key := range []string{"a1", "b1", "a2", "a3"} {
+		_, err := c.Get(key, loader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// Access a1 again to make it the most recent.
+	_, err := c.Get("a1", loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := c.Keys("a")
+	if len(keys) != 3 || keys[0] != "a1" || keys[1] != "a3" || keys[2] != "a2" {
+		t.Fatal(keys)
+	}
+}
+
+func TestGeneration(t *testing.T) {
+	c := New(2, time.Minute, 1)
+	loader := func() ([]byte, error) { return []byte("x"), nil }
+	gen := c.Generation()
+
+	// Adding an item changes the generation.
+	_, err := c.Get("a", loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Generation() == gen {
+		t.Fatal("generation is not changed after adding an item")
+	}
+	gen = c.Generation()
+
+	// Accessing an existing item does not change the generation.
+	_, err = c.Get("a", loader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Generation() != gen {
+		t.Fatal("generation is changed after accessing an item")
+	}
+
+	c.Clear()
+	if c.Generation() == gen {
+		t.Fatal("generation is not changed after clearing the cache")
+	}
+}
@@ -108,6 +108,11 @@ func (d *PieceDownloader) GotBlock(begin uint32, data []byte) error {
 	return nil
 }
 
+// Pending returns the number of requests that are sent to the peer and waiting for a response.
+func (d *PieceDownloader) Pending() int {
+	return len(d.pending)
+}
+
 // Rejected must be called when the peer has rejected a piece request.
 func (d *PieceDownloader) Rejected(begin, length uint32) bool {
 	if !d.findBlock(begin, length) {
@@ -24,11 +24,15 @@ These are the things to consider when selecting a piece for downloading:
   * Piece is reserved for downloading by a webseed source
   * Is endgame mode activated (all pieces are requested)
   * Are there stalled peers (snubbed or choked in the middle of download)
+  * Piece is suggested by the peer (only as a tie-breaker between equally rare pieces)
 
 Do not forget to re-check these when making changes.
 
 */
 
+// Maximum number of pieces suggested by a peer to remember.
+const maxSuggested = 16
+
 // PiecePicker runs an algorithm to determine which piece to download next, from which peer or webseed source.
 // PiecePicker keeps track availability of pieces among peers.
 type PiecePicker struct {
@@ -159,6 +163,18 @@ func (p *PiecePicker) HandleAllowedFast(pe *peer.Peer, i uint32) {
 	pe.ReceivedAllowedFast.Add(p.pieces[i].Piece)
 }
 
+// HandleSuggest must be called when the peer suggests downloading the piece.
+// Only the last maxSuggested suggestions of the peer are kept.
+func (p *PiecePicker) HandleSuggest(pe *peer.Peer, i uint32) {
+	if pe.ReceivedSuggested.Has(p.pieces[i].Piece) {
+		return
+	}
+	if pe.ReceivedSuggested.Len() >= maxSuggested {
+		pe.ReceivedSuggested.Items = pe.ReceivedSuggested.Items[1:]
+	}
+	pe.ReceivedSuggested.Add(p.pieces[i].Piece)
+}
+
 // HandleSnubbed must be called to set the peer as snubbed when it is slow or stalled.
 func (p *PiecePicker) HandleSnubbed(pe *peer.Peer, i uint32) {
 	if p.pieces[i].Choked.Has(pe) {
@@ -298,9 +314,29 @@ func (p *PiecePicker) pickRarest(pe *peer.Peer) *myPiece {
 	if picked == nil && !hasUnrequested {
 		p.endgame = true
 	}
+	if picked != nil && pe.ReceivedSuggested.Len() > 0 {
+		if mp := p.pickSuggested(pe, picked.Having.Len()); mp != nil {
+			picked = mp
+		}
+	}
 	return picked
 }
 
+// pickSuggested returns a piece suggested by the peer that is as rare as the rarest piece.
+// Suggestions are only used as a tie-breaker, they do not override the rarest-first order.
+func (p *PiecePicker) pickSuggested(pe *peer.Peer, availability int) *myPiece {
+	for _, pi := range pe.ReceivedSuggested.Items {
+		mp := &p.pieces[pi.Index]
+		if mp.Done || mp.Writing {
+			continue
+		}
+		if mp.Requested.Len() == 0 && mp.Having.Has(pe) && mp.Having.Len() == availability {
+			return mp
+		}
+	}
+	return nil
+}
+
 func (p *PiecePicker) pickEndgame(pe *peer.Peer) *myPiece {
 	// Sort by request count
 	sort.Slice(p.piecesByAvailability, func(i, j int) bool {
@@ -84,3 +84,29 @@ func (p *PiecePicker) pickFor(pe *peer.Peer) *piece.Piece {
 	pi, _ := p.PickFor(pe)
 	return pi
 }
+
+func TestPickSuggested(t *testing.T) {
+	pieces := make([]piece.Piece, numPieces)
+	for i := range pieces {
+		pieces[i] = newPiece(i)
+	}
+	pe := newPeer(0)
+	pe2 := newPeer(1)
+	pp := New(pieces, 2, nil)
+	for i := uint32(0); i < numPieces; i++ {
+		pp.HandleHave(pe, i)
+	}
+	// Piece 1 is less rare than the others.
+	pp.HandleHave(pe2, 1)
+
+	// Suggestion does not override rarity.
+	pp.HandleSuggest(pe, 1)
+	pp.HandleSuggest(pe, 4)
+	assert.Equal(t, &pieces[4], pp.pickFor(pe))
+	pp.HandleCancelDownload(pe, 4)
+
+	// Suggested piece is picked among equally rare pieces.
+	pp.HandleSuggest(pe, 5)
+	pieces[4].Done = true
+	assert.Equal(t, &pieces[5], pp.pickFor(pe))
+}
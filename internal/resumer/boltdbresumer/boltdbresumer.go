@@ -34,6 +34,12 @@ var Keys = struct {
 	StopAfterDownload []byte
 	StopAfterMetadata []byte
 	CompleteCmdRun    []byte
+	InMemory          []byte
+	LastHook          []byte
+	CachedPeers       []byte
+	ChokingAlgorithm  []byte
+	SuperSeeding      []byte
+	StorageLayout     []byte
 	Version           []byte
 }{
 	InfoHash:          []byte("info_hash"),
@@ -54,6 +60,12 @@ var Keys = struct {
 	StopAfterDownload: []byte("stop_after_download"),
 	StopAfterMetadata: []byte("stop_after_metadata"),
 	CompleteCmdRun:    []byte("complete_cmd_run"),
+	InMemory:          []byte("in_memory"),
+	LastHook:          []byte("last_hook"),
+	CachedPeers:       []byte("cached_peers"),
+	ChokingAlgorithm:  []byte("choking_algorithm"),
+	SuperSeeding:      []byte("super_seeding"),
+	StorageLayout:     []byte("storage_layout"),
 	Version:           []byte("version"),
 }
 
@@ -93,6 +105,14 @@ func (r *Resumer) Write(torrentID string, spec *Spec) error {
 	if err != nil {
 		return err
 	}
+	lastHook, err := json.Marshal(spec.LastHook)
+	if err != nil {
+		return err
+	}
+	cachedPeers, err := json.Marshal(spec.CachedPeers)
+	if err != nil {
+		return err
+	}
 	version := LatestVersion
 	if spec.Version != 0 {
 		version = spec.Version
@@ -119,6 +139,12 @@ func (r *Resumer) Write(torrentID string, spec *Spec) error {
 		_ = b.Put(Keys.StopAfterDownload, []byte(strconv.FormatBool(spec.StopAfterDownload)))
 		_ = b.Put(Keys.StopAfterMetadata, []byte(strconv.FormatBool(spec.StopAfterMetadata)))
 		_ = b.Put(Keys.CompleteCmdRun, []byte(strconv.FormatBool(spec.CompleteCmdRun)))
+		_ = b.Put(Keys.InMemory, []byte(strconv.FormatBool(spec.InMemory)))
+		_ = b.Put(Keys.LastHook, lastHook)
+		_ = b.Put(Keys.CachedPeers, cachedPeers)
+		_ = b.Put(Keys.ChokingAlgorithm, []byte(spec.ChokingAlgorithm))
+		_ = b.Put(Keys.SuperSeeding, []byte(strconv.FormatBool(spec.SuperSeeding)))
+		_ = b.Put(Keys.StorageLayout, []byte(spec.StorageLayout))
 		_ = b.Put(Keys.Version, []byte(strconv.Itoa(version)))
 		return nil
 	})
@@ -198,6 +224,47 @@ func (r *Resumer) WriteCompleteCmdRun(torrentID string) error {
 	})
 }
 
+// WriteSuperSeeding writes the super-seeding status of a torrent.
+func (r *Resumer) WriteSuperSeeding(torrentID string, value bool) error {
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(r.bucket).Bucket([]byte(torrentID))
+		if b == nil {
+			return nil
+		}
+		return b.Put(Keys.SuperSeeding, []byte(strconv.FormatBool(value)))
+	})
+}
+
+// WriteLastHook writes the outcome of the last hook command run for a torrent.
+func (r *Resumer) WriteLastHook(torrentID string, value HookResult) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(r.bucket).Bucket([]byte(torrentID))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Put(Keys.LastHook, b)
+	})
+}
+
+// WriteCachedPeers writes the addresses of peers that we have successfully connected before.
+func (r *Resumer) WriteCachedPeers(torrentID string, value []CachedPeer) error {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return r.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(r.bucket).Bucket([]byte(torrentID))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Put(Keys.CachedPeers, b)
+	})
+}
+
 func (r *Resumer) Read(torrentID string) (spec *Spec, err error) {
 	defer debug.SetPanicOnFault(debug.SetPanicOnFault(true))
 	defer func() {
@@ -359,6 +426,48 @@ func (r *Resumer) Read(torrentID string) (spec *Spec, err error) {
 			}
 		}
 
+		value = b.Get(Keys.InMemory)
+		if value != nil {
+			spec.InMemory, err = strconv.ParseBool(string(value))
+			if err != nil {
+				return err
+			}
+		}
+
+		value = b.Get(Keys.LastHook)
+		if value != nil {
+			err = json.Unmarshal(value, &spec.LastHook)
+			if err != nil {
+				return err
+			}
+		}
+
+		value = b.Get(Keys.CachedPeers)
+		if value != nil {
+			err = json.Unmarshal(value, &spec.CachedPeers)
+			if err != nil {
+				return err
+			}
+		}
+
+		value = b.Get(Keys.ChokingAlgorithm)
+		if value != nil {
+			spec.ChokingAlgorithm = string(value)
+		}
+
+		value = b.Get(Keys.SuperSeeding)
+		if value != nil {
+			spec.SuperSeeding, err = strconv.ParseBool(string(value))
+			if err != nil {
+				return err
+			}
+		}
+
+		value = b.Get(Keys.StorageLayout)
+		if value != nil {
+			spec.StorageLayout = string(value)
+		}
+
 		value = b.Get(Keys.Version)
 		if value != nil {
 			spec.Version, err = strconv.Atoi(string(value))
@@ -25,9 +25,33 @@ type Spec struct {
 	StopAfterDownload bool
 	StopAfterMetadata bool
 	CompleteCmdRun    bool
+	InMemory          bool
+	LastHook          HookResult
+	CachedPeers       []CachedPeer
+	ChokingAlgorithm  string
+	SuperSeeding      bool
+	StorageLayout     string
 	Version           int
 }
 
+// HookResult is the outcome of the last hook command run for a torrent.
+type HookResult struct {
+	// Name of the event that triggered the hook. Empty if no hook has run.
+	Event    string
+	ExitCode int
+	Error    string
+	Time     time.Time
+}
+
+// CachedPeer is the address of a peer that we have successfully connected before.
+type CachedPeer struct {
+	Addr string
+	// Average download speed from the peer during the last connection in bytes/s.
+	DownloadSpeed int64
+	// Time of the last successful connection.
+	Time time.Time
+}
+
 type jsonSpec struct {
 	Port              int
 	Name              string
@@ -42,6 +66,12 @@ type jsonSpec struct {
 	StopAfterDownload bool
 	StopAfterMetadata bool
 	CompleteCmdRun    bool
+	InMemory          bool
+	LastHook          HookResult
+	CachedPeers       []CachedPeer
+	ChokingAlgorithm  string
+	SuperSeeding      bool
+	StorageLayout     string
 	Version           int
 
 	// JSON unsafe types
@@ -67,6 +97,12 @@ func (s Spec) MarshalJSON() ([]byte, error) {
 		StopAfterDownload: s.StopAfterDownload,
 		StopAfterMetadata: s.StopAfterMetadata,
 		CompleteCmdRun:    s.CompleteCmdRun,
+		InMemory:          s.InMemory,
+		LastHook:          s.LastHook,
+		CachedPeers:       s.CachedPeers,
+		ChokingAlgorithm:  s.ChokingAlgorithm,
+		SuperSeeding:      s.SuperSeeding,
+		StorageLayout:     s.StorageLayout,
 		Version:           s.Version,
 
 		InfoHash:  base64.StdEncoding.EncodeToString(s.InfoHash),
@@ -110,6 +146,12 @@ func (s *Spec) UnmarshalJSON(b []byte) error {
 	s.StopAfterDownload = j.StopAfterDownload
 	s.StopAfterMetadata = j.StopAfterMetadata
 	s.CompleteCmdRun = j.CompleteCmdRun
+	s.InMemory = j.InMemory
+	s.LastHook = j.LastHook
+	s.CachedPeers = j.CachedPeers
+	s.ChokingAlgorithm = j.ChokingAlgorithm
+	s.SuperSeeding = j.SuperSeeding
+	s.StorageLayout = j.StorageLayout
 	s.Version = j.Version
 	return nil
 }
@@ -27,6 +27,31 @@ type Peer struct {
 	EncryptedStream    bool
 	DownloadSpeed      int
 	UploadSpeed        int
+	BytesDownloaded    int64
+	BytesUploaded      int64
+	// Requests received from the peer that are waiting to be served.
+	RequestsIn int
+	// Requests sent to the peer that are waiting for a response.
+	RequestsOut int
+	// Percentage of the pieces that the peer has.
+	Completion int
+	// Time of the last message received from the peer. Zero if no message is received yet.
+	LastMessageAt Time
+	// Number of outstanding requests that the peer accepts ("reqq" in extension handshake).
+	RequestQueue int
+	Extensions   []string
+	// Set only for disconnected peers.
+	DisconnectedAt   Time
+	DisconnectReason string
+}
+
+// ConnectionAttempt is an outgoing or incoming connection to a peer of a Torrent.
+type ConnectionAttempt struct {
+	Addr   string
+	Source string
+	Time   Time
+	Result string
+	Error  string
 }
 
 // Webseed source of a Torrent.
@@ -97,6 +122,10 @@ type SessionStats struct {
 	BytesUploaded   int64
 	BytesRead       int64
 	BytesWritten    int64
+
+	SuggestsSent      int64
+	SuggestsReceived  int64
+	SuggestedRequests int64
 }
 
 // Stats contains statistics about a Torrent.
@@ -136,6 +165,22 @@ type Stats struct {
 		Tracker int
 		DHT     int
 		PEX     int
+		Cache   int
+	}
+	ConnectionAttempts struct {
+		Succeeded        int
+		Blocklisted      int
+		Banned           int
+		PeerLimit        int
+		Duplicate        int
+		DialFailed       int
+		Timeout          int
+		Closed           int
+		EncryptionFailed int
+		InfoHashMismatch int
+		ProtocolError    int
+		Error            int
+		DialLimitReached bool
 	}
 	Downloads struct {
 		Total   int
@@ -157,7 +202,45 @@ type Stats struct {
 		Download int
 		Upload   int
 	}
-	ETA int
+	ETA      int
+	LastHook struct {
+		Event    string
+		ExitCode int
+		Error    string
+		Time     Time
+	}
+	SuperSeeding bool
+}
+
+// Event is a change in the state of a Torrent.
+type Event struct {
+	Type      string
+	TorrentID string
+	Time      Time
+	Status    string
+	Error     string
+	Tracker   string
+	Peers     int
+}
+
+// WebhookPayload is the body of the requests sent to webhooks.
+type WebhookPayload struct {
+	Event     string
+	TorrentID string
+	Time      Time
+	Error     string
+	Stats     Stats
+}
+
+// HookPayload is written to the standard input of hook commands.
+type HookPayload struct {
+	Event     string
+	TorrentID string
+	Time      Time
+	Error     string
+	Dir       string
+	Files     []string
+	Stats     Stats
 }
 
 // GetMagnetRequest contains request arguments for Session.GetMagnet method.
@@ -195,6 +278,8 @@ type AddTorrentOptions struct {
 	Stopped           bool
 	StopAfterDownload bool
 	StopAfterMetadata bool
+	ChokingAlgorithm  string
+	SuperSeeding      bool
 }
 
 // AddTorrentRequest contains request arguments for Session.AddTorrent method.
@@ -275,6 +360,26 @@ type GetTorrentPeersResponse struct {
 	Peers []Peer
 }
 
+// GetTorrentDisconnectedPeersRequest contains request arguments for Session.GetTorrentDisconnectedPeers method.
+type GetTorrentDisconnectedPeersRequest struct {
+	ID string
+}
+
+// GetTorrentDisconnectedPeersResponse contains response arguments for Session.GetTorrentDisconnectedPeers method.
+type GetTorrentDisconnectedPeersResponse struct {
+	Peers []Peer
+}
+
+// GetTorrentConnectionAttemptsRequest contains request arguments for Session.GetTorrentConnectionAttempts method.
+type GetTorrentConnectionAttemptsRequest struct {
+	ID string
+}
+
+// GetTorrentConnectionAttemptsResponse contains response arguments for Session.GetTorrentConnectionAttempts method.
+type GetTorrentConnectionAttemptsResponse struct {
+	ConnectionAttempts []ConnectionAttempt
+}
+
 // GetTorrentWebseedsRequest contains request arguments for Session.GetTorrentWebseeds method.
 type GetTorrentWebseedsRequest struct {
 	ID string
@@ -386,3 +491,123 @@ type StopAllTorrentsRequest struct {
 // StopAllTorrentsResponse contains response arguments for Session.StopAllTorrents method.
 type StopAllTorrentsResponse struct {
 }
+
+// TorrentFilter selects the torrents for batch methods. Torrents must match all of the non-empty fields.
+type TorrentFilter struct {
+	IDs []string
+	// Status of the torrent as returned in Stats. Case insensitive.
+	Status string
+	// Shell pattern for the torrent name.
+	Name string
+	// Torrents added at or after this time. Ignored if zero.
+	AddedSince Time
+}
+
+// BatchResult is the result of a batch method for a single torrent.
+type BatchResult struct {
+	ID    string
+	Error string
+}
+
+// StatsResult is the result of Session.GetTorrentsStats method for a single torrent.
+type StatsResult struct {
+	ID    string
+	Error string
+	Stats Stats
+}
+
+// BatchRequest contains request arguments for the methods that operate on multiple torrents:
+// Session.RemoveTorrents, Session.StartTorrents, Session.StopTorrents, Session.VerifyTorrents and Session.AnnounceTorrents.
+type BatchRequest struct {
+	Filter TorrentFilter
+}
+
+// BatchResponse contains response arguments for the methods that operate on multiple torrents.
+type BatchResponse struct {
+	Results []BatchResult
+}
+
+// GetTorrentsStatsRequest contains request arguments for Session.GetTorrentsStats method.
+type GetTorrentsStatsRequest struct {
+	Filter TorrentFilter
+}
+
+// GetTorrentsStatsResponse contains response arguments for Session.GetTorrentsStats method.
+type GetTorrentsStatsResponse struct {
+	Results []StatsResult
+}
+
+// ListTorrentsWithStatsRequest contains request arguments for Session.ListTorrentsWithStats method.
+type ListTorrentsWithStatsRequest struct {
+	// Names of the fields in TorrentWithStats to return. All fields are returned if empty. ID is always returned.
+	Fields []string
+	// Number of torrents to skip. Torrents are sorted by the time they are added.
+	Offset int
+	// Maximum number of torrents to return. No limit if zero.
+	Limit int
+}
+
+// ListTorrentsWithStatsResponse contains response arguments for Session.ListTorrentsWithStats method.
+type ListTorrentsWithStatsResponse struct {
+	Torrents []TorrentWithStats
+	// Number of torrents in the Session.
+	Total int
+}
+
+// TorrentWithStats is a compact projection of Torrent and its Stats.
+// Fields that are not requested are omitted in the response.
+type TorrentWithStats struct {
+	ID       string
+	Name     string `json:",omitempty"`
+	InfoHash string `json:",omitempty"`
+	Port     int    `json:",omitempty"`
+	AddedAt  *Time  `json:",omitempty"`
+	Status   string `json:",omitempty"`
+	Error    string `json:",omitempty"`
+	// Percentage of pieces that are downloaded, checked or allocated depending on the status.
+	Progress        int   `json:",omitempty"`
+	BytesTotal      int64 `json:",omitempty"`
+	BytesCompleted  int64 `json:",omitempty"`
+	BytesDownloaded int64 `json:",omitempty"`
+	BytesUploaded   int64 `json:",omitempty"`
+	Peers           int   `json:",omitempty"`
+	SpeedDownload   int   `json:",omitempty"`
+	SpeedUpload     int   `json:",omitempty"`
+	// Seconds. -1 means infinity.
+	ETA       *int `json:",omitempty"`
+	SeededFor uint `json:",omitempty"`
+}
+
+// Ban prevents peers from connecting to torrents. Only one of IP and PeerIDPrefix is set.
+type Ban struct {
+	IP           string
+	PeerIDPrefix string
+	Reason       string
+	CreatedAt    Time
+	Automatic    bool
+}
+
+// ListBansRequest contains request arguments for Session.ListBans method.
+type ListBansRequest struct{}
+
+// ListBansResponse contains response arguments for Session.ListBans method.
+type ListBansResponse struct {
+	Bans []Ban
+}
+
+// AddBanRequest contains request arguments for Session.AddBan method.
+type AddBanRequest struct {
+	Ban Ban
+}
+
+// AddBanResponse contains response arguments for Session.AddBan method.
+type AddBanResponse struct{}
+
+// RemoveBanRequest contains request arguments for Session.RemoveBan method.
+type RemoveBanRequest struct {
+	IP           string
+	PeerIDPrefix string
+}
+
+// RemoveBanResponse contains response arguments for Session.RemoveBan method.
+type RemoveBanResponse struct{}
@@ -2,6 +2,7 @@
 package filestorage
 
 import (
+	"io"
 	"io/fs"
 	"os"
 	"path/filepath"
@@ -9,23 +10,39 @@ import (
 	"github.com/cenkalti/rain/internal/storage"
 )
 
+// Allocation is the method for reserving disk space for the files in storage.
+type Allocation int
+
+const (
+	// Sparse files are truncated to their final size. Disk blocks are assigned by the filesystem as data is written.
+	Sparse Allocation = iota
+	// Full allocation reserves all disk blocks of the files before downloading starts.
+	Full
+	// Lazy files are created empty and grow as pieces are written.
+	Lazy
+)
+
 // FileStorage implements Storage interface for saving files on disk.
 type FileStorage struct {
-	dest string
-	perm fs.FileMode
+	dest       string
+	perm       fs.FileMode
+	allocation Allocation
 }
 
 // New returns a new FileStorage at the destination.
-func New(dest string, perm fs.FileMode) (*FileStorage, error) {
+func New(dest string, perm fs.FileMode, allocation Allocation) (*FileStorage, error) {
 	var err error
 	dest, err = filepath.Abs(dest)
 	if err != nil {
 		return nil, err
 	}
-	return &FileStorage{dest: dest, perm: perm}, nil
+	return &FileStorage{dest: dest, perm: perm, allocation: allocation}, nil
 }
 
-var _ storage.Storage = (*FileStorage)(nil)
+var (
+	_ storage.Storage    = (*FileStorage)(nil)
+	_ storage.ReadOpener = (*FileStorage)(nil)
+)
 
 // Open a file.
 func (s *FileStorage) Open(name string, size int64) (f storage.File, exists bool, err error) {
@@ -42,14 +59,16 @@ func (s *FileStorage) Open(name string, size int64) (f storage.File, exists bool
 
 	// Make sure OS file is closed in case of any error.
 	var of *os.File
+	// Only files that are created or extended need their space to be reserved.
+	var grown bool
 	defer func() {
 		if err == nil && of != nil {
 			err = disableReadAhead(of)
 		}
 		if err != nil && of != nil {
 			_ = of.Close()
-		} else {
-			f = of
+		} else if of != nil {
+			f = s.wrap(of, grown)
 		}
 	}()
 
@@ -64,7 +83,10 @@ func (s *FileStorage) Open(name string, size int64) (f storage.File, exists bool
 		if err != nil {
 			return
 		}
-		err = of.Truncate(size)
+		if s.allocation != Lazy {
+			err = of.Truncate(size)
+		}
+		grown = true
 		return
 	}
 	if err != nil {
@@ -75,13 +97,68 @@ func (s *FileStorage) Open(name string, size int64) (f storage.File, exists bool
 	if err != nil {
 		return
 	}
-	if fi.Size() != size {
+	// Lazy files are allowed to be shorter than their final size.
+	if fi.Size() > size || (fi.Size() < size && s.allocation != Lazy) {
 		err = of.Truncate(size)
 	}
+	grown = fi.Size() < size
 	return
 }
 
+// OpenRead opens an existing file for reading.
+func (s *FileStorage) OpenRead(name string, size int64) (storage.ReadFile, error) {
+	name = filepath.Join(s.dest, filepath.Clean(name))
+	of, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if s.allocation == Lazy {
+		return lazyFile{of}, nil
+	}
+	return of, nil
+}
+
+func (s *FileStorage) wrap(of *os.File, grown bool) storage.File {
+	switch s.allocation {
+	case Full:
+		if !grown {
+			// Disk blocks of the existing file are reserved on a previous start.
+			return of
+		}
+		return fullFile{of}
+	case Lazy:
+		return lazyFile{of}
+	default:
+		return of
+	}
+}
+
 // RootDir is the root of opened storage file.
 func (s *FileStorage) RootDir() string {
 	return s.dest
 }
+
+// fullFile can reserve the disk blocks of a file before the data is written.
+type fullFile struct {
+	*os.File
+}
+
+var _ storage.Preallocator = fullFile{}
+
+func (f fullFile) Preallocate(offset, length int64) error {
+	return preallocate(f.File, offset, length)
+}
+
+// lazyFile may be shorter than its final size. Reading the part that is not written yet returns zeroes.
+type lazyFile struct {
+	*os.File
+}
+
+func (f lazyFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.File.ReadAt(p, off)
+	if err == io.EOF {
+		clear(p[n:])
+		return len(p), nil
+	}
+	return n, err
+}
@@ -1,6 +1,7 @@
 package filestorage
 
 import (
+	"errors"
 	"os"
 	"syscall"
 
@@ -14,3 +15,13 @@ func disableReadAhead(f *os.File) error {
 func applyNoAtimeFlag(f int) int {
 	return f | syscall.O_NOATIME
 }
+
+// preallocate reserves the disk blocks with fallocate.
+// Files stay sparse if the filesystem does not support it.
+func preallocate(f *os.File, offset, length int64) error {
+	err := unix.Fallocate(int(f.Fd()), 0, offset, length)
+	if errors.Is(err, unix.EOPNOTSUPP) || errors.Is(err, unix.ENOTSUP) {
+		return nil
+	}
+	return err
+}
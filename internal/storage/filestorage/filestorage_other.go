@@ -11,3 +11,8 @@ func disableReadAhead(f *os.File) error {
 func applyNoAtimeFlag(f int) int {
 	return f
 }
+
+// preallocate is not supported on this platform, files stay sparse.
+func preallocate(f *os.File, offset, length int64) error {
+	return nil
+}
@@ -0,0 +1,108 @@
+package filestorage
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSparse(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o750, Sparse)
+	require.NoError(t, err)
+	f, exists, err := s.Open("foo", 10)
+	require.NoError(t, err)
+	require.False(t, exists)
+	defer f.Close()
+	_, ok := f.(storage.Preallocator)
+	require.False(t, ok)
+	fi, err := os.Stat(filepath.Join(dir, "foo"))
+	require.NoError(t, err)
+	require.Equal(t, int64(10), fi.Size())
+}
+
+func TestFull(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o750, Full)
+	require.NoError(t, err)
+	f, _, err := s.Open("foo", 10)
+	require.NoError(t, err)
+	defer f.Close()
+	pa, ok := f.(storage.Preallocator)
+	require.True(t, ok)
+	require.NoError(t, pa.Preallocate(0, 10))
+	fi, err := os.Stat(filepath.Join(dir, "foo"))
+	require.NoError(t, err)
+	require.Equal(t, int64(10), fi.Size())
+}
+
+func TestLazy(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o750, Lazy)
+	require.NoError(t, err)
+	f, _, err := s.Open("foo", 10)
+	require.NoError(t, err)
+	defer f.Close()
+	fi, err := os.Stat(filepath.Join(dir, "foo"))
+	require.NoError(t, err)
+	require.Zero(t, fi.Size())
+
+	_, err = f.WriteAt([]byte("ab"), 2)
+	require.NoError(t, err)
+	b := []byte("xxxxxx")
+	n, err := f.ReadAt(b, 2)
+	require.NoError(t, err)
+	require.Equal(t, 6, n)
+	require.Equal(t, []byte{'a', 'b', 0, 0, 0, 0}, b)
+}
+
+func TestFullExisting(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o750, Full)
+	require.NoError(t, err)
+	f, _, err := s.Open("foo", 10)
+	require.NoError(t, err)
+	f.Close()
+
+	// Existing file with full size is not allocated again.
+	f, exists, err := s.Open("foo", 10)
+	require.NoError(t, err)
+	require.True(t, exists)
+	_, ok := f.(storage.Preallocator)
+	require.False(t, ok)
+	f.Close()
+
+	// Shorter file is extended and allocated.
+	f, exists, err = s.Open("foo", 20)
+	require.NoError(t, err)
+	require.True(t, exists)
+	defer f.Close()
+	_, ok = f.(storage.Preallocator)
+	require.True(t, ok)
+}
+
+func TestOpenRead(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(dir, 0o750, Sparse)
+	require.NoError(t, err)
+	_, err = s.OpenRead("foo", 10)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = os.Stat(filepath.Join(dir, "foo"))
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "foo"), []byte("abc"), 0o640))
+	f, err := s.OpenRead("foo", 10)
+	require.NoError(t, err)
+	defer f.Close()
+	b := make([]byte, 3)
+	_, err = f.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("abc"), b)
+	fi, err := os.Stat(filepath.Join(dir, "foo"))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), fi.Size())
+}
@@ -0,0 +1,219 @@
+// Package memorystorage implements Storage interface that keeps files in memory.
+package memorystorage
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"sync"
+
+	"github.com/cenkalti/rain/internal/storage"
+)
+
+// ErrNoSpace is returned from Open when there is not enough space left in the Pool.
+var ErrNoSpace = errors.New("not enough space in memory storage pool")
+
+// Pool limits the total size of files in MemoryStorages created from it.
+// When there is not enough space for a new file, files of the least recently used storages are evicted.
+// Storages that have open files are never evicted.
+type Pool struct {
+	m        sync.Mutex
+	maxSize  int64
+	size     int64
+	clock    uint64
+	storages map[*MemoryStorage]struct{}
+}
+
+// NewPool returns a new Pool that can hold maxSize bytes. If maxSize is zero, the size is not limited.
+func NewPool(maxSize int64) *Pool {
+	return &Pool{
+		maxSize:  maxSize,
+		storages: make(map[*MemoryStorage]struct{}),
+	}
+}
+
+// Size returns the total size of files in the Pool.
+func (p *Pool) Size() int64 {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.size
+}
+
+// NewStorage returns a new empty MemoryStorage that keeps its files in the Pool.
+func (p *Pool) NewStorage() *MemoryStorage {
+	s := &MemoryStorage{
+		pool:  p,
+		files: make(map[string]*File),
+	}
+	p.m.Lock()
+	p.storages[s] = struct{}{}
+	p.m.Unlock()
+	return s
+}
+
+// Remove deletes all files of s and releases the space used by them.
+func (p *Pool) Remove(s *MemoryStorage) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.evict(s)
+	delete(p.storages, s)
+}
+
+// reserve makes room for n more bytes for storage s. Must be called with p.m held.
+func (p *Pool) reserve(s *MemoryStorage, n int64) error {
+	for p.maxSize > 0 && p.size+n > p.maxSize {
+		var lru *MemoryStorage
+		for s2 := range p.storages {
+			if s2 == s || s2.openFiles > 0 || s2.size == 0 {
+				continue
+			}
+			if lru == nil || s2.lastUsed < lru.lastUsed {
+				lru = s2
+			}
+		}
+		if lru == nil {
+			return ErrNoSpace
+		}
+		p.evict(lru)
+	}
+	p.size += n
+	return nil
+}
+
+// evict deletes the files of s. Must be called with p.m held.
+func (p *Pool) evict(s *MemoryStorage) {
+	for _, f := range s.files {
+		f.m.Lock()
+		f.data = nil
+		f.m.Unlock()
+	}
+	s.files = make(map[string]*File)
+	p.size -= s.size
+	s.size = 0
+}
+
+// MemoryStorage implements Storage interface for keeping files in memory.
+type MemoryStorage struct {
+	pool *Pool
+	// Fields below are protected by pool.m
+	files     map[string]*File
+	size      int64
+	openFiles int
+	lastUsed  uint64
+}
+
+// New returns a new empty MemoryStorage that is not limited in size.
+// Contents of the files are kept until the MemoryStorage is garbage collected.
+func New() *MemoryStorage {
+	return NewPool(0).NewStorage()
+}
+
+var (
+	_ storage.Storage    = (*MemoryStorage)(nil)
+	_ storage.ReadOpener = (*MemoryStorage)(nil)
+)
+
+// Open a file. The file is created if it does not exist.
+// Files of other storages in the Pool may be evicted to make room for the file.
+func (s *MemoryStorage) Open(name string, size int64) (f storage.File, exists bool, err error) {
+	s.pool.m.Lock()
+	defer s.pool.m.Unlock()
+	mf, exists := s.files[name]
+	var oldSize int64
+	if exists {
+		oldSize = int64(len(mf.data))
+	}
+	if size > oldSize {
+		err = s.pool.reserve(s, size-oldSize)
+		if err != nil {
+			return nil, false, err
+		}
+	} else {
+		s.pool.size -= oldSize - size
+	}
+	s.size += size - oldSize
+	if !exists {
+		mf = &File{storage: s}
+		s.files[name] = mf
+	}
+	mf.truncate(size)
+	s.openFiles++
+	s.pool.clock++
+	s.lastUsed = s.pool.clock
+	return mf, exists, nil
+}
+
+// OpenRead opens an existing file for reading. No space is reserved in the Pool.
+// The storage is not evicted until the file is closed.
+func (s *MemoryStorage) OpenRead(name string, size int64) (storage.ReadFile, error) {
+	s.pool.m.Lock()
+	defer s.pool.m.Unlock()
+	mf, ok := s.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	s.openFiles++
+	s.pool.clock++
+	s.lastUsed = s.pool.clock
+	return mf, nil
+}
+
+// RootDir returns empty string because files are not saved on disk.
+func (s *MemoryStorage) RootDir() string {
+	return ""
+}
+
+// File is a file in memory.
+type File struct {
+	storage *MemoryStorage
+	m       sync.RWMutex
+	data    []byte
+}
+
+var _ storage.File = (*File)(nil)
+
+func (f *File) truncate(size int64) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if int64(len(f.data)) > size {
+		f.data = f.data[:size]
+		return
+	}
+	f.data = append(f.data, make([]byte, size-int64(len(f.data)))...)
+}
+
+// ReadAt implements io.ReaderAt interface.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	f.m.RLock()
+	defer f.m.RUnlock()
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// WriteAt implements io.WriterAt interface.
+func (f *File) WriteAt(p []byte, off int64) (int, error) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if off+int64(len(p)) > int64(len(f.data)) {
+		return 0, io.ErrShortWrite
+	}
+	return copy(f.data[off:], p), nil
+}
+
+// Close the file. Data is kept in the MemoryStorage after the file is closed.
+// The storage becomes eligible for eviction after all of its files are closed.
+func (f *File) Close() error {
+	p := f.storage.pool
+	p.m.Lock()
+	f.storage.openFiles--
+	p.clock++
+	f.storage.lastUsed = p.clock
+	p.m.Unlock()
+	return nil
+}
@@ -0,0 +1,81 @@
+package memorystorage
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWrite(t *testing.T) {
+	s := New()
+	f, exists, err := s.Open("foo", 5)
+	require.NoError(t, err)
+	require.False(t, exists)
+	_, err = f.WriteAt([]byte("abc"), 1)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	f, exists, err = s.Open("foo", 5)
+	require.NoError(t, err)
+	require.True(t, exists)
+	b := make([]byte, 5)
+	_, err = f.ReadAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, []byte("\x00abc\x00"), b)
+}
+
+func TestPoolEviction(t *testing.T) {
+	p := NewPool(10)
+	s1 := p.NewStorage()
+	s2 := p.NewStorage()
+	s3 := p.NewStorage()
+
+	f1, _, err := s1.Open("foo", 4)
+	require.NoError(t, err)
+	f2, _, err := s2.Open("foo", 4)
+	require.NoError(t, err)
+
+	// Both storages have open files.
+	_, _, err = s3.Open("foo", 4)
+	require.ErrorIs(t, err, ErrNoSpace)
+
+	require.NoError(t, f2.Close())
+	require.NoError(t, f1.Close())
+
+	// s2 is the least recently used storage.
+	f3, _, err := s3.Open("foo", 4)
+	require.NoError(t, err)
+	defer f3.Close()
+	require.Equal(t, int64(8), p.Size())
+
+	_, exists, err := s1.Open("foo", 4)
+	require.NoError(t, err)
+	require.True(t, exists)
+	_, exists, err = s2.Open("foo", 4)
+	require.ErrorIs(t, err, ErrNoSpace)
+	require.False(t, exists)
+
+	p.Remove(s1)
+	require.Equal(t, int64(4), p.Size())
+}
+
+func TestOpenRead(t *testing.T) {
+	p := NewPool(10)
+	s := p.NewStorage()
+	_, err := s.OpenRead("foo", 5)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+	require.Equal(t, int64(0), p.Size())
+
+	f, _, err := s.Open("foo", 5)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	rf, err := s.OpenRead("foo", 5)
+	require.NoError(t, err)
+	require.Equal(t, int64(5), p.Size())
+
+	// Storage with a file open for reading is not evicted.
+	_, _, err = p.NewStorage().Open("bar", 6)
+	require.ErrorIs(t, err, ErrNoSpace)
+	require.NoError(t, rf.Close())
+}
@@ -0,0 +1,243 @@
+// Package piecestorage implements Storage interface that saves each piece of a torrent in a separate file.
+package piecestorage
+
+import (
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/cenkalti/rain/internal/storage"
+)
+
+var errNoInfo = errors.New("torrent info is not set")
+
+// PieceStorage implements Storage interface for saving pieces on disk.
+// Each piece is saved in a file named after the hash of the piece, so torrents that share pieces can share the files.
+// Files of the torrent are views over the piece files.
+type PieceStorage struct {
+	dir  string
+	perm fs.FileMode
+
+	m    sync.RWMutex
+	info *metainfo.Info
+}
+
+// New returns a new PieceStorage that saves pieces under dir.
+func New(dir string, perm fs.FileMode) (*PieceStorage, error) {
+	var err error
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &PieceStorage{dir: dir, perm: perm}, nil
+}
+
+var (
+	_ storage.Storage    = (*PieceStorage)(nil)
+	_ storage.InfoSetter = (*PieceStorage)(nil)
+	_ storage.ReadOpener = (*PieceStorage)(nil)
+)
+
+// SetInfo sets the info of the torrent for mapping files to pieces.
+func (s *PieceStorage) SetInfo(info *metainfo.Info) {
+	s.m.Lock()
+	s.info = info
+	s.m.Unlock()
+}
+
+// Open a view of the file in torrent. Piece files are created when data is written to the file.
+// exists is true if any of the pieces that contain the file data exists on disk.
+func (s *PieceStorage) Open(name string, size int64) (f storage.File, exists bool, err error) {
+	pf, err := s.file(name, size)
+	if err != nil {
+		return nil, false, err
+	}
+	exists, err = pf.exists()
+	return pf, exists, err
+}
+
+// OpenRead opens a view of the file in torrent for reading. An error is returned if none of its pieces exists on disk.
+func (s *PieceStorage) OpenRead(name string, size int64) (storage.ReadFile, error) {
+	pf, err := s.file(name, size)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := pf.exists()
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return pf, nil
+}
+
+func (s *PieceStorage) file(name string, size int64) (*pieceFile, error) {
+	s.m.RLock()
+	info := s.info
+	s.m.RUnlock()
+	if info == nil {
+		return nil, errNoInfo
+	}
+	var offset int64
+	var found bool
+	for _, tf := range info.Files {
+		if tf.Path == name && !tf.Padding {
+			found = true
+			break
+		}
+		offset += tf.Length
+	}
+	if !found || offset+size > info.Length {
+		return nil, errors.New("file not found in torrent: " + name)
+	}
+	return &pieceFile{storage: s, info: info, offset: offset, size: size}, nil
+}
+
+// RootDir returns the directory that contains piece files.
+func (s *PieceStorage) RootDir() string {
+	return s.dir
+}
+
+// RemovePieces deletes the piece files of the torrent, except the pieces with hashes in keep.
+func (s *PieceStorage) RemovePieces(keep map[string]struct{}) error {
+	s.m.RLock()
+	info := s.info
+	s.m.RUnlock()
+	if info == nil {
+		return nil
+	}
+	for i := uint32(0); i < info.NumPieces; i++ {
+		hash := info.PieceHash(i)
+		if _, ok := keep[string(hash)]; ok {
+			continue
+		}
+		err := os.Remove(s.piecePath(hash))
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// piecePath returns the path of the file for the piece with hash.
+// Files are spread into sub-directories by the first byte of the hash.
+func (s *PieceStorage) piecePath(hash []byte) string {
+	h := hex.EncodeToString(hash)
+	return filepath.Join(s.dir, h[:2], h)
+}
+
+// pieceFile is a view of a file in torrent that reads/writes the data from/to piece files.
+type pieceFile struct {
+	storage *PieceStorage
+	info    *metainfo.Info
+	offset  int64
+	size    int64
+}
+
+// sections calls fn for each part of the piece files that are mapped to the range in the file.
+func (f *pieceFile) sections(off, length int64, fn func(hash []byte, pieceOff int64, begin, end int64) error) error {
+	pieceLength := int64(f.info.PieceLength)
+	for pos := off; pos < off+length; {
+		index := (f.offset + pos) / pieceLength
+		pieceOff := (f.offset + pos) % pieceLength
+		n := min(pieceLength-pieceOff, off+length-pos)
+		err := fn(f.info.PieceHash(uint32(index)), pieceOff, pos-off, pos-off+n)
+		if err != nil {
+			return err
+		}
+		pos += n
+	}
+	return nil
+}
+
+func (f *pieceFile) exists() (bool, error) {
+	var found bool
+	errFound := errors.New("found")
+	err := f.sections(0, f.size, func(hash []byte, _, _, _ int64) error {
+		_, err := os.Stat(f.storage.piecePath(hash))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+		return errFound
+	})
+	if err == errFound {
+		err = nil
+	}
+	return found, err
+}
+
+// ReadAt reads the data from piece files. Parts of the pieces that are not written yet are read as zeroes.
+func (f *pieceFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	length := min(int64(len(p)), f.size-off)
+	err := f.sections(off, length, func(hash []byte, pieceOff int64, begin, end int64) error {
+		buf := p[begin:end]
+		pf, err := os.Open(f.storage.piecePath(hash))
+		if os.IsNotExist(err) {
+			clear(buf)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		defer pf.Close()
+		n, err := pf.ReadAt(buf, pieceOff)
+		if err == io.EOF {
+			clear(buf[n:])
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if length < int64(len(p)) {
+		return int(length), io.EOF
+	}
+	return int(length), nil
+}
+
+// WriteAt writes the data into piece files.
+func (f *pieceFile) WriteAt(p []byte, off int64) (int, error) {
+	if off+int64(len(p)) > f.size {
+		return 0, io.ErrShortWrite
+	}
+	err := f.sections(off, int64(len(p)), func(hash []byte, pieceOff int64, begin, end int64) error {
+		name := f.storage.piecePath(hash)
+		err := os.MkdirAll(filepath.Dir(name), os.ModeDir|f.storage.perm)
+		if err != nil {
+			return err
+		}
+		pf, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE, f.storage.perm&^0111)
+		if err != nil {
+			return err
+		}
+		_, err = pf.WriteAt(p[begin:end], pieceOff)
+		if err != nil {
+			pf.Close()
+			return err
+		}
+		return pf.Close()
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Close does nothing because piece files are opened only while reading or writing.
+func (f *pieceFile) Close() error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package piecestorage
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/logger"
+	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPieceStorage(t *testing.T) {
+	// Piece length is 32K. file2.bin starts in the middle of the first piece.
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "file1.bin"), make([]byte, 20<<10), 0o600))
+	b := make([]byte, 50<<10)
+	for i := range b {
+		b[i] = byte(i)
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(src, "file2.bin"), b, 0o600))
+	ib, err := metainfo.NewInfoBytes(src, []string{filepath.Join(src, "file1.bin"), filepath.Join(src, "file2.bin")}, false, 32<<10, "test", logger.New("test"))
+	require.NoError(t, err)
+	info, err := metainfo.NewInfo(ib, true, true)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	s, err := New(dir, 0o750)
+	require.NoError(t, err)
+	_, _, err = s.Open("file2.bin", int64(len(b)))
+	require.Error(t, err)
+
+	s.SetInfo(info)
+	f, exists, err := s.Open(filepath.Join("test", "file2.bin"), int64(len(b)))
+	require.NoError(t, err)
+	require.False(t, exists)
+	n, err := f.WriteAt(b, 0)
+	require.NoError(t, err)
+	require.Equal(t, len(b), n)
+	require.NoError(t, f.Close())
+
+	// Second piece is fully contained in file2.bin.
+	piece, err := os.ReadFile(s.piecePath(info.PieceHash(1)))
+	require.NoError(t, err)
+	require.Equal(t, b[12<<10:44<<10], piece)
+
+	// Another torrent with the same info shares the pieces.
+	s2, err := New(dir, 0o750)
+	require.NoError(t, err)
+	s2.SetInfo(info)
+	f, exists, err = s2.Open(filepath.Join("test", "file2.bin"), int64(len(b)))
+	require.NoError(t, err)
+	require.True(t, exists)
+	buf := make([]byte, len(b))
+	_, err = f.ReadAt(buf, 0)
+	require.NoError(t, err)
+	require.Equal(t, b, buf)
+
+	// Data of file1.bin is not written yet.
+	f, _, err = s2.Open(filepath.Join("test", "file1.bin"), 20<<10)
+	require.NoError(t, err)
+	buf = make([]byte, 20<<10)
+	_, err = f.ReadAt(buf, 0)
+	require.NoError(t, err)
+	require.Equal(t, make([]byte, 20<<10), buf)
+
+	require.NoError(t, s.RemovePieces(map[string]struct{}{string(info.PieceHash(1)): {}}))
+	_, err = os.Stat(s.piecePath(info.PieceHash(0)))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(s.piecePath(info.PieceHash(1)))
+	require.NoError(t, err)
+}
@@ -1,11 +1,16 @@
 // Package storage contains an interface for reading and writing files in a torrent.
 package storage
 
-import "io"
+import (
+	"io"
+
+	"github.com/cenkalti/rain/internal/metainfo"
+)
 
 // Storage is an interface for reading/writing torrent files.
 type Storage interface {
 	Open(name string, size int64) (f File, exists bool, err error)
+	// RootDir returns the directory that contains the files. Returns empty string if the files are not on local disk.
 	RootDir() string
 }
 
@@ -15,3 +20,28 @@ type File interface {
 	io.WriterAt
 	io.Closer
 }
+
+// ReadFile is a file opened for reading only.
+type ReadFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// ReadOpener is implemented by storages that can open existing files without changing them.
+type ReadOpener interface {
+	// OpenRead opens an existing file for reading. The file is not created or resized.
+	// Returned error matches fs.ErrNotExist if the file does not exist.
+	OpenRead(name string, size int64) (ReadFile, error)
+}
+
+// Preallocator is implemented by files that can reserve storage space before the data is written.
+type Preallocator interface {
+	// Preallocate reserves space for length bytes starting at offset. Existing data in the range is not changed.
+	Preallocate(offset, length int64) error
+}
+
+// InfoSetter is implemented by storages that map the files of a torrent to a different layout on disk.
+type InfoSetter interface {
+	// SetInfo is called with the info of the torrent before any file is opened.
+	SetInfo(info *metainfo.Info)
+}
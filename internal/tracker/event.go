@@ -9,6 +9,9 @@ const (
 	EventCompleted
 	EventStarted
 	EventStopped
+	// EventPaused is sent by partial seeds that are not downloading (BEP 21).
+	// It is only defined for HTTP trackers.
+	EventPaused
 )
 
 var eventNames = [...]string{
@@ -16,6 +19,7 @@ var eventNames = [...]string{
 	"completed",
 	"started",
 	"stopped",
+	"paused",
 }
 
 // String returns the name of event as represented in HTTP tracker protocol.
@@ -8,4 +8,6 @@ type Torrent struct {
 	InfoHash        [20]byte
 	PeerID          [20]byte
 	Port            int
+	// True if the torrent has missing pieces but it is not downloading them.
+	PartialSeed bool
 }
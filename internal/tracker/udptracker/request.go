@@ -15,13 +15,18 @@ type transportRequest struct {
 var _ udpRequest = (*transportRequest)(nil)
 
 func newTransportRequest(ctx context.Context, req tracker.AnnounceRequest, dest string, urlData string) *transportRequest {
+	event := req.Event
+	if event == tracker.EventPaused {
+		// UDP tracker protocol has no paused event.
+		event = tracker.EventNone
+	}
 	request := &announceRequest{
 		InfoHash:   req.Torrent.InfoHash,
 		PeerID:     req.Torrent.PeerID,
 		Downloaded: req.Torrent.BytesDownloaded,
 		Left:       req.Torrent.BytesLeft,
 		Uploaded:   req.Torrent.BytesUploaded,
-		Event:      req.Event,
+		Event:      event,
 		NumWant:    int32(req.NumWant),
 		Port:       uint16(req.Torrent.Port),
 	}
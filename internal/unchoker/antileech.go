@@ -0,0 +1,38 @@
+package unchoker
+
+import "sort"
+
+// AntiLeech is like Unchoker while downloading.
+// When the torrent is completed, peers with fewer pieces are unchoked first instead of the peers we upload faster.
+// Among the peers with the same number of pieces, choked peers are preferred so that upload slots are given in turns.
+type AntiLeech struct {
+	*Unchoker
+}
+
+var _ Algorithm = (*AntiLeech)(nil)
+
+// NewAntiLeech returns a new AntiLeech unchoker.
+func NewAntiLeech(numUnchoked, numOptimisticUnchoked int) *AntiLeech {
+	u := New(numUnchoked, numOptimisticUnchoked)
+	u.sortPeers = sortByPieces
+	return &AntiLeech{Unchoker: u}
+}
+
+func sortByPieces(peers []Peer, completed bool) {
+	if !completed {
+		sortBySpeed(peers, completed)
+		return
+	}
+	// Counting pieces is not cheap. Do it once for each peer.
+	pieces := make(map[Peer]int, len(peers))
+	for _, pe := range peers {
+		pieces[pe] = pe.NumPieces()
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		ni, nj := pieces[peers[i]], pieces[peers[j]]
+		if ni != nj {
+			return ni < nj
+		}
+		return peers[i].Choking() && !peers[j].Choking()
+	})
+}
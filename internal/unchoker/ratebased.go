@@ -0,0 +1,55 @@
+package unchoker
+
+import "sort"
+
+// rateBasedStep is the increase in the upload speed threshold for each upload slot in bytes/s.
+const rateBasedStep = 1024
+
+// RateBased is like Unchoker but the number of regular upload slots is not fixed.
+// The upload speeds of unchoked peers are compared with a threshold that starts at 1 KiB/s and increases by 1 KiB/s for each slot.
+// If all unchoked peers are above their thresholds, the upload capacity is not used fully and another slot is opened.
+// Slots that are below their thresholds are closed but the number of slots never goes below the minimum.
+type RateBased struct {
+	*Unchoker
+	minUnchoked int
+}
+
+var _ Algorithm = (*RateBased)(nil)
+
+// NewRateBased returns a new RateBased unchoker.
+// numUnchoked is the minimum number of regular upload slots.
+func NewRateBased(numUnchoked, numOptimisticUnchoked int) *RateBased {
+	return &RateBased{
+		Unchoker:    New(numUnchoked, numOptimisticUnchoked),
+		minUnchoked: numUnchoked,
+	}
+}
+
+// TickUnchoke must be called at every 10 seconds.
+func (u *RateBased) TickUnchoke(allPeers []Peer, torrentCompleted bool) {
+	u.numUnchoked = u.slots(allPeers)
+	u.Unchoker.TickUnchoke(allPeers, torrentCompleted)
+}
+
+func (u *RateBased) slots(peers []Peer) int {
+	speeds := make([]int, 0, len(peers))
+	for _, pe := range peers {
+		if !pe.Choking() {
+			speeds = append(speeds, pe.UploadSpeed())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(speeds)))
+	var n int
+	threshold := rateBasedStep
+	for _, speed := range speeds {
+		if speed < threshold {
+			break
+		}
+		n++
+		threshold += rateBasedStep
+	}
+	if n == len(speeds) {
+		n++
+	}
+	return max(n, u.minUnchoked)
+}
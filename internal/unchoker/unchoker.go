@@ -5,11 +5,27 @@ import (
 	"sort"
 )
 
+// Algorithm selects the peers to upload to.
+type Algorithm interface {
+	// TickUnchoke must be called at every 10 seconds.
+	TickUnchoke(allPeers []Peer, torrentCompleted bool)
+	// FastUnchoke must be called when remote peer is interested.
+	FastUnchoke(pe Peer)
+	// HandleDisconnect must be called to remove the peer from internal indexes.
+	HandleDisconnect(pe Peer)
+}
+
+var _ Algorithm = (*Unchoker)(nil)
+
 // Unchoker implements an algorithm to select peers to unchoke based on their download speed.
+// A fixed number of peers are unchoked regularly and a fixed number of peers are unchoked optimistically.
 type Unchoker struct {
 	numUnchoked           int
 	numOptimisticUnchoked int
 
+	// Sorts the candidate peers in place, most preferred peer first.
+	sortPeers func(peers []Peer, torrentCompleted bool)
+
 	// Every 3rd round an optimistic unchoke logic is applied.
 	round uint8
 
@@ -36,6 +52,9 @@ type Peer interface {
 
 	DownloadSpeed() int
 	UploadSpeed() int
+
+	// NumPieces returns the number of pieces that the remote peer has.
+	NumPieces() int
 }
 
 // New returns a new Unchoker.
@@ -43,6 +62,7 @@ func New(numUnchoked, numOptimisticUnchoked int) *Unchoker {
 	return &Unchoker{
 		numUnchoked:             numUnchoked,
 		numOptimisticUnchoked:   numOptimisticUnchoked,
+		sortPeers:               sortBySpeed,
 		peersUnchoked:           make(map[Peer]struct{}, numUnchoked),
 		peersUnchokedOptimistic: make(map[Peer]struct{}, numUnchoked),
 	}
@@ -64,7 +84,9 @@ func (u *Unchoker) candidatesUnchoke(allPeers []Peer) []Peer {
 	return peers
 }
 
-func (u *Unchoker) sortPeers(peers []Peer, completed bool) {
+// sortBySpeed sorts peers by the speed of download from them.
+// When the torrent is completed, peers are sorted by the speed of upload to them.
+func sortBySpeed(peers []Peer, completed bool) {
 	byUploadSpeed := func(i, j int) bool { return peers[i].UploadSpeed() > peers[j].UploadSpeed() }
 	byDownloadSpeed := func(i, j int) bool { return peers[i].DownloadSpeed() > peers[j].DownloadSpeed() }
 	if completed {
@@ -143,12 +143,53 @@ func TestTickUnchoke(t *testing.T) {
 	}, testPeers)
 }
 
+func TestRateBasedSlots(t *testing.T) {
+	u := NewRateBased(2, 1)
+	peers := []Peer{
+		&TestPeer{interested: true, uploadSpeed: 5000},
+		&TestPeer{interested: true, uploadSpeed: 3000},
+		&TestPeer{interested: true, choking: true},
+		&TestPeer{interested: true, choking: true},
+	}
+	// Both unchoked peers are above the threshold. Another slot is opened.
+	assert.Equal(t, 3, u.slots(peers))
+
+	// Third peer is below the threshold of 3 KiB/s. Upload capacity is saturated.
+	peers[2].(*TestPeer).choking = false
+	peers[2].(*TestPeer).uploadSpeed = 2000
+	assert.Equal(t, 2, u.slots(peers))
+
+	// Never goes below the minimum.
+	peers[0].(*TestPeer).uploadSpeed = 0
+	peers[1].(*TestPeer).uploadSpeed = 0
+	assert.Equal(t, 2, u.slots(peers))
+}
+
+func TestAntiLeech(t *testing.T) {
+	testPeers := []*TestPeer{
+		{interested: true, choking: true, numPieces: 10, uploadSpeed: 9},
+		{interested: true, choking: true, numPieces: 1},
+		{interested: true, choking: true, numPieces: 5},
+	}
+	peers := make([]Peer, len(testPeers))
+	for i := range peers {
+		peers[i] = testPeers[i]
+	}
+	u := NewAntiLeech(2, 1)
+	u.round = 1
+	u.TickUnchoke(peers, true)
+	assert.True(t, testPeers[0].choking)
+	assert.False(t, testPeers[1].choking)
+	assert.False(t, testPeers[2].choking)
+}
+
 type TestPeer struct {
 	interested    bool
 	choking       bool
 	optimistic    bool
 	downloadSpeed int
 	uploadSpeed   int
+	numPieces     int
 }
 
 func (p *TestPeer) Choke()                   { p.choking = true }
@@ -159,3 +200,4 @@ func (p *TestPeer) Optimistic() bool         { return p.optimistic }
 func (p *TestPeer) SetOptimistic(value bool) { p.optimistic = value }
 func (p *TestPeer) DownloadSpeed() int       { return p.downloadSpeed }
 func (p *TestPeer) UploadSpeed() int         { return p.uploadSpeed }
+func (p *TestPeer) NumPieces() int           { return p.numPieces }
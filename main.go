@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -24,6 +29,7 @@ import (
 	"github.com/cenkalti/rain/internal/logger"
 	"github.com/cenkalti/rain/internal/magnet"
 	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/cenkalti/rain/internal/rpctypes"
 	"github.com/cenkalti/rain/rainrpc"
 	"github.com/cenkalti/rain/torrent"
 	"github.com/hokaccha/go-prettyjson"
@@ -148,7 +154,7 @@ func main() {
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "url",
-					Usage: "URL of RPC server",
+					Usage: "URL of RPC server, use unix:///path/to/socket form for Unix domain socket",
 					Value: "http://127.0.0.1:" + strconv.Itoa(torrent.DefaultConfig.RPCPort),
 				},
 				cli.DurationFlag{
@@ -156,6 +162,28 @@ func main() {
 					Usage: "request timeout",
 					Value: 10 * time.Second,
 				},
+				cli.StringFlag{
+					Name:   "token",
+					Usage:  "bearer token for authentication",
+					EnvVar: "RAIN_RPC_TOKEN",
+				},
+				cli.StringFlag{
+					Name:  "username",
+					Usage: "username for basic authentication",
+				},
+				cli.StringFlag{
+					Name:   "password",
+					Usage:  "password for basic authentication",
+					EnvVar: "RAIN_RPC_PASSWORD",
+				},
+				cli.StringFlag{
+					Name:  "ca-cert",
+					Usage: "verify server certificate with CA certificate in PEM `FILE`",
+				},
+				cli.BoolFlag{
+					Name:  "insecure",
+					Usage: "do not verify server certificate",
+				},
 			},
 			Before: handleBeforeClient,
 			Subcommands: []cli.Command{
@@ -194,6 +222,14 @@ func main() {
 							Name:  "stop-after-metadata",
 							Usage: "stop the torrent after metadata download is finished",
 						},
+						cli.StringFlag{
+							Name:  "choking-algorithm",
+							Usage: "algorithm for selecting peers to upload to: fixed-slots, rate-based or anti-leech",
+						},
+						cli.BoolFlag{
+							Name:  "super-seeding",
+							Usage: "advertise pieces to peers one at a time when seeding until all pieces are distributed",
+						},
 						cli.StringFlag{
 							Name:  "id",
 							Usage: "if id is not given, a unique id is automatically generated",
@@ -207,8 +243,11 @@ func main() {
 					Action:   handleRemove,
 					Flags: []cli.Flag{
 						cli.StringFlag{
-							Name:     "id",
-							Required: true,
+							Name: "id",
+						},
+						cli.StringFlag{
+							Name:  "filter",
+							Usage: "select torrents with space separated key=value pairs instead of id (keys: id, status, name, added-since)",
 						},
 					},
 				},
@@ -225,8 +264,11 @@ func main() {
 					Action:   handleStats,
 					Flags: []cli.Flag{
 						cli.StringFlag{
-							Name:     "id",
-							Required: true,
+							Name: "id",
+						},
+						cli.StringFlag{
+							Name:  "filter",
+							Usage: "select torrents with space separated key=value pairs instead of id (keys: id, status, name, added-since)",
 						},
 						cli.BoolFlag{
 							Name:  "json",
@@ -246,6 +288,22 @@ func main() {
 						},
 					},
 				},
+				{
+					Name:     "events",
+					Usage:    "print events of torrents as they happen",
+					Category: "Getters",
+					Action:   handleEvents,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "id",
+							Usage: "print only the events of torrent with `ID`",
+						},
+						cli.BoolFlag{
+							Name:  "json",
+							Usage: "print raw events as JSON",
+						},
+					},
+				},
 				{
 					Name:     "trackers",
 					Usage:    "get trackers of torrent",
@@ -306,6 +364,30 @@ func main() {
 						},
 					},
 				},
+				{
+					Name:     "connection-attempts",
+					Usage:    "get recent connection attempts of torrent",
+					Category: "Getters",
+					Action:   handleConnectionAttempts,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "id",
+							Required: true,
+						},
+					},
+				},
+				{
+					Name:     "disconnected-peers",
+					Usage:    "get recently disconnected peers of torrent",
+					Category: "Getters",
+					Action:   handleDisconnectedPeers,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:     "id",
+							Required: true,
+						},
+					},
+				},
 				{
 					Name:     "add-peer",
 					Usage:    "add peer to torrent",
@@ -340,6 +422,48 @@ func main() {
 						},
 					},
 				},
+				{
+					Name:     "bans",
+					Usage:    "list banned IPs and peer ID prefixes",
+					Category: "Getters",
+					Action:   handleBans,
+				},
+				{
+					Name:     "add-ban",
+					Usage:    "ban peers in all torrents",
+					Category: "Actions",
+					Action:   handleAddBan,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "ip",
+							Usage: "IP address of the peer",
+						},
+						cli.StringFlag{
+							Name:  "peer-id-prefix",
+							Usage: "ban peers with IDs starting with this prefix",
+						},
+						cli.StringFlag{
+							Name:  "reason",
+							Usage: "reason of the ban",
+						},
+					},
+				},
+				{
+					Name:     "remove-ban",
+					Usage:    "remove ban of an IP or peer ID prefix",
+					Category: "Actions",
+					Action:   handleRemoveBan,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "ip",
+							Usage: "IP address of the peer",
+						},
+						cli.StringFlag{
+							Name:  "peer-id-prefix",
+							Usage: "peer ID prefix",
+						},
+					},
+				},
 				{
 					Name:     "announce",
 					Usage:    "announce to tracker",
@@ -347,8 +471,11 @@ func main() {
 					Action:   handleAnnounce,
 					Flags: []cli.Flag{
 						cli.StringFlag{
-							Name:     "id",
-							Required: true,
+							Name: "id",
+						},
+						cli.StringFlag{
+							Name:  "filter",
+							Usage: "select torrents with space separated key=value pairs instead of id (keys: id, status, name, added-since)",
 						},
 					},
 				},
@@ -359,8 +486,11 @@ func main() {
 					Action:   handleVerify,
 					Flags: []cli.Flag{
 						cli.StringFlag{
-							Name:     "id",
-							Required: true,
+							Name: "id",
+						},
+						cli.StringFlag{
+							Name:  "filter",
+							Usage: "select torrents with space separated key=value pairs instead of id (keys: id, status, name, added-since)",
 						},
 					},
 				},
@@ -371,8 +501,11 @@ func main() {
 					Action:   handleStart,
 					Flags: []cli.Flag{
 						cli.StringFlag{
-							Name:     "id",
-							Required: true,
+							Name: "id",
+						},
+						cli.StringFlag{
+							Name:  "filter",
+							Usage: "select torrents with space separated key=value pairs instead of id (keys: id, status, name, added-since)",
 						},
 					},
 				},
@@ -383,8 +516,11 @@ func main() {
 					Action:   handleStop,
 					Flags: []cli.Flag{
 						cli.StringFlag{
-							Name:     "id",
-							Required: true,
+							Name: "id",
+						},
+						cli.StringFlag{
+							Name:  "filter",
+							Usage: "select torrents with space separated key=value pairs instead of id (keys: id, status, name, added-since)",
 						},
 					},
 				},
@@ -413,7 +549,7 @@ func main() {
 						cli.StringFlag{
 							Name:     "target",
 							Required: true,
-							Usage:    "target server in host:port format",
+							Usage:    "target server in host:port, http(s)://[user:pass@]host:port or unix:///path/to/socket format",
 						},
 					},
 				},
@@ -892,6 +1028,25 @@ func handleMagnetToTorrent(c *cli.Context) error {
 func handleBeforeClient(c *cli.Context) error {
 	clt = rainrpc.NewClient(c.String("url"))
 	clt.SetTimeout(c.Duration("timeout"))
+	if c.IsSet("token") {
+		clt.SetToken(c.String("token"))
+	} else if c.IsSet("username") {
+		clt.SetBasicAuth(c.String("username"), c.String("password"))
+	}
+	if c.IsSet("ca-cert") || c.Bool("insecure") {
+		tlsConfig := &tls.Config{InsecureSkipVerify: c.Bool("insecure")} // nolint: gosec
+		if c.IsSet("ca-cert") {
+			b, err := os.ReadFile(c.String("ca-cert"))
+			if err != nil {
+				return err
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(b) {
+				return errors.New("cannot parse CA certificate")
+			}
+		}
+		clt.SetTLSConfig(tlsConfig)
+	}
 	return nil
 }
 
@@ -931,6 +1086,8 @@ func handleAdd(c *cli.Context) error {
 		Stopped:           c.Bool("stopped"),
 		StopAfterDownload: c.Bool("stop-after-download"),
 		StopAfterMetadata: c.Bool("stop-after-metadata"),
+		ChokingAlgorithm:  c.String("choking-algorithm"),
+		SuperSeeding:      c.Bool("super-seeding"),
 		ID:                c.String("id"),
 	}
 	if isURI(arg) {
@@ -960,7 +1117,7 @@ func handleAdd(c *cli.Context) error {
 }
 
 func handleRemove(c *cli.Context) error {
-	return clt.RemoveTorrent(c.String("id"))
+	return handleSingleOrBatch(c, clt.RemoveTorrent, clt.RemoveTorrents)
 }
 
 func handleCleanDatabase(c *cli.Context) error {
@@ -968,6 +1125,26 @@ func handleCleanDatabase(c *cli.Context) error {
 }
 
 func handleStats(c *cli.Context) error {
+	if c.IsSet("filter") {
+		f, err := rainrpc.ParseFilter(c.String("filter"))
+		if err != nil {
+			return err
+		}
+		results, err := clt.GetTorrentsStats(f)
+		if err != nil {
+			return err
+		}
+		b, err := prettyjson.Marshal(results)
+		if err != nil {
+			return err
+		}
+		_, _ = os.Stdout.Write(b)
+		_, _ = os.Stdout.WriteString("\n")
+		return nil
+	}
+	if c.String("id") == "" {
+		return errIDOrFilterRequired
+	}
 	s, err := clt.GetTorrentStats(c.String("id"))
 	if err != nil {
 		return err
@@ -1003,6 +1180,39 @@ func handleSessionStats(c *cli.Context) error {
 	return nil
 }
 
+func handleEvents(c *cli.Context) error {
+	events, err := clt.Subscribe(context.Background())
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for e := range events {
+		if c.IsSet("id") && e.TorrentID != c.String("id") {
+			continue
+		}
+		if c.Bool("json") {
+			err = enc.Encode(e)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		var detail string
+		switch {
+		case e.Status != "":
+			detail = e.Status
+		case e.Tracker != "":
+			detail = e.Tracker + ": " + e.Error
+		case e.Error != "":
+			detail = e.Error
+		case e.Type == "peers":
+			detail = strconv.Itoa(e.Peers)
+		}
+		fmt.Printf("%s %-13s %s %s\n", e.Time.Format(time.RFC3339), e.Type, e.TorrentID, detail)
+	}
+	return errors.New("event stream closed")
+}
+
 func handleTrackers(c *cli.Context) error {
 	resp, err := clt.GetTorrentTrackers(c.String("id"))
 	if err != nil {
@@ -1073,6 +1283,34 @@ func handlePeers(c *cli.Context) error {
 	return nil
 }
 
+func handleConnectionAttempts(c *cli.Context) error {
+	resp, err := clt.GetTorrentConnectionAttempts(c.String("id"))
+	if err != nil {
+		return err
+	}
+	b, err := prettyjson.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, _ = os.Stdout.Write(b)
+	_, _ = os.Stdout.WriteString("\n")
+	return nil
+}
+
+func handleDisconnectedPeers(c *cli.Context) error {
+	resp, err := clt.GetTorrentDisconnectedPeers(c.String("id"))
+	if err != nil {
+		return err
+	}
+	b, err := prettyjson.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, _ = os.Stdout.Write(b)
+	_, _ = os.Stdout.WriteString("\n")
+	return nil
+}
+
 func handleAddPeer(c *cli.Context) error {
 	return clt.AddPeer(c.String("id"), c.String("addr"))
 }
@@ -1081,20 +1319,76 @@ func handleAddTracker(c *cli.Context) error {
 	return clt.AddTracker(c.String("id"), c.String("tracker"))
 }
 
+func handleBans(c *cli.Context) error {
+	resp, err := clt.ListBans()
+	if err != nil {
+		return err
+	}
+	b, err := prettyjson.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	_, _ = os.Stdout.Write(b)
+	_, _ = os.Stdout.WriteString("\n")
+	return nil
+}
+
+func handleAddBan(c *cli.Context) error {
+	return clt.AddBan(c.String("ip"), c.String("peer-id-prefix"), c.String("reason"))
+}
+
+func handleRemoveBan(c *cli.Context) error {
+	return clt.RemoveBan(c.String("ip"), c.String("peer-id-prefix"))
+}
+
 func handleAnnounce(c *cli.Context) error {
-	return clt.AnnounceTorrent(c.String("id"))
+	return handleSingleOrBatch(c, clt.AnnounceTorrent, clt.AnnounceTorrents)
 }
 
 func handleVerify(c *cli.Context) error {
-	return clt.VerifyTorrent(c.String("id"))
+	return handleSingleOrBatch(c, clt.VerifyTorrent, clt.VerifyTorrents)
 }
 
 func handleStart(c *cli.Context) error {
-	return clt.StartTorrent(c.String("id"))
+	return handleSingleOrBatch(c, clt.StartTorrent, clt.StartTorrents)
 }
 
 func handleStop(c *cli.Context) error {
-	return clt.StopTorrent(c.String("id"))
+	return handleSingleOrBatch(c, clt.StopTorrent, clt.StopTorrents)
+}
+
+var errIDOrFilterRequired = errors.New("one of --id or --filter flags is required")
+
+// handleSingleOrBatch calls single with the value of --id flag or batch with the value of --filter flag.
+// Results of the batch call are printed and an error is returned if any of the torrents has failed.
+func handleSingleOrBatch(c *cli.Context, single func(id string) error, batch func(f rainrpc.Filter) ([]rpctypes.BatchResult, error)) error {
+	if !c.IsSet("filter") {
+		if c.String("id") == "" {
+			return errIDOrFilterRequired
+		}
+		return single(c.String("id"))
+	}
+	f, err := rainrpc.ParseFilter(c.String("filter"))
+	if err != nil {
+		return err
+	}
+	results, err := batch(f)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+			fmt.Printf("%s: %s\n", r.ID, r.Error)
+		} else {
+			fmt.Printf("%s: ok\n", r.ID)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed for %d of %d torrents", failed, len(results))
+	}
+	return nil
 }
 
 func handleStartAll(c *cli.Context) error {
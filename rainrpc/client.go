@@ -2,9 +2,17 @@
 package rainrpc
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/rain/internal/rpctypes"
@@ -15,18 +23,33 @@ import (
 type Client struct {
 	client     *jsonrpc2.Client
 	httpClient *http.Client
+	transport  *authTransport
 	addr       string
+	url        string
 }
 
 // NewClient returns a new Client for remote address.
+// addr is the URL of the server. Use "unix:///path/to/socket" form for connecting over a Unix domain socket.
 func NewClient(addr string) *Client {
+	t := &authTransport{base: http.DefaultTransport.(*http.Transport).Clone()}
+	url := addr
+	if path, ok := strings.CutPrefix(addr, "unix://"); ok {
+		t.base.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		}
+		url = "http://unix/"
+	}
 	hc := &http.Client{
-		Timeout: 10 * time.Second,
+		Timeout:   10 * time.Second,
+		Transport: t,
 	}
 	return &Client{
-		client:     jsonrpc2.NewCustomHTTPClient(addr, hc),
+		client:     jsonrpc2.NewCustomHTTPClient(url, hc),
 		httpClient: hc,
+		transport:  t,
 		addr:       addr,
+		url:        url,
 	}
 }
 
@@ -35,6 +58,22 @@ func (c *Client) SetTimeout(d time.Duration) {
 	c.httpClient.Timeout = d
 }
 
+// SetToken sets the bearer token that is sent with each request.
+func (c *Client) SetToken(token string) {
+	c.transport.token = token
+}
+
+// SetBasicAuth sets the username and password that is sent with each request.
+func (c *Client) SetBasicAuth(username, password string) {
+	c.transport.username = username
+	c.transport.password = password
+}
+
+// SetTLSConfig sets the TLS configuration for connecting to a server over HTTPS.
+func (c *Client) SetTLSConfig(cfg *tls.Config) {
+	c.transport.base.TLSClientConfig = cfg
+}
+
 // Addr returns the address of remote Session.
 func (c *Client) Addr() string {
 	return c.addr
@@ -57,12 +96,24 @@ func (c *Client) ListTorrents() ([]rpctypes.Torrent, error) {
 	return reply.Torrents, c.client.Call("Session.ListTorrents", nil, &reply)
 }
 
+// ListTorrentsWithStats returns the torrents in remote Session with the requested fields of their stats.
+// All fields are returned if fields is empty. Torrents are sorted by the time they are added.
+// offset and limit can be used for pagination. The total number of torrents in the Session is returned in total.
+func (c *Client) ListTorrentsWithStats(fields []string, offset, limit int) (torrents []rpctypes.TorrentWithStats, total int, err error) {
+	args := rpctypes.ListTorrentsWithStatsRequest{Fields: fields, Offset: offset, Limit: limit}
+	var reply rpctypes.ListTorrentsWithStatsResponse
+	err = c.client.Call("Session.ListTorrentsWithStats", args, &reply)
+	return reply.Torrents, reply.Total, err
+}
+
 // AddTorrentOptions contains optional parameters for adding a new Torrent.
 type AddTorrentOptions struct {
 	ID                string
 	Stopped           bool
 	StopAfterDownload bool
 	StopAfterMetadata bool
+	ChokingAlgorithm  string
+	SuperSeeding      bool
 }
 
 // AddTorrent adds a new torrent by reading .torrent file.
@@ -77,6 +128,8 @@ func (c *Client) AddTorrent(f io.Reader, options *AddTorrentOptions) (*rpctypes.
 		args.AddTorrentOptions.Stopped = options.Stopped
 		args.AddTorrentOptions.StopAfterDownload = options.StopAfterDownload
 		args.AddTorrentOptions.StopAfterMetadata = options.StopAfterMetadata
+		args.AddTorrentOptions.ChokingAlgorithm = options.ChokingAlgorithm
+		args.AddTorrentOptions.SuperSeeding = options.SuperSeeding
 	}
 	var reply rpctypes.AddTorrentResponse
 	return &reply.Torrent, c.client.Call("Session.AddTorrent", args, &reply)
@@ -90,6 +143,8 @@ func (c *Client) AddURI(uri string, options *AddTorrentOptions) (*rpctypes.Torre
 		args.AddTorrentOptions.Stopped = options.Stopped
 		args.AddTorrentOptions.StopAfterDownload = options.StopAfterDownload
 		args.AddTorrentOptions.StopAfterMetadata = options.StopAfterMetadata
+		args.AddTorrentOptions.ChokingAlgorithm = options.ChokingAlgorithm
+		args.AddTorrentOptions.SuperSeeding = options.SuperSeeding
 	}
 	var reply rpctypes.AddURIResponse
 	return &reply.Torrent, c.client.Call("Session.AddURI", args, &reply)
@@ -156,6 +211,20 @@ func (c *Client) GetTorrentPeers(id string) ([]rpctypes.Peer, error) {
 	return reply.Peers, c.client.Call("Session.GetTorrentPeers", args, &reply)
 }
 
+// GetTorrentConnectionAttempts returns the recent connection attempts of a torrent.
+func (c *Client) GetTorrentConnectionAttempts(id string) ([]rpctypes.ConnectionAttempt, error) {
+	args := rpctypes.GetTorrentConnectionAttemptsRequest{ID: id}
+	var reply rpctypes.GetTorrentConnectionAttemptsResponse
+	return reply.ConnectionAttempts, c.client.Call("Session.GetTorrentConnectionAttempts", args, &reply)
+}
+
+// GetTorrentDisconnectedPeers returns the list of recently disconnected peers of a torrent.
+func (c *Client) GetTorrentDisconnectedPeers(id string) ([]rpctypes.Peer, error) {
+	args := rpctypes.GetTorrentDisconnectedPeersRequest{ID: id}
+	var reply rpctypes.GetTorrentDisconnectedPeersResponse
+	return reply.Peers, c.client.Call("Session.GetTorrentDisconnectedPeers", args, &reply)
+}
+
 // GetTorrentWebseeds returns the WebSeed sources of a torrent.
 func (c *Client) GetTorrentWebseeds(id string) ([]rpctypes.Webseed, error) {
 	args := rpctypes.GetTorrentWebseedsRequest{ID: id}
@@ -205,6 +274,7 @@ func (c *Client) VerifyTorrent(id string) error {
 }
 
 // MoveTorrent moves the torrent to another Session.
+// The request to target is sent by the remote Session. See torrent.Torrent.Move for the supported target forms.
 func (c *Client) MoveTorrent(id, target string) error {
 	args := rpctypes.MoveTorrentRequest{ID: id, Target: target}
 	var reply rpctypes.MoveTorrentResponse
@@ -238,3 +308,94 @@ func (c *Client) AddTracker(id string, uri string) error {
 	var reply rpctypes.AddTrackerResponse
 	return c.client.Call("Session.AddTracker", args, &reply)
 }
+
+// ListBans returns the banned IPs and peer ID prefixes.
+func (c *Client) ListBans() ([]rpctypes.Ban, error) {
+	args := rpctypes.ListBansRequest{}
+	var reply rpctypes.ListBansResponse
+	return reply.Bans, c.client.Call("Session.ListBans", args, &reply)
+}
+
+// AddBan bans an IP or peer ID prefix in all torrents. Only one of ip and peerIDPrefix must be given.
+func (c *Client) AddBan(ip, peerIDPrefix, reason string) error {
+	args := rpctypes.AddBanRequest{Ban: rpctypes.Ban{IP: ip, PeerIDPrefix: peerIDPrefix, Reason: reason}}
+	var reply rpctypes.AddBanResponse
+	return c.client.Call("Session.AddBan", args, &reply)
+}
+
+// RemoveBan removes the ban of an IP or peer ID prefix.
+func (c *Client) RemoveBan(ip, peerIDPrefix string) error {
+	args := rpctypes.RemoveBanRequest{IP: ip, PeerIDPrefix: peerIDPrefix}
+	var reply rpctypes.RemoveBanResponse
+	return c.client.Call("Session.RemoveBan", args, &reply)
+}
+
+// Subscribe opens the event stream of the remote Session.
+// Events are sent to the returned channel until ctx is cancelled or the connection is closed.
+// The channel is closed after the stream ends.
+func (c *Client) Subscribe(ctx context.Context) (<-chan rpctypes.Event, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.url, "/")+"/events", nil)
+	if err != nil {
+		return nil, err
+	}
+	// Do not use c.httpClient because its timeout would close the stream.
+	hc := &http.Client{Transport: c.transport}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("bad HTTP Status: %s", resp.Status)
+	}
+	ch := make(chan rpctypes.Event)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+		var data []byte
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if b, ok := bytes.CutPrefix(line, []byte("data: ")); ok {
+				data = append(data, b...)
+				continue
+			}
+			if len(line) > 0 || len(data) == 0 {
+				// Event name, comment or empty line without data.
+				continue
+			}
+			var e rpctypes.Event
+			err := json.Unmarshal(data, &e)
+			data = data[:0]
+			if err != nil {
+				return
+			}
+			select {
+			case ch <- e:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// authTransport adds credentials to the requests sent to the server.
+type authTransport struct {
+	base     *http.Transport
+	token    string
+	username string
+	password string
+}
+
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.token != "" || t.username != "" {
+		req = req.Clone(req.Context())
+		if t.token != "" {
+			req.Header.Set("Authorization", "Bearer "+t.token)
+		} else {
+			req.SetBasicAuth(t.username, t.password)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
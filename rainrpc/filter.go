@@ -0,0 +1,102 @@
+package rainrpc
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cenkalti/rain/internal/rpctypes"
+)
+
+// Filter selects the torrents for batch methods. Torrents must match all of the non-empty fields.
+type Filter struct {
+	IDs []string
+	// Status of the torrent as returned in stats, e.g. "seeding" or "downloading-metadata". Case insensitive.
+	Status string
+	// Shell pattern for the torrent name.
+	Name string
+	// Torrents added at or after this time.
+	AddedSince time.Time
+}
+
+// ParseFilter parses a filter expression that consists of space separated key=value pairs.
+// Valid keys are "id", "status", "name" and "added-since".
+// "id" can be given multiple times.
+// "added-since" is either a RFC3339 time or a duration relative to now, like "24h".
+func ParseFilter(expr string) (Filter, error) {
+	var f Filter
+	for _, field := range strings.Fields(expr) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok || value == "" {
+			return f, errors.New("invalid filter: " + field)
+		}
+		switch key {
+		case "id":
+			f.IDs = append(f.IDs, value)
+		case "status":
+			f.Status = value
+		case "name":
+			f.Name = value
+		case "added-since":
+			if d, err := time.ParseDuration(value); err == nil {
+				f.AddedSince = time.Now().Add(-d)
+				break
+			}
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return f, errors.New("invalid added-since value: " + value)
+			}
+			f.AddedSince = t
+		default:
+			return f, errors.New("invalid filter key: " + key)
+		}
+	}
+	return f, nil
+}
+
+func (f Filter) rpc() rpctypes.TorrentFilter {
+	return rpctypes.TorrentFilter{
+		IDs:        f.IDs,
+		Status:     f.Status,
+		Name:       f.Name,
+		AddedSince: rpctypes.Time{Time: f.AddedSince},
+	}
+}
+
+func (c *Client) batch(method string, f Filter) ([]rpctypes.BatchResult, error) {
+	args := rpctypes.BatchRequest{Filter: f.rpc()}
+	var reply rpctypes.BatchResponse
+	return reply.Results, c.client.Call(method, args, &reply)
+}
+
+// RemoveTorrents removes the torrents matching the filter and deletes their data.
+func (c *Client) RemoveTorrents(f Filter) ([]rpctypes.BatchResult, error) {
+	return c.batch("Session.RemoveTorrents", f)
+}
+
+// StartTorrents starts the torrents matching the filter.
+func (c *Client) StartTorrents(f Filter) ([]rpctypes.BatchResult, error) {
+	return c.batch("Session.StartTorrents", f)
+}
+
+// StopTorrents stops the torrents matching the filter.
+func (c *Client) StopTorrents(f Filter) ([]rpctypes.BatchResult, error) {
+	return c.batch("Session.StopTorrents", f)
+}
+
+// VerifyTorrents verifies the pieces of the torrents matching the filter.
+func (c *Client) VerifyTorrents(f Filter) ([]rpctypes.BatchResult, error) {
+	return c.batch("Session.VerifyTorrents", f)
+}
+
+// AnnounceTorrents forces the torrents matching the filter to re-announce to trackers and DHT.
+func (c *Client) AnnounceTorrents(f Filter) ([]rpctypes.BatchResult, error) {
+	return c.batch("Session.AnnounceTorrents", f)
+}
+
+// GetTorrentsStats returns statistics about the torrents matching the filter.
+func (c *Client) GetTorrentsStats(f Filter) ([]rpctypes.StatsResult, error) {
+	args := rpctypes.GetTorrentsStatsRequest{Filter: f.rpc()}
+	var reply rpctypes.GetTorrentsStatsResponse
+	return reply.Results, c.client.Call("Session.GetTorrentsStats", args, &reply)
+}
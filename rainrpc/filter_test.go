@@ -0,0 +1,36 @@
+package rainrpc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	f, err := ParseFilter("id=a id=b status=seeding name=*.iso added-since=2020-01-02T03:04:05Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.IDs) != 2 || f.IDs[0] != "a" || f.IDs[1] != "b" {
+		t.Errorf("invalid ids: %v", f.IDs)
+	}
+	if f.Status != "seeding" || f.Name != "*.iso" {
+		t.Errorf("invalid filter: %+v", f)
+	}
+	if !f.AddedSince.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("invalid added-since: %s", f.AddedSince)
+	}
+
+	f, err = ParseFilter("added-since=1h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(f.AddedSince); d < time.Hour || d > time.Hour+time.Minute {
+		t.Errorf("invalid added-since: %s", f.AddedSince)
+	}
+
+	for _, expr := range []string{"foo=bar", "status", "added-since=yesterday"} {
+		if _, err = ParseFilter(expr); err == nil {
+			t.Errorf("expected error for %q", expr)
+		}
+	}
+}
@@ -79,6 +79,26 @@ type Config struct {
 	HealthCheckTimeout time.Duration
 	// The unix permission of created files, execute bit is removed for files
 	FilePermissions fs.FileMode
+	// Method for allocating files on disk. One of "sparse", "full" or "lazy".
+	// Sparse files are truncated to their final size and disk blocks are assigned by the filesystem when data is written.
+	// Full allocation reserves all disk blocks before download starts (fallocate on Linux, same as sparse on other platforms).
+	// Only new or extended files are allocated. Files stay sparse if the filesystem does not support fallocate.
+	// Lazy files are created empty and grow as pieces are written.
+	FileAllocation string
+	// Layout of the torrent data on disk. One of "files" or "pieces".
+	// With "files" layout, files of each torrent are saved under DataDir as they appear in the torrent.
+	// With "pieces" layout, each piece is saved in a separate file named after its hash under "pieces" directory in DataDir.
+	// Torrents that share pieces share the same piece files. Ignored if StorageProvider is set.
+	StorageLayout string
+	// Downloads are paused when free space on the disk is less than this number of bytes.
+	// Downloading continues automatically after free space goes above the limit. Zero value disables the check.
+	MinFreeDiskSpace int64
+	// Interval for checking free space on the disks that contain torrent files.
+	// Must be positive if MinFreeDiskSpace is set, otherwise NewSession returns an error.
+	DiskSpaceCheckInterval time.Duration
+	// Maximum total size of files of torrents added with AddTorrentOptions.InMemory.
+	// Files of least recently used stopped in-memory torrents are evicted when there is not enough space.
+	InMemoryStorageSize int64
 
 	// Enable RPC server
 	RPCEnabled bool
@@ -88,6 +108,26 @@ type Config struct {
 	RPCPort int
 	// Time to wait for ongoing requests before shutting down RPC HTTP server.
 	RPCShutdownTimeout time.Duration
+	// Path of the Unix domain socket for RPC server. If set, RPC server listens on the socket instead of RPCHost and RPCPort.
+	// A stale socket file from a previous run is removed. NewSession fails if another process listens on the socket or the path is not a socket.
+	RPCUnixSocket string
+	// The unix permission of the RPC socket file.
+	RPCUnixSocketPermissions fs.FileMode
+	// Certificate and private key files in PEM format for serving RPC over HTTPS.
+	// RPC server uses plain HTTP if empty. Not used when listening on RPCUnixSocket.
+	RPCTLSCertFile string
+	RPCTLSKeyFile  string
+	// Credentials of RPC clients. If empty, RPC server does not require authentication.
+	RPCCredentials []RPCCredential
+	// Include series for each torrent in /metrics endpoint of RPC server.
+	// Each torrent adds several series, so it is disabled by default to keep the cardinality low.
+	RPCMetricsPerTorrent bool
+	// Stats of torrents returned from ListTorrentsWithStats are cached for this duration.
+	RPCStatsCacheDuration time.Duration
+	// Serve a subset of Transmission RPC protocol at /transmission/rpc for compatibility with third-party clients.
+	RPCTransmissionEnabled bool
+	// Serve the web UI at /ui/ path of RPC server.
+	RPCWebUIEnabled bool
 
 	// Enable DHT node.
 	DHTEnabled bool
@@ -125,6 +165,12 @@ type Config struct {
 	UnchokedPeers int
 	// Number of optimistic unchoked peers.
 	OptimisticUnchokedPeers int
+	// Algorithm for selecting the peers to upload to. One of "fixed-slots", "rate-based" or "anti-leech".
+	// With "fixed-slots", UnchokedPeers peers that we download from (upload to, if seeding) fastest are unchoked.
+	// With "rate-based", UnchokedPeers is the minimum and more peers are unchoked while the upload capacity is not used fully.
+	// With "anti-leech", peers with fewer pieces are unchoked first while seeding.
+	// Can be overridden for each torrent with AddTorrentOptions.ChokingAlgorithm.
+	ChokingAlgorithm string
 	// Max number of blocks allowed to be queued without dropping any.
 	MaxRequestsIn int
 	// Max number of blocks requested from a peer but not received yet.
@@ -136,10 +182,20 @@ type Config struct {
 	RequestTimeout time.Duration
 	// Max number of running downloads on piece in endgame mode, snubbed and choed peers don't count
 	EndgameMaxDuplicateDownloads int
-	// Max number of outgoing connections to dial
+	// Max number of outgoing connections to dial for each torrent
 	MaxPeerDial int
-	// Max number of incoming connections to accept
+	// Max number of incoming connections to accept for each torrent
 	MaxPeerAccept int
+	// Max number of connections of a torrent, incoming and outgoing, including the ones doing handshake. Zero means no limit.
+	MaxConnectionsPerTorrent int
+	// Max number of connections in the session, incoming and outgoing, including the ones doing handshake.
+	// Each running torrent has an equal share of the connections. The unused part of a share is reserved for its torrent,
+	// so a busy torrent cannot starve the others. When a torrent below its share needs a connection while the limit is reached,
+	// a connection of the torrent that is furthest over its share is closed. Zero means no limit.
+	MaxConnections int
+	// Max number of connected peers of a torrent by the source of the peer.
+	// Keys are source names: tracker, dht, pex, manual, incoming, cache, holepunch. Sources that are not in the map are not limited.
+	MaxPeersPerSource map[string]int
 	// Running metadata downloads, snubbed peers don't count
 	ParallelMetadataDownloads int
 	// Time to wait for TCP connection to open.
@@ -150,8 +206,19 @@ type Config struct {
 	PieceReadTimeout time.Duration
 	// Max number of peer addresses to keep in connect queue.
 	MaxPeerAddresses int
+	// Number of peer addresses that we have connected before to keep in resume database for each torrent.
+	// On start, these addresses are dialed before other addresses, faster peers first. Zero disables the cache.
+	PeerCacheSize int
+	// A peer sending corrupt pieces is disconnected and not allowed to connect to the same torrent again.
+	// After this many corrupt pieces, the IP of the peer is banned in all torrents until the Session is closed.
+	// Failures are forgotten if the IP does not send another corrupt piece in an hour. Zero disables banning in all torrents.
+	PeerBanHashFailures int
 	// Number of allowed-fast messages to send after handshake.
 	AllowedFastSet int
+	// Maximum number of pieces in the read cache to suggest to each unchoked peer with suggest piece messages.
+	// Peers downloading the suggested pieces are served from the cache, which reduces disk reads.
+	// Zero disables sending suggestions.
+	SuggestPieces int
 
 	// Number of bytes to read when a piece is requested by a peer.
 	ReadCacheBlockSize int64
@@ -192,8 +259,34 @@ type Config struct {
 	// Number of maximum simulateous downloads from WebSeed sources.
 	WebseedMaxDownloads int
 
+	// Creates the storage for saving files of torrents. If nil, files are saved under DataDir.
+	StorageProvider StorageProvider
+
 	// Shell command to execute on torrent completion.
+	// It is run only once for each torrent.
 	OnCompleteCmd []string
+	// Shell commands to execute when a torrent is added, its metadata is downloaded, it is stopped with an error or removed.
+	// Hooks get information about the torrent in RAIN_* environment variables
+	// and a JSON object of rpctypes.HookPayload on stdin. Output of the command is logged.
+	OnAddCmd      []string
+	OnMetadataCmd []string
+	OnErrorCmd    []string
+	OnRemoveCmd   []string
+	// Hook commands are killed if they do not exit in this duration.
+	HookTimeout time.Duration
+	// Maximum number of hook commands running at the same time.
+	HookMaxParallel int
+
+	// HTTP endpoints to notify on torrent lifecycle events.
+	Webhooks []Webhook
+	// Number of attempts for delivering an event to a webhook before giving up.
+	WebhookMaxAttempts int
+	// Timeout for a single webhook request.
+	WebhookTimeout time.Duration
+	// Time to wait before retrying a failed webhook request. Doubled after each failed attempt.
+	WebhookRetryInterval time.Duration
+	// Emit EventRatioReached when the ratio of uploaded bytes to torrent size reaches this value. Disabled if zero.
+	EventRatio float64
 
 	// Replace default log handler
 	CustomLogHandler log.Handler
@@ -230,12 +323,19 @@ var DefaultConfig = Config{
 	HealthCheckInterval:                    10 * time.Second,
 	HealthCheckTimeout:                     60 * time.Second,
 	FilePermissions:                        0o750,
+	FileAllocation:                         "sparse",
+	StorageLayout:                          "files",
+	DiskSpaceCheckInterval:                 10 * time.Second,
+	InMemoryStorageSize:                    1 << 30,
 
 	// RPC Server
-	RPCEnabled:         true,
-	RPCHost:            "127.0.0.1",
-	RPCPort:            7246,
-	RPCShutdownTimeout: 5 * time.Second,
+	RPCEnabled:               true,
+	RPCHost:                  "127.0.0.1",
+	RPCPort:                  7246,
+	RPCShutdownTimeout:       5 * time.Second,
+	RPCUnixSocketPermissions: 0o600,
+	RPCStatsCacheDuration:    time.Second,
+	RPCWebUIEnabled:          true,
 
 	// Tracker
 	TrackerNumWant:              200,
@@ -263,6 +363,7 @@ var DefaultConfig = Config{
 	// Peer
 	UnchokedPeers:                3,
 	OptimisticUnchokedPeers:      1,
+	ChokingAlgorithm:             "fixed-slots",
 	MaxRequestsIn:                250,
 	MaxRequestsOut:               250,
 	DefaultRequestsOut:           50,
@@ -270,12 +371,17 @@ var DefaultConfig = Config{
 	EndgameMaxDuplicateDownloads: 20,
 	MaxPeerDial:                  80,
 	MaxPeerAccept:                20,
+	MaxConnectionsPerTorrent:     0,
+	MaxConnections:               500,
 	ParallelMetadataDownloads:    2,
 	PeerConnectTimeout:           5 * time.Second,
 	PeerHandshakeTimeout:         10 * time.Second,
 	PieceReadTimeout:             30 * time.Second,
 	MaxPeerAddresses:             2000,
+	PeerCacheSize:                50,
+	PeerBanHashFailures:          2,
 	AllowedFastSet:               10,
+	SuggestPieces:                10,
 
 	// IO
 	ReadCacheBlockSize: 128 << 10,
@@ -294,4 +400,39 @@ var DefaultConfig = Config{
 	WebseedVerifyTLS:               true,
 	WebseedMaxSources:              10,
 	WebseedMaxDownloads:            4,
+
+	// Hooks
+	HookTimeout:     5 * time.Minute,
+	HookMaxParallel: 4,
+
+	// Webhooks
+	WebhookMaxAttempts:   5,
+	WebhookTimeout:       10 * time.Second,
+	WebhookRetryInterval: time.Second,
+}
+
+// RPCCredential identifies a client of the RPC server.
+// Clients authenticate with a bearer token in the "Authorization" header or with HTTP basic authentication.
+type RPCCredential struct {
+	// Bearer token of the client.
+	Token string
+	// Username and password for HTTP basic authentication.
+	Username string
+	Password string
+	// Role of the client. One of "admin" or "readonly".
+	// Read-only clients can only call methods that do not modify the Session.
+	Role string
+}
+
+// Webhook is an HTTP endpoint that is notified on torrent events.
+// Events are sent as POST requests with a JSON body of rpctypes.WebhookPayload.
+type Webhook struct {
+	// URL of the endpoint.
+	URL string
+	// Names of the events to send. One of "added", "metadata", "completed", "error", "removed" or "ratio".
+	// All events are sent if empty.
+	Events []string
+	// If set, the request body is signed with HMAC-SHA256 using this key
+	// and the hex encoded signature is sent in "X-Rain-Signature" header.
+	Secret string
 }
@@ -1,6 +1,9 @@
 package torrent
 
 import (
+	"errors"
+	"syscall"
+
 	"github.com/cenkalti/rain/internal/announcer"
 )
 
@@ -45,3 +48,26 @@ func (e *AnnounceError) Unwrap() error {
 func (e *AnnounceError) Unknown() bool {
 	return e.err.Unknown
 }
+
+// AllocationError is the error that stops the torrent when files cannot be allocated on storage.
+type AllocationError struct {
+	err error
+}
+
+// Error implements error interface.
+func (e *AllocationError) Error() string {
+	if e.NoSpace() {
+		return "file allocation error: not enough space on disk"
+	}
+	return "file allocation error: " + e.err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *AllocationError) Unwrap() error {
+	return e.err
+}
+
+// NoSpace returns true if the allocation has failed because the disk is full.
+func (e *AllocationError) NoSpace() bool {
+	return errors.Is(e.err, syscall.ENOSPC)
+}
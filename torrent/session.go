@@ -17,13 +17,18 @@ import (
 	"github.com/cenkalti/rain/internal/blocklist"
 	"github.com/cenkalti/rain/internal/logger"
 	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peersource"
 	"github.com/cenkalti/rain/internal/piececache"
 	"github.com/cenkalti/rain/internal/resolver"
 	"github.com/cenkalti/rain/internal/resourcemanager"
 	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
 	"github.com/cenkalti/rain/internal/semaphore"
+	"github.com/cenkalti/rain/internal/storage/filestorage"
+	"github.com/cenkalti/rain/internal/storage/memorystorage"
+	"github.com/cenkalti/rain/internal/storage/piecestorage"
 	"github.com/cenkalti/rain/internal/tracker"
 	"github.com/cenkalti/rain/internal/trackermanager"
+	"github.com/cenkalti/rain/internal/unchoker"
 	"github.com/juju/ratelimit"
 	"github.com/mitchellh/go-homedir"
 	"github.com/nictuku/dht"
@@ -41,6 +46,8 @@ var (
 // Session contains torrents, DHT node, caches and other data structures shared by multiple torrents.
 type Session struct {
 	config         Config
+	allocation     filestorage.Allocation
+	memoryStorage  *memorystorage.Pool
 	db             *bbolt.DB
 	resumer        *boltdbresumer.Resumer
 	log            logger.Logger
@@ -53,14 +60,28 @@ type Session struct {
 	webseedClient  http.Client
 	createdAt      time.Time
 	semWrite       *semaphore.Semaphore
+	semHook        *semaphore.Semaphore
 	metrics        *sessionMetrics
 	bucketDownload *ratelimit.Bucket
 	bucketUpload   *ratelimit.Bucket
 	closeC         chan struct{}
 
+	maxPeersBySource map[peersource.Source]int
+
+	// Number of connections of running torrents for sharing Config.MaxConnections between torrents.
+	mConnections       sync.Mutex
+	torrentConnections map[*torrent]int
+	// Number of connections that torrents must close to give room to torrents below their share.
+	connectionsToClose map[*torrent]int
+
 	mPeerRequests   sync.Mutex
 	dhtPeerRequests map[*torrent]struct{}
 
+	mEvents          sync.Mutex
+	eventSubscribers map[chan Event]struct{}
+	webhooks         *webhookSender
+	statsCache       *statsCache
+
 	mTorrents          sync.RWMutex
 	torrents           map[string]*Torrent
 	torrentsByInfoHash map[dht.InfoHash][]*Torrent
@@ -72,6 +93,13 @@ type Session struct {
 	mBlocklist         sync.RWMutex
 	blocklist          *blocklist.Blocklist
 	blocklistTimestamp time.Time
+
+	mBans         sync.RWMutex
+	bannedIPs     map[string]Ban
+	bannedPeerIDs map[string]Ban
+	hashFailures  map[string]hashFailures
+	// IPs of peers that sent corrupt data are banned in the torrent until the ban is removed with RemoveBan.
+	torrentBannedIPs map[*torrent]map[string]struct{}
 }
 
 // NewSession creates a new Session for downloading and seeding torrents.
@@ -80,6 +108,37 @@ func NewSession(cfg Config) (*Session, error) {
 	if cfg.PortBegin >= cfg.PortEnd {
 		return nil, errors.New("invalid port range")
 	}
+	allocation, err := parseFileAllocation(cfg.FileAllocation)
+	if err != nil {
+		return nil, err
+	}
+	err = validateRPCCredentials(cfg.RPCCredentials)
+	if err != nil {
+		return nil, err
+	}
+	webhooks, err := newWebhookSender(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.HookMaxParallel < 1 {
+		return nil, errors.New("hook max parallel must be at least 1")
+	}
+	if cfg.MinFreeDiskSpace > 0 && cfg.DiskSpaceCheckInterval <= 0 {
+		return nil, errors.New("disk space check interval must be positive")
+	}
+	_, err = newUnchoker(cfg.ChokingAlgorithm, cfg.UnchokedPeers, cfg.OptimisticUnchokedPeers)
+	if err != nil {
+		return nil, err
+	}
+	switch cfg.StorageLayout {
+	case "", "files", "pieces":
+	default:
+		return nil, errors.New("invalid storage layout: " + cfg.StorageLayout)
+	}
+	maxPeersBySource, err := parseMaxPeersPerSource(cfg.MaxPeersPerSource)
+	if err != nil {
+		return nil, err
+	}
 	if cfg.MaxOpenFiles > 0 {
 		err := setNoFile(cfg.MaxOpenFiles)
 		if err != nil {
@@ -94,7 +153,6 @@ func NewSession(cfg Config) (*Session, error) {
 		logger.SetDebug()
 	}
 
-	var err error
 	cfg.Database, err = homedir.Expand(cfg.Database)
 	if err != nil {
 		return nil, err
@@ -125,6 +183,10 @@ func NewSession(cfg Config) (*Session, error) {
 		if err2 != nil {
 			return err2
 		}
+		_, err2 = tx.CreateBucketIfNotExists(bansBucket)
+		if err2 != nil {
+			return err2
+		}
 		b, err2 := tx.CreateBucketIfNotExists(torrentsBucket)
 		if err2 != nil {
 			return err2
@@ -169,6 +231,8 @@ func NewSession(cfg Config) (*Session, error) {
 	}
 	c := &Session{
 		config:             cfg,
+		allocation:         allocation,
+		memoryStorage:      memorystorage.NewPool(cfg.InMemoryStorageSize),
 		db:                 db,
 		resumer:            res,
 		blocklist:          bl,
@@ -177,12 +241,23 @@ func NewSession(cfg Config) (*Session, error) {
 		torrents:           make(map[string]*Torrent),
 		torrentsByInfoHash: make(map[dht.InfoHash][]*Torrent),
 		availablePorts:     ports,
+		eventSubscribers:   make(map[chan Event]struct{}),
+		webhooks:           webhooks,
+		statsCache:         newStatsCache(cfg.RPCStatsCacheDuration),
 		dht:                dhtNode,
 		pieceCache:         piececache.New(cfg.ReadCacheSize, cfg.ReadCacheTTL, cfg.ParallelReads),
 		ram:                resourcemanager.New[*peer.Peer](cfg.WriteCacheSize),
 		createdAt:          time.Now(),
 		semWrite:           semaphore.New(int(cfg.ParallelWrites)),
+		semHook:            semaphore.New(cfg.HookMaxParallel),
+		bannedIPs:          make(map[string]Ban),
+		bannedPeerIDs:      make(map[string]Ban),
+		hashFailures:       make(map[string]hashFailures),
+		torrentBannedIPs:   make(map[*torrent]map[string]struct{}),
 		closeC:             make(chan struct{}),
+		maxPeersBySource:   maxPeersBySource,
+		torrentConnections: make(map[*torrent]int),
+		connectionsToClose: make(map[*torrent]int),
 		webseedClient: http.Client{
 			Transport: &http.Transport{
 				DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
@@ -224,11 +299,19 @@ func NewSession(cfg Config) (*Session, error) {
 		ext.Set(63) // DHT Protocol (BEP 5)
 		c.dhtPeerRequests = make(map[*torrent]struct{})
 	}
+	err = c.loadBans()
+	if err != nil {
+		return nil, err
+	}
 	c.initMetrics()
 	c.loadExistingTorrents(ids)
 	if c.config.RPCEnabled {
 		c.rpc = newRPCServer(c)
-		err = c.rpc.Start(c.config.RPCHost, c.config.RPCPort)
+		if c.config.RPCUnixSocket != "" {
+			err = c.rpc.StartUnix(c.config.RPCUnixSocket, c.config.RPCUnixSocketPermissions)
+		} else {
+			err = c.rpc.Start(c.config.RPCHost, c.config.RPCPort)
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -237,9 +320,53 @@ func NewSession(cfg Config) (*Session, error) {
 		go c.processDHTResults()
 	}
 	go c.updateStatsLoop()
+	if cfg.MinFreeDiskSpace > 0 {
+		go c.checkDiskSpaceLoop()
+	}
 	return c, nil
 }
 
+func parseFileAllocation(s string) (filestorage.Allocation, error) {
+	switch s {
+	case "", "sparse":
+		return filestorage.Sparse, nil
+	case "full":
+		return filestorage.Full, nil
+	case "lazy":
+		return filestorage.Lazy, nil
+	default:
+		return 0, errors.New("invalid file allocation method: " + s)
+	}
+}
+
+func parseMaxPeersPerSource(limits map[string]int) (map[peersource.Source]int, error) {
+	ret := make(map[peersource.Source]int, len(limits))
+	for name, limit := range limits {
+		src, ok := peersource.Parse(name)
+		if !ok {
+			return nil, errors.New("invalid peer source: " + name)
+		}
+		if limit < 0 {
+			return nil, errors.New("max peers of source cannot be negative: " + name)
+		}
+		ret[src] = limit
+	}
+	return ret, nil
+}
+
+func newUnchoker(algorithm string, numUnchoked, numOptimisticUnchoked int) (unchoker.Algorithm, error) {
+	switch algorithm {
+	case "", "fixed-slots":
+		return unchoker.New(numUnchoked, numOptimisticUnchoked), nil
+	case "rate-based":
+		return unchoker.NewRateBased(numUnchoked, numOptimisticUnchoked), nil
+	case "anti-leech":
+		return unchoker.NewAntiLeech(numUnchoked, numOptimisticUnchoked), nil
+	default:
+		return nil, errors.New("invalid choking algorithm: " + algorithm)
+	}
+}
+
 func (s *Session) parseTrackers(tiers [][]string, private bool) []tracker.Tracker {
 	ret := make([]tracker.Tracker, 0, len(tiers))
 	for _, tier := range tiers {
@@ -289,6 +416,10 @@ func (s *Session) Close() error {
 	s.torrents = nil
 	s.mTorrents.Unlock()
 
+	// Event stream handlers of RPC server return after subscriptions are closed.
+	s.closeEventSubscribers()
+	s.webhooks.close()
+
 	if s.rpc != nil {
 		err := s.rpc.Stop(s.config.RPCShutdownTimeout)
 		if err != nil {
@@ -340,9 +471,35 @@ func (s *Session) GetTorrent(id string) *Torrent {
 
 // RemoveTorrent removes the torrent from the session and delete its files.
 func (s *Session) RemoveTorrent(id string) error {
+	return s.removeTorrent(id, false)
+}
+
+// removeTorrent removes the torrent from the session. Files of the torrent are deleted unless keepData is true.
+// Data of in-memory torrents is always discarded.
+func (s *Session) removeTorrent(id string, keepData bool) error {
 	t, err := s.removeTorrentFromClient(id)
 	if t != nil {
-		err = s.stopAndRemoveData(t)
+		e := Event{Type: EventTorrentRemoved, TorrentID: id, Time: time.Now()}
+		// Stats are not available after the torrent is closed.
+		var stats Stats
+		webhook := s.webhooks.wants(e.Type)
+		hook := len(s.hookCommand(e.Type)) > 0
+		if webhook || hook {
+			stats = t.Stats()
+		}
+		if keepData {
+			s.closeTorrent(t)
+		} else {
+			err = s.stopAndRemoveData(t)
+		}
+		s.forgetTorrentBans(t.torrent)
+		s.publishEvent(e)
+		if webhook {
+			s.webhooks.notify(e, stats)
+		}
+		if hook {
+			s.runHook(t.torrent, e, stats)
+		}
 	}
 	return err
 }
@@ -382,9 +539,34 @@ func (s *Session) removeTorrentFromClient(id string) (*Torrent, error) {
 	})
 }
 
-func (s *Session) stopAndRemoveData(t *Torrent) error {
+// closeTorrent stops the torrent and releases its resources without touching the files on disk.
+func (s *Session) closeTorrent(t *Torrent) {
 	t.torrent.Close()
 	s.releasePort(t.torrent.port)
+	if t.torrent.inMemory {
+		s.memoryStorage.Remove(t.torrent.storage.(*memorystorage.MemoryStorage))
+	}
+}
+
+func (s *Session) stopAndRemoveData(t *Torrent) error {
+	s.closeTorrent(t)
+	if t.torrent.inMemory {
+		return nil
+	}
+	if s.config.StorageProvider != nil {
+		err := s.config.StorageProvider.RemoveStorage(t.torrent.id)
+		if err != nil {
+			s.log.Errorf("cannot remove torrent storage. err: %s id: %s", err, t.torrent.id)
+		}
+		return err
+	}
+	if ps, ok := t.torrent.storage.(*piecestorage.PieceStorage); ok {
+		err := ps.RemovePieces(s.piecesInUse())
+		if err != nil {
+			s.log.Errorf("cannot remove torrent pieces. err: %s id: %s", err, t.torrent.id)
+		}
+		return err
+	}
 	var err error
 	var dest string
 	if s.config.DataDirIncludesTorrentID {
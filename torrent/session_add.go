@@ -14,7 +14,6 @@ import (
 	"github.com/cenkalti/rain/internal/metainfo"
 	"github.com/cenkalti/rain/internal/resumer"
 	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
-	"github.com/cenkalti/rain/internal/storage/filestorage"
 	"github.com/cenkalti/rain/internal/webseedsource"
 	"github.com/gofrs/uuid"
 	"github.com/nictuku/dht"
@@ -31,6 +30,19 @@ type AddTorrentOptions struct {
 	StopAfterDownload bool
 	// Stop torrent after metadata is downloaded from magnet links.
 	StopAfterMetadata bool
+	// Keep files in memory instead of saving them to disk.
+	// Total size of in-memory torrents is limited by Config.InMemoryStorageSize.
+	// Data of stopped in-memory torrents may be evicted to make room for other in-memory torrents
+	// and it is lost when the Session is closed.
+	// Use with StopAfterDownload and read files with Torrent.OpenFile after Torrent.NotifyComplete.
+	InMemory bool
+	// Algorithm for selecting the peers to upload to. See Config.ChokingAlgorithm for possible values.
+	// Empty value means Config.ChokingAlgorithm.
+	ChokingAlgorithm string
+	// Do BEP 16 super-seeding when the torrent is seeding.
+	// Pieces are advertised to peers one at a time until all pieces are distributed to the swarm.
+	// Useful for publishing new content from a single seed.
+	SuperSeeding bool
 }
 
 // AddTorrent adds a new torrent to the session by reading .torrent metainfo from reader.
@@ -66,7 +78,7 @@ func (s *Session) addTorrentStopped(r io.Reader, opt *AddTorrentOptions) (*Torre
 	if err != nil {
 		return nil, newInputError(err)
 	}
-	id, port, sto, err := s.add(opt)
+	id, port, sto, layout, err := s.add(opt)
 	if err != nil {
 		return nil, err
 	}
@@ -92,10 +104,14 @@ func (s *Session) addTorrentStopped(r io.Reader, opt *AddTorrentOptions) (*Torre
 		opt.StopAfterDownload,
 		opt.StopAfterMetadata,
 		false, // completeCmdRun
+		opt.ChokingAlgorithm,
 	)
 	if err != nil {
 		return nil, err
 	}
+	t.inMemory = opt.InMemory
+	t.storageLayout = layout
+	t.superSeeding = opt.SuperSeeding
 	go s.checkTorrent(t)
 	defer func() {
 		if err != nil {
@@ -112,12 +128,17 @@ func (s *Session) addTorrentStopped(r io.Reader, opt *AddTorrentOptions) (*Torre
 		AddedAt:           t.addedAt,
 		StopAfterDownload: opt.StopAfterDownload,
 		StopAfterMetadata: opt.StopAfterMetadata,
+		InMemory:          opt.InMemory,
+		ChokingAlgorithm:  opt.ChokingAlgorithm,
+		SuperSeeding:      opt.SuperSeeding,
+		StorageLayout:     layout,
 	}
 	err = s.resumer.Write(id, rspec)
 	if err != nil {
 		return nil, err
 	}
 	t2 := s.insertTorrent(t)
+	s.notifyAdded(t2)
 	return t2, nil
 }
 
@@ -179,7 +200,7 @@ func (s *Session) addMagnet(link string, opt *AddTorrentOptions) (*Torrent, erro
 	if err != nil {
 		return nil, newInputError(err)
 	}
-	id, port, sto, err := s.add(opt)
+	id, port, sto, layout, err := s.add(opt)
 	if err != nil {
 		return nil, err
 	}
@@ -205,10 +226,14 @@ func (s *Session) addMagnet(link string, opt *AddTorrentOptions) (*Torrent, erro
 		opt.StopAfterDownload,
 		opt.StopAfterMetadata,
 		false, // completeCmdRun
+		opt.ChokingAlgorithm,
 	)
 	if err != nil {
 		return nil, err
 	}
+	t.inMemory = opt.InMemory
+	t.storageLayout = layout
+	t.superSeeding = opt.SuperSeeding
 	go s.checkTorrent(t)
 	defer func() {
 		if err != nil {
@@ -224,19 +249,29 @@ func (s *Session) addMagnet(link string, opt *AddTorrentOptions) (*Torrent, erro
 		AddedAt:           t.addedAt,
 		StopAfterDownload: opt.StopAfterDownload,
 		StopAfterMetadata: opt.StopAfterMetadata,
+		InMemory:          opt.InMemory,
+		ChokingAlgorithm:  opt.ChokingAlgorithm,
+		SuperSeeding:      opt.SuperSeeding,
+		StorageLayout:     layout,
 	}
 	err = s.resumer.Write(id, rspec)
 	if err != nil {
 		return nil, err
 	}
 	t2 := s.insertTorrent(t)
+	s.notifyAdded(t2)
 	if !opt.Stopped {
 		err = t2.Start()
 	}
 	return t2, err
 }
 
-func (s *Session) add(opt *AddTorrentOptions) (id string, port int, sto *filestorage.FileStorage, err error) {
+func (s *Session) add(opt *AddTorrentOptions) (id string, port int, sto Storage, layout string, err error) {
+	_, err = newUnchoker(opt.ChokingAlgorithm, s.config.UnchokedPeers, s.config.OptimisticUnchokedPeers)
+	if err != nil {
+		err = newInputError(err)
+		return
+	}
 	port, err = s.getPort()
 	if err != nil {
 		return
@@ -266,10 +301,8 @@ func (s *Session) add(opt *AddTorrentOptions) (id string, port int, sto *filesto
 		}
 		id = base64.RawURLEncoding.EncodeToString(u1[:])
 	}
-	sto, err = filestorage.New(s.getDataDir(id), s.config.FilePermissions)
-	if err != nil {
-		return
-	}
+	layout = s.config.StorageLayout
+	sto, err = s.newStorage(id, opt.InMemory, layout)
 	return
 }
 
@@ -285,3 +318,22 @@ func (s *Session) insertTorrent(t *torrent) *Torrent {
 	s.torrentsByInfoHash[ih] = append(s.torrentsByInfoHash[ih], t2)
 	return t2
 }
+
+// notifyAdded emits EventTorrentAdded for a torrent that is newly added to the Session.
+// It is not called for the torrents loaded from the resume database on startup.
+func (s *Session) notifyAdded(t *Torrent) {
+	e := Event{Type: EventTorrentAdded, TorrentID: t.torrent.id, Time: time.Now()}
+	s.publishEvent(e)
+	webhook := s.webhooks.wants(e.Type)
+	hook := len(s.hookCommand(e.Type)) > 0
+	if !webhook && !hook {
+		return
+	}
+	stats := t.Stats()
+	if webhook {
+		s.webhooks.notify(e, stats)
+	}
+	if hook {
+		s.runHook(t.torrent, e, stats)
+	}
+}
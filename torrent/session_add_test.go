@@ -1,10 +1,13 @@
 package torrent
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 
+	"github.com/cenkalti/rain/internal/unchoker"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestInvalidTorrentData is test case for reproducing bug:
@@ -19,3 +22,40 @@ func TestInvalidTorrentData(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestAddChokingAlgorithm(t *testing.T) {
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+
+	cfg.ChokingAlgorithm = "foo"
+	_, err := NewSession(cfg)
+	assert.Error(t, err)
+
+	cfg.ChokingAlgorithm = "rate-based"
+	s, err := NewSession(cfg)
+	require.NoError(t, err)
+
+	var e *InputError
+	_, err = s.AddURI(torrentMagnetLink, &AddTorrentOptions{Stopped: true, ChokingAlgorithm: "foo"})
+	assert.ErrorAs(t, err, &e)
+
+	tor, err := s.AddURI(torrentMagnetLink, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+	assert.IsType(t, &unchoker.RateBased{}, tor.torrent.unchoker)
+	tor2, err := s.AddURI(torrentMagnetLink, &AddTorrentOptions{Stopped: true, ChokingAlgorithm: "anti-leech"})
+	require.NoError(t, err)
+	assert.IsType(t, &unchoker.AntiLeech{}, tor2.torrent.unchoker)
+
+	// Choking algorithm of the torrent is loaded from the database.
+	require.NoError(t, s.Close())
+	s, err = NewSession(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+	assert.IsType(t, &unchoker.RateBased{}, s.GetTorrent(tor.ID()).torrent.unchoker)
+	assert.IsType(t, &unchoker.AntiLeech{}, s.GetTorrent(tor2.ID()).torrent.unchoker)
+}
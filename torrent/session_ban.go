@@ -0,0 +1,287 @@
+package torrent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var bansBucket = []byte("bans")
+
+// Hash failures of an IP are forgotten if there is no new failure in this duration.
+const hashFailureExpiry = time.Hour
+
+// Max number of IPs to keep hash failure counts for.
+const maxHashFailureIPs = 1000
+
+var errBanNotFound = errors.New("ban not found")
+
+// Ban prevents peers from connecting to the torrents in Session.
+// Exactly one of IP and PeerIDPrefix must be set.
+type Ban struct {
+	// IP address of the peer.
+	IP string
+	// Peers with IDs starting with this prefix are banned.
+	// Most clients put their name and version at the start of peer ID, e.g. "-XL0012-".
+	PeerIDPrefix string
+	// Reason of the ban.
+	Reason string
+	// Time when the ban is added. Set by AddBan if zero.
+	CreatedAt time.Time
+	// Automatic bans are added by Session for peers sending corrupt data.
+	// They are not saved in the database, so they are lifted when the Session is closed.
+	Automatic bool
+}
+
+// hashFailures is the number of corrupt pieces received from an IP.
+type hashFailures struct {
+	count int
+	last  time.Time
+}
+
+// key validates the ban, normalizes the IP and returns the key of the ban in database.
+func (b *Ban) key() (string, error) {
+	switch {
+	case b.IP != "" && b.PeerIDPrefix != "":
+		return "", errors.New("only one of IP and peer ID prefix can be set")
+	case b.IP != "":
+		ip := net.ParseIP(b.IP)
+		if ip == nil {
+			return "", errors.New("invalid IP: " + b.IP)
+		}
+		b.IP = ip.String()
+		return "ip:" + b.IP, nil
+	case b.PeerIDPrefix != "":
+		if len(b.PeerIDPrefix) > 20 {
+			return "", errors.New("peer ID prefix is longer than 20 bytes")
+		}
+		return "peer-id:" + b.PeerIDPrefix, nil
+	default:
+		return "", errors.New("IP or peer ID prefix is required")
+	}
+}
+
+func (s *Session) loadBans() error {
+	return s.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bansBucket).ForEach(func(k, v []byte) error {
+			var b Ban
+			err := json.Unmarshal(v, &b)
+			if err != nil {
+				s.log.Errorf("cannot load ban %q: %s", k, err)
+				return nil
+			}
+			s.putBan(b)
+			return nil
+		})
+	})
+}
+
+func (s *Session) putBan(b Ban) {
+	s.mBans.Lock()
+	defer s.mBans.Unlock()
+	if b.IP != "" {
+		s.bannedIPs[b.IP] = b
+	} else {
+		s.bannedPeerIDs[b.PeerIDPrefix] = b
+	}
+}
+
+// ListBans returns the bans in the order they are added.
+func (s *Session) ListBans() []Ban {
+	s.mBans.RLock()
+	bans := make([]Ban, 0, len(s.bannedIPs)+len(s.bannedPeerIDs))
+	for _, b := range s.bannedIPs {
+		bans = append(bans, b)
+	}
+	for _, b := range s.bannedPeerIDs {
+		bans = append(bans, b)
+	}
+	s.mBans.RUnlock()
+	sort.Slice(bans, func(i, j int) bool { return bans[i].CreatedAt.Before(bans[j].CreatedAt) })
+	return bans
+}
+
+// AddBan saves the ban into the database and disconnects the matching peers from all torrents.
+// Existing ban for the same IP or peer ID prefix is replaced.
+func (s *Session) AddBan(b Ban) error {
+	b.Automatic = false
+	return s.addBan(b)
+}
+
+func (s *Session) addBan(b Ban) error {
+	key, err := b.key()
+	if err != nil {
+		return newInputError(err)
+	}
+	if b.CreatedAt.IsZero() {
+		b.CreatedAt = time.Now()
+	}
+	if !b.Automatic {
+		val, err := json.Marshal(b)
+		if err != nil {
+			return err
+		}
+		err = s.db.Update(func(tx *bbolt.Tx) error {
+			return tx.Bucket(bansBucket).Put([]byte(key), val)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	s.putBan(b)
+	s.log.Infof("added ban %q: %s", key, b.Reason)
+	for _, t := range s.ListTorrents() {
+		t.torrent.notifyBansChanged()
+	}
+	return nil
+}
+
+// RemoveBan removes the ban matching IP or PeerIDPrefix of b.
+// Bans of the IP in single torrents, added for sending corrupt pieces, are removed too.
+func (s *Session) RemoveBan(b Ban) error {
+	key, err := b.key()
+	if err != nil {
+		return newInputError(err)
+	}
+	s.mBans.Lock()
+	if b.IP != "" {
+		_, ok := s.bannedIPs[b.IP]
+		for t, ips := range s.torrentBannedIPs {
+			if _, ok2 := ips[b.IP]; ok2 {
+				ok = true
+				delete(ips, b.IP)
+				if len(ips) == 0 {
+					delete(s.torrentBannedIPs, t)
+				}
+			}
+		}
+		if !ok {
+			s.mBans.Unlock()
+			return errBanNotFound
+		}
+		delete(s.bannedIPs, b.IP)
+		delete(s.hashFailures, b.IP)
+	} else {
+		_, ok := s.bannedPeerIDs[b.PeerIDPrefix]
+		if !ok {
+			s.mBans.Unlock()
+			return errBanNotFound
+		}
+		delete(s.bannedPeerIDs, b.PeerIDPrefix)
+	}
+	s.mBans.Unlock()
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return tx.Bucket(bansBucket).Delete([]byte(key))
+	})
+}
+
+func (s *Session) isIPBanned(ip string) bool {
+	s.mBans.RLock()
+	defer s.mBans.RUnlock()
+	_, ok := s.bannedIPs[ip]
+	return ok
+}
+
+// isIPBannedInTorrent returns true if ip is banned in the Session or only in torrent t.
+func (s *Session) isIPBannedInTorrent(t *torrent, ip string) bool {
+	s.mBans.RLock()
+	defer s.mBans.RUnlock()
+	if _, ok := s.bannedIPs[ip]; ok {
+		return true
+	}
+	_, ok := s.torrentBannedIPs[t][ip]
+	return ok
+}
+
+// banIPInTorrent bans ip in torrent t only.
+func (s *Session) banIPInTorrent(t *torrent, ip string) {
+	s.mBans.Lock()
+	defer s.mBans.Unlock()
+	ips, ok := s.torrentBannedIPs[t]
+	if !ok {
+		ips = make(map[string]struct{})
+		s.torrentBannedIPs[t] = ips
+	}
+	ips[ip] = struct{}{}
+}
+
+// forgetTorrentBans is called when torrent t is removed from the Session.
+func (s *Session) forgetTorrentBans(t *torrent) {
+	s.mBans.Lock()
+	delete(s.torrentBannedIPs, t)
+	s.mBans.Unlock()
+}
+
+func (s *Session) isPeerBanned(ip string, peerID [20]byte) bool {
+	s.mBans.RLock()
+	defer s.mBans.RUnlock()
+	if _, ok := s.bannedIPs[ip]; ok {
+		return true
+	}
+	for prefix := range s.bannedPeerIDs {
+		if strings.HasPrefix(string(peerID[:]), prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// recordHashFailure is called when the peer at ip sends data that does not match its hash.
+// The peer is banned in all torrents after Config.PeerBanHashFailures failures until the Session is closed.
+// It is called from torrent event loops, so the ban is added in a separate goroutine.
+func (s *Session) recordHashFailure(ip string) {
+	if s.config.PeerBanHashFailures <= 0 {
+		return
+	}
+	now := time.Now()
+	s.mBans.Lock()
+	f, ok := s.hashFailures[ip]
+	if !ok || now.Sub(f.last) > hashFailureExpiry {
+		f = hashFailures{}
+		if len(s.hashFailures) >= maxHashFailureIPs {
+			s.pruneHashFailures(now)
+		}
+	}
+	f.count++
+	f.last = now
+	n := f.count
+	_, banned := s.bannedIPs[ip]
+	if banned || n >= s.config.PeerBanHashFailures {
+		delete(s.hashFailures, ip)
+	} else {
+		s.hashFailures[ip] = f
+	}
+	s.mBans.Unlock()
+	if banned || n < s.config.PeerBanHashFailures {
+		return
+	}
+	go func() {
+		err := s.addBan(Ban{IP: ip, Reason: fmt.Sprintf("sent %d corrupt pieces", n), Automatic: true})
+		if err != nil {
+			s.log.Errorf("cannot ban peer %s: %s", ip, err)
+		}
+	}()
+}
+
+// pruneHashFailures removes the expired hash failures. If none is expired, the oldest one is removed.
+// Must be called with mBans held.
+func (s *Session) pruneHashFailures(now time.Time) {
+	var oldestIP string
+	var oldest time.Time
+	for ip, f := range s.hashFailures {
+		if now.Sub(f.last) > hashFailureExpiry {
+			delete(s.hashFailures, ip)
+		} else if oldestIP == "" || f.last.Before(oldest) {
+			oldestIP, oldest = ip, f.last
+		}
+	}
+	if len(s.hashFailures) >= maxHashFailureIPs {
+		delete(s.hashFailures, oldestIP)
+	}
+}
@@ -0,0 +1,130 @@
+package torrent
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/rpctypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestBans(t *testing.T) {
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+	s, err := NewSession(cfg)
+	require.NoError(t, err)
+
+	var e *InputError
+	assert.ErrorAs(t, s.AddBan(Ban{}), &e)
+	assert.ErrorAs(t, s.AddBan(Ban{IP: "1.2.3.4", PeerIDPrefix: "-XX"}), &e)
+	assert.ErrorAs(t, s.AddBan(Ban{IP: "1.2.3"}), &e)
+
+	require.NoError(t, s.AddBan(Ban{IP: "::ffff:1.2.3.4", Reason: "spam"}))
+	require.NoError(t, s.AddBan(Ban{PeerIDPrefix: "-XX"}))
+	var peerID [20]byte
+	copy(peerID[:], "-XX0100-abcdefghijkl")
+	assert.True(t, s.isIPBanned("1.2.3.4"))
+	assert.True(t, s.isPeerBanned("5.6.7.8", peerID))
+	copy(peerID[:], "-YY0100-")
+	assert.False(t, s.isPeerBanned("5.6.7.8", peerID))
+
+	// Bans are loaded from the database.
+	require.NoError(t, s.Close())
+	s, err = NewSession(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+	bans := s.ListBans()
+	require.Len(t, bans, 2)
+	assert.Equal(t, "1.2.3.4", bans[0].IP)
+	assert.Equal(t, "spam", bans[0].Reason)
+	assert.Equal(t, "-XX", bans[1].PeerIDPrefix)
+
+	h := &rpcHandler{session: s}
+	var reply rpctypes.ListBansResponse
+	require.NoError(t, h.ListBans(&rpctypes.ListBansRequest{}, &reply))
+	assert.Len(t, reply.Bans, 2)
+
+	require.NoError(t, s.RemoveBan(Ban{IP: "1.2.3.4"}))
+	assert.Equal(t, errBanNotFound, s.RemoveBan(Ban{IP: "1.2.3.4"}))
+	assert.False(t, s.isIPBanned("1.2.3.4"))
+	assert.Len(t, s.ListBans(), 1)
+}
+
+func TestBanAfterHashFailures(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.PeerBanHashFailures = 2
+
+	s.recordHashFailure("1.2.3.4")
+	assert.False(t, s.isIPBanned("1.2.3.4"))
+	s.recordHashFailure("1.2.3.4")
+	deadline := time.Now().Add(timeout)
+	for !s.isIPBanned("1.2.3.4") {
+		if time.Now().After(deadline) {
+			t.Fatal("peer is not banned")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, "sent 2 corrupt pieces", s.ListBans()[0].Reason)
+	assert.True(t, s.ListBans()[0].Automatic)
+}
+
+func TestAutomaticBanNotSaved(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	require.NoError(t, s.addBan(Ban{IP: "1.2.3.4", Automatic: true}))
+	assert.True(t, s.isIPBanned("1.2.3.4"))
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		assert.Nil(t, tx.Bucket(bansBucket).Get([]byte("ip:1.2.3.4")))
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestHashFailuresBounded(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.PeerBanHashFailures = 2
+
+	s.mBans.Lock()
+	s.hashFailures["1.2.3.4"] = hashFailures{count: 1, last: time.Now().Add(-2 * hashFailureExpiry)}
+	s.mBans.Unlock()
+	s.recordHashFailure("1.2.3.4")
+	assert.False(t, s.isIPBanned("1.2.3.4"))
+
+	for i := 0; i < maxHashFailureIPs+10; i++ {
+		s.recordHashFailure(fmt.Sprintf("10.0.%d.%d", i/256, i%256))
+	}
+	s.mBans.Lock()
+	n := len(s.hashFailures)
+	s.mBans.Unlock()
+	assert.LessOrEqual(t, n, maxHashFailureIPs)
+}
+
+func TestRemoveBanInTorrent(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	tor, port := startSeeding(t, s, nil)
+
+	s.banIPInTorrent(tor.torrent, "127.0.0.2")
+	require.NoError(t, s.AddBan(Ban{IP: "127.0.0.2"}))
+	require.NoError(t, s.RemoveBan(Ban{IP: "127.0.0.2"}))
+	assert.False(t, s.isIPBannedInTorrent(tor.torrent, "127.0.0.2"))
+
+	// Peer can connect to the torrent again.
+	conn := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 2), [20]byte{1})
+	defer conn.Close()
+	assert.Eventually(t, func() bool { return len(tor.Peers()) == 1 }, timeout, 10*time.Millisecond)
+	assert.Equal(t, errBanNotFound, s.RemoveBan(Ban{IP: "127.0.0.2"}))
+}
@@ -1,34 +1,165 @@
 package torrent
 
 import (
+	"bufio"
+	"bytes"
+	"context"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"time"
+
+	"github.com/cenkalti/rain/internal/rpctypes"
 )
 
-func (s *Session) runOnCompleteCmd(torrent *torrent) {
-	command, err := exec.LookPath(s.config.OnCompleteCmd[0])
+// hookOutputLimit is the maximum number of bytes logged from stdout and stderr of a hook command.
+const hookOutputLimit = 64 << 10
+
+// hookWaitDelay is the time to wait for the output pipes of a hook command to be closed after it is killed.
+// Pipes may be kept open by the processes started by the hook.
+const hookWaitDelay = 5 * time.Second
+
+// hookResult is the outcome of a hook command.
+type hookResult struct {
+	event    string
+	exitCode int
+	err      error
+	time     time.Time
+}
+
+func (s *Session) hookCommand(et EventType) []string {
+	switch et {
+	case EventTorrentAdded:
+		return s.config.OnAddCmd
+	case EventMetadataReceived:
+		return s.config.OnMetadataCmd
+	case EventCompleted:
+		return s.config.OnCompleteCmd
+	case EventError:
+		return s.config.OnErrorCmd
+	case EventTorrentRemoved:
+		return s.config.OnRemoveCmd
+	default:
+		return nil
+	}
+}
+
+// runHook runs the hook command for the event of torrent t in background if there is one configured.
+// It reads the fields of t, so it must be called from the torrent event loop or when the loop is not running.
+func (s *Session) runHook(t *torrent, e Event, stats Stats) {
+	args := s.hookCommand(e.Type)
+	if len(args) == 0 {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	var paths []string
+	if files, err := t.Files(); err == nil {
+		paths = make([]string, len(files))
+		for i, f := range files {
+			paths[i] = f.Path()
+		}
+	}
+	payload := rpctypes.HookPayload{
+		Event:     e.Type.String(),
+		TorrentID: t.id,
+		Time:      rpctypes.Time{Time: e.Time},
+		Dir:       t.Dir(),
+		Files:     paths,
+		Stats:     newStats(stats),
+	}
+	if e.Error != nil {
+		payload.Error = e.Error.Error()
+	}
+	stdin, err := json.Marshal(payload)
 	if err != nil {
-		s.log.Errorf("error resolving completion hook command path: %s", err)
+		panic(err)
+	}
+	env := []string{
+		"RAIN_EVENT=" + payload.Event,
+		"RAIN_TORRENT_ADDED=" + fmt.Sprint(t.addedAt.Unix()),
+		"RAIN_TORRENT_DIR=" + payload.Dir,
+		"RAIN_TORRENT_HASH=" + hex.EncodeToString(t.infoHash[:]),
+		"RAIN_TORRENT_ID=" + t.id,
+		"RAIN_TORRENT_NAME=" + stats.Name,
+		"RAIN_TORRENT_STATUS=" + payload.Stats.Status,
+		"RAIN_TORRENT_ERROR=" + payload.Error,
+		"RAIN_TORRENT_SIZE=" + fmt.Sprint(stats.Bytes.Total),
+		"RAIN_TORRENT_DOWNLOADED=" + fmt.Sprint(stats.Bytes.Downloaded),
+		"RAIN_TORRENT_UPLOADED=" + fmt.Sprint(stats.Bytes.Uploaded),
+	}
+	go s.execHook(t, payload.Event, args, env, stdin)
+}
+
+func (s *Session) execHook(t *torrent, event string, args, env []string, stdin []byte) {
+	s.semHook.Wait()
+	defer s.semHook.Signal()
+
+	res := hookResult{
+		event:    event,
+		exitCode: -1,
+		time:     time.Now(),
+	}
+	defer func() { t.setLastHook(res) }()
+
+	command, err := exec.LookPath(args[0])
+	if err != nil {
+		res.err = err
+		s.log.Errorf("error resolving %s hook command path: %s", event, err)
 		return
 	}
 
-	cmd := exec.Command(command)
-	if len(s.config.OnCompleteCmd) > 1 {
-		cmd.Args = append(cmd.Args, s.config.OnCompleteCmd[1:]...)
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.HookTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, command, args[1:]...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	stdout := &limitedBuffer{limit: hookOutputLimit}
+	stderr := &limitedBuffer{limit: hookOutputLimit}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = hookWaitDelay
+	setHookProcessGroup(cmd)
+
+	s.log.Debugf("executing %s hook for torrent %s: %s", event, t.id, cmd.String())
+
+	err = cmd.Run()
+	if cmd.ProcessState != nil {
+		res.exitCode = cmd.ProcessState.ExitCode()
 	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		err = fmt.Errorf("timeout after %s", s.config.HookTimeout)
+	}
+	res.err = err
 
-	cmd.Env = append(os.Environ(),
-		"RAIN_TORRENT_ADDED="+fmt.Sprint(torrent.addedAt.Unix()),
-		"RAIN_TORRENT_DIR="+torrent.Dir(),
-		"RAIN_TORRENT_HASH="+hex.EncodeToString(torrent.infoHash[:]),
-		"RAIN_TORRENT_ID="+torrent.id,
-		"RAIN_TORRENT_NAME="+torrent.name)
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		s.log.Infof("%s hook for torrent %s: %s", event, t.id, scanner.Text())
+	}
+	scanner = bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		s.log.Warningf("%s hook for torrent %s: %s", event, t.id, scanner.Text())
+	}
+	if err != nil {
+		s.log.Errorf("%s hook execution failed for torrent %s: %s", event, t.id, err)
+	}
+}
 
-	s.log.Debugf("executing completion hook for torrent %s: %s", torrent.id, cmd.String())
+// limitedBuffer discards the bytes written after the limit is reached.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
 
-	if err := cmd.Run(); err != nil {
-		s.log.Errorf("completion hook execution failed: %s", err)
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if remaining := b.limit - b.Len(); remaining < len(p) {
+		p = p[:max(remaining, 0)]
 	}
+	b.Buffer.Write(p)
+	return n, nil
 }
@@ -0,0 +1,118 @@
+package torrent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/rpctypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook uses sh")
+	}
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	payloadFile := filepath.Join(tmp, "payload.json")
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+	cfg.OnAddCmd = []string{"sh", "-c", `cat > "$0"; echo "$RAIN_EVENT $RAIN_TORRENT_NAME"; exit 3`, payloadFile}
+	s, err := NewSession(cfg)
+	require.NoError(t, err)
+
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+
+	waitForHook := func(tor *Torrent) Stats {
+		deadline := time.Now().Add(timeout)
+		for {
+			stats := tor.Stats()
+			if stats.LastHook.Event != "" {
+				return stats
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("hook did not run")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+	stats := waitForHook(tor)
+	assert.Equal(t, "added", stats.LastHook.Event)
+	assert.Equal(t, 3, stats.LastHook.ExitCode)
+	assert.Error(t, stats.LastHook.Error)
+
+	b, err := os.ReadFile(payloadFile)
+	require.NoError(t, err)
+	var p rpctypes.HookPayload
+	require.NoError(t, json.Unmarshal(b, &p))
+	assert.Equal(t, "added", p.Event)
+	assert.Equal(t, tor.ID(), p.TorrentID)
+	assert.Equal(t, tor.Dir(), p.Dir)
+	assert.Contains(t, p.Files, "sample_torrent/README")
+	assert.Equal(t, torrentName, p.Stats.Name)
+
+	// Hook result is loaded from the resume database.
+	require.NoError(t, s.Close())
+	s, err = NewSession(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+	tor = s.GetTorrent(tor.ID())
+	stats = tor.Stats()
+	assert.Equal(t, "added", stats.LastHook.Event)
+	assert.Equal(t, 3, stats.LastHook.ExitCode)
+}
+
+func TestHookTimeoutKillsChildren(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hook uses sh")
+	}
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+	cfg.HookTimeout = 100 * time.Millisecond
+	// Background process keeps the output pipes open.
+	cfg.OnAddCmd = []string{"sh", "-c", "sleep 60 & sleep 60"}
+	s, err := NewSession(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+
+	var stats Stats
+	assert.Eventually(t, func() bool {
+		stats = tor.Stats()
+		return stats.LastHook.Event != ""
+	}, hookWaitDelay, 10*time.Millisecond)
+	assert.EqualError(t, stats.LastHook.Error, "timeout after 100ms")
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{limit: 4}
+	n, err := b.Write([]byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = b.Write([]byte("def"))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "abcd", b.String())
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package torrent
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setHookProcessGroup runs the hook command in a new process group
+// so that the processes started by the hook are killed with it on timeout.
+func setHookProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
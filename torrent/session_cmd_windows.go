@@ -0,0 +1,7 @@
+//go:build windows
+
+package torrent
+
+import "os/exec"
+
+func setHookProcessGroup(cmd *exec.Cmd) {}
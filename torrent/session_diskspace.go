@@ -0,0 +1,48 @@
+package torrent
+
+import (
+	"time"
+
+	"github.com/cenkalti/rain/internal/diskspace"
+)
+
+// isDiskSpaceLow returns true if free space on the disk that contains dir is less than Config.MinFreeDiskSpace.
+func (s *Session) isDiskSpaceLow(dir string) bool {
+	if s.config.MinFreeDiskSpace <= 0 || dir == "" {
+		return false
+	}
+	free, err := diskspace.Free(dir)
+	if err != nil {
+		s.log.Debugf("cannot get free disk space of %q: %s", dir, err)
+		return false
+	}
+	return free < s.config.MinFreeDiskSpace
+}
+
+// checkDiskSpaceLoop periodically checks free disk space of torrents and pauses/resumes downloads.
+func (s *Session) checkDiskSpaceLoop() {
+	ticker := time.NewTicker(s.config.DiskSpaceCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.checkDiskSpace()
+		case <-s.closeC:
+			return
+		}
+	}
+}
+
+func (s *Session) checkDiskSpace() {
+	// Torrents usually share the same disk. Check each directory only once.
+	lowByDir := make(map[string]bool)
+	for _, t := range s.ListTorrents() {
+		dir := t.torrent.Dir()
+		low, ok := lowByDir[dir]
+		if !ok {
+			low = s.isDiskSpaceLow(dir)
+			lowByDir[dir] = low
+		}
+		t.torrent.SetLowDiskSpace(low)
+	}
+}
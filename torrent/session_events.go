@@ -0,0 +1,144 @@
+package torrent
+
+import (
+	"time"
+)
+
+// eventBufferSize is the capacity of the channels returned from Session.Subscribe.
+const eventBufferSize = 1000
+
+// EventType is the type of an Event.
+type EventType int
+
+const (
+	// EventTorrentAdded is emitted when a torrent is added to the Session.
+	EventTorrentAdded EventType = iota
+	// EventTorrentRemoved is emitted when a torrent is removed from the Session.
+	EventTorrentRemoved
+	// EventStatusChanged is emitted when the status of a torrent changes.
+	EventStatusChanged
+	// EventMetadataReceived is emitted when the metadata of a torrent added with magnet link is downloaded.
+	EventMetadataReceived
+	// EventCompleted is emitted when all pieces of a torrent are downloaded.
+	EventCompleted
+	// EventError is emitted when a torrent is stopped with an error.
+	EventError
+	// EventTrackerError is emitted when an announce to a tracker fails.
+	EventTrackerError
+	// EventPeersChanged is emitted when the number of connected peers of a torrent changes.
+	EventPeersChanged
+	// EventRatioReached is emitted once when the upload ratio of a torrent reaches Config.EventRatio.
+	EventRatioReached
+)
+
+func (e EventType) String() string {
+	m := map[EventType]string{
+		EventTorrentAdded:     "added",
+		EventTorrentRemoved:   "removed",
+		EventStatusChanged:    "status",
+		EventMetadataReceived: "metadata",
+		EventCompleted:        "completed",
+		EventError:            "error",
+		EventTrackerError:     "tracker-error",
+		EventPeersChanged:     "peers",
+		EventRatioReached:     "ratio",
+	}
+	return m[e]
+}
+
+// Event is a change in the state of a torrent in the Session.
+type Event struct {
+	Type      EventType
+	TorrentID string
+	Time      time.Time
+	// New status of the torrent. Set for EventStatusChanged.
+	Status Status
+	// Set for EventError and EventTrackerError.
+	Error error
+	// URL of the tracker. Set for EventTrackerError.
+	Tracker string
+	// Number of connected peers. Set for EventPeersChanged.
+	Peers int
+}
+
+// Subscribe returns a channel that receives the events of all torrents in the Session.
+// Events are dropped if the subscriber cannot keep up and the channel buffer is full.
+// Call the returned function to stop receiving events. The channel is closed after unsubscribing or closing the Session.
+func (s *Session) Subscribe() (events <-chan Event, unsubscribe func()) {
+	ch := make(chan Event, eventBufferSize)
+	s.mEvents.Lock()
+	defer s.mEvents.Unlock()
+	if s.eventSubscribers == nil {
+		// Session is closed.
+		close(ch)
+		return ch, func() {}
+	}
+	s.eventSubscribers[ch] = struct{}{}
+	return ch, func() {
+		s.mEvents.Lock()
+		defer s.mEvents.Unlock()
+		if _, ok := s.eventSubscribers[ch]; ok {
+			delete(s.eventSubscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+func (s *Session) publishEvent(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	s.mEvents.Lock()
+	defer s.mEvents.Unlock()
+	for ch := range s.eventSubscribers {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+func (s *Session) closeEventSubscribers() {
+	s.mEvents.Lock()
+	defer s.mEvents.Unlock()
+	for ch := range s.eventSubscribers {
+		close(ch)
+	}
+	s.eventSubscribers = nil
+}
+
+// publishEvent must be called from the torrent event loop because stats of the torrent are sent to webhooks along with the event.
+func (t *torrent) publishEvent(e Event) {
+	e.TorrentID = t.id
+	e.Time = time.Now()
+	t.session.publishEvent(e)
+	if t.session.webhooks.wants(e.Type) {
+		t.session.webhooks.notify(e, t.stats())
+	}
+}
+
+// ratioReached returns true if the torrent has uploaded Config.EventRatio times its size.
+func (t *torrent) ratioReached() bool {
+	ratio := t.session.config.EventRatio
+	if ratio <= 0 || t.info == nil || t.info.Length == 0 {
+		return false
+	}
+	return float64(t.bytesUploaded.Count()) >= ratio*float64(t.info.Length)
+}
+
+// publishStateChanges emits events for the changes in torrent status, number of peers and upload ratio since the last call.
+// It is called from the torrent event loop after each processed message.
+func (t *torrent) publishStateChanges() {
+	if status := t.status(); status != t.lastEventStatus {
+		t.lastEventStatus = status
+		t.publishEvent(Event{Type: EventStatusChanged, Status: status})
+	}
+	if peers := len(t.peers); peers != t.lastEventPeers {
+		t.lastEventPeers = peers
+		t.publishEvent(Event{Type: EventPeersChanged, Peers: peers})
+	}
+	if !t.lastEventRatio && t.ratioReached() {
+		t.lastEventRatio = true
+		t.publishEvent(Event{Type: EventRatioReached})
+	}
+}
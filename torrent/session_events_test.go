@@ -0,0 +1,89 @@
+package torrent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/rainrpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	events, unsubscribe := s.Subscribe()
+	defer unsubscribe()
+
+	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	types := make(map[EventType]bool)
+	statuses := make(map[Status]bool)
+	timeoutC := time.After(timeout)
+	for !types[EventCompleted] {
+		select {
+		case e := <-events:
+			assert.Equal(t, tor.ID(), e.TorrentID)
+			types[e.Type] = true
+			if e.Type == EventStatusChanged {
+				statuses[e.Status] = true
+			}
+		case <-timeoutC:
+			t.Fatal("torrent did not complete")
+		}
+	}
+	assert.True(t, types[EventTorrentAdded])
+	assert.True(t, types[EventMetadataReceived])
+	assert.True(t, types[EventPeersChanged])
+	assert.True(t, statuses[DownloadingMetadata])
+	assert.True(t, statuses[Downloading])
+
+	err = s.RemoveTorrent(tor.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for e := range events {
+		if e.Type == EventTorrentRemoved {
+			break
+		}
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	h := &rpcHandler{session: s}
+	srv := httptest.NewServer(http.HandlerFunc(h.handleEvents))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	events, err := rainrpc.NewClient(srv.URL).Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, ok := <-events
+	if !ok {
+		t.Fatal("event stream closed")
+	}
+	assert.Equal(t, "added", e.Type)
+	assert.Equal(t, tor.ID(), e.TorrentID)
+}
@@ -1,6 +1,8 @@
 package torrent
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
 	"time"
 
@@ -8,7 +10,6 @@ import (
 	"github.com/cenkalti/rain/internal/metainfo"
 	"github.com/cenkalti/rain/internal/resumer"
 	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
-	"github.com/cenkalti/rain/internal/storage/filestorage"
 	"github.com/cenkalti/rain/internal/webseedsource"
 	"go.etcd.io/bbolt"
 )
@@ -76,7 +77,8 @@ func (s *Session) loadExistingTorrent(id string) (tt *Torrent, hasStarted bool,
 		}
 		info = info2
 		private = info.Private
-		if len(spec.Bitfield) > 0 {
+		// Files of in-memory torrents are lost when the Session is closed.
+		if len(spec.Bitfield) > 0 && !spec.InMemory {
 			bf3, err3 := bitfield.NewBytes(spec.Bitfield, info.NumPieces)
 			if err3 != nil {
 				return nil, spec.Started, err3
@@ -84,7 +86,8 @@ func (s *Session) loadExistingTorrent(id string) (tt *Torrent, hasStarted bool,
 			bf = bf3
 		}
 	}
-	sto, err := filestorage.New(s.getDataDir(id), s.config.FilePermissions)
+	// Torrents saved before the layout is stored use "files" layout.
+	sto, err := s.newStorage(id, spec.InMemory, spec.StorageLayout)
 	if err != nil {
 		return
 	}
@@ -110,12 +113,25 @@ func (s *Session) loadExistingTorrent(id string) (tt *Torrent, hasStarted bool,
 		spec.StopAfterDownload,
 		spec.StopAfterMetadata,
 		spec.CompleteCmdRun,
+		spec.ChokingAlgorithm,
 	)
 	if err != nil {
 		return
 	}
 	t.rawTrackers = spec.Trackers
 	t.rawWebseedSources = spec.URLList
+	t.inMemory = spec.InMemory
+	t.storageLayout = spec.StorageLayout
+	t.cachedPeers = spec.CachedPeers
+	t.superSeeding = spec.SuperSeeding
+	t.lastHook = hookResult{
+		event:    spec.LastHook.Event,
+		exitCode: spec.LastHook.ExitCode,
+		time:     spec.LastHook.Time,
+	}
+	if spec.LastHook.Error != "" {
+		t.lastHook.err = errors.New(spec.LastHook.Error)
+	}
 	go s.checkTorrent(t)
 	delete(s.availablePorts, spec.Port)
 
@@ -153,7 +169,19 @@ func (s *Session) CompactDatabase(output string) error {
 	defer db.Close()
 	err = db.Update(func(tx *bbolt.Tx) error {
 		_, err2 := tx.CreateBucketIfNotExists(torrentsBucket)
-		return err2
+		if err2 != nil {
+			return err2
+		}
+		b, err2 := tx.CreateBucketIfNotExists(bansBucket)
+		if err2 != nil {
+			return err2
+		}
+		return s.db.View(func(tx2 *bbolt.Tx) error {
+			return tx2.Bucket(bansBucket).ForEach(func(k, v []byte) error {
+				// Values must be valid until the write transaction is committed.
+				return b.Put(bytes.Clone(k), bytes.Clone(v))
+			})
+		})
 	})
 	if err != nil {
 		return err
@@ -185,6 +213,12 @@ func (s *Session) CompactDatabase(output string) error {
 			StopAfterDownload: t.torrent.stopAfterDownload,
 			StopAfterMetadata: t.torrent.stopAfterMetadata,
 			CompleteCmdRun:    t.torrent.completeCmdRun,
+			InMemory:          t.torrent.inMemory,
+			LastHook:          t.torrent.lastHook.spec(),
+			CachedPeers:       t.torrent.CachedPeers(),
+			ChokingAlgorithm:  t.torrent.chokingAlgorithm,
+			SuperSeeding:      t.torrent.superSeeding,
+			StorageLayout:     t.torrent.storageLayout,
 		}
 		err = res.Write(t.torrent.id, spec)
 		if err != nil {
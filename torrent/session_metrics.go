@@ -32,6 +32,9 @@ type sessionMetrics struct {
 	SpeedUpload           metrics.Meter
 	SpeedRead             metrics.Meter
 	SpeedWrite            metrics.Meter
+	SuggestsSent          metrics.Meter
+	SuggestsReceived      metrics.Meter
+	SuggestedRequests     metrics.Meter
 }
 
 func (s *Session) initMetrics() {
@@ -83,6 +86,10 @@ func (s *Session) initMetrics() {
 		SpeedUpload:   metrics.NewRegisteredMeter("speed_upload", r),
 		SpeedRead:     s.pieceCache.NumLoadedBytes,
 		SpeedWrite:    metrics.NewRegisteredMeter("speed_write", r),
+
+		SuggestsSent:      metrics.NewRegisteredMeter("suggests_sent", r),
+		SuggestsReceived:  metrics.NewRegisteredMeter("suggests_received", r),
+		SuggestedRequests: metrics.NewRegisteredMeter("suggested_requests", r),
 	}
 	_ = r.Register("speed_read", s.metrics.SpeedRead)
 	_ = r.Register("reads_per_seconds", s.metrics.ReadsPerSecond)
@@ -93,4 +100,7 @@ func (m *sessionMetrics) Close() {
 	m.SpeedDownload.Stop()
 	m.SpeedUpload.Stop()
 	m.SpeedWrite.Stop()
+	m.SuggestsSent.Stop()
+	m.SuggestsReceived.Stop()
+	m.SuggestedRequests.Stop()
 }
@@ -0,0 +1,173 @@
+package torrent
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type rpcRole int
+
+const (
+	rpcRoleNone rpcRole = iota
+	rpcRoleReadOnly
+	rpcRoleAdmin
+)
+
+// maxReadOnlyRequestSize is the limit for the body of requests sent by read-only clients.
+// Read-only methods do not take large arguments.
+const maxReadOnlyRequestSize = 1 << 20
+
+// rpcReadOnlyMethods can be called by clients with "readonly" role.
+var rpcReadOnlyMethods = map[string]struct{}{
+	"Session.Version":                      {},
+	"Session.ListTorrents":                 {},
+	"Session.ListTorrentsWithStats":        {},
+	"Session.GetMagnet":                    {},
+	"Session.GetTorrent":                   {},
+	"Session.GetSessionStats":              {},
+	"Session.GetTorrentStats":              {},
+	"Session.GetTorrentsStats":             {},
+	"Session.GetTorrentTrackers":           {},
+	"Session.GetTorrentPeers":              {},
+	"Session.GetTorrentDisconnectedPeers":  {},
+	"Session.GetTorrentConnectionAttempts": {},
+	"Session.GetTorrentWebseeds":           {},
+	"Session.GetTorrentFiles":              {},
+	"Session.GetTorrentFileStats":          {},
+	"Session.ListBans":                     {},
+}
+
+func validateRPCCredentials(creds []RPCCredential) error {
+	for _, c := range creds {
+		if c.Token == "" && c.Username == "" {
+			return errors.New("rpc credential must have a token or username")
+		}
+		if _, err := parseRPCRole(c.Role); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseRPCRole(s string) (rpcRole, error) {
+	switch s {
+	case "admin":
+		return rpcRoleAdmin, nil
+	case "readonly":
+		return rpcRoleReadOnly, nil
+	default:
+		return rpcRoleNone, errors.New("invalid rpc role: " + s)
+	}
+}
+
+// rpcAuth authenticates the requests to the RPC server.
+type rpcAuth struct {
+	credentials []RPCCredential
+}
+
+// role returns the role of the client that sent the request.
+func (a *rpcAuth) role(r *http.Request) rpcRole {
+	if len(a.credentials) == 0 {
+		return rpcRoleAdmin
+	}
+	var match func(c RPCCredential) bool
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		match = func(c RPCCredential) bool {
+			return c.Token != "" && secureEqual(c.Token, token)
+		}
+	} else if username, password, ok := r.BasicAuth(); ok {
+		match = func(c RPCCredential) bool {
+			return c.Username != "" && secureEqual(c.Username, username) && secureEqual(c.Password, password)
+		}
+	} else {
+		return rpcRoleNone
+	}
+	for _, c := range a.credentials {
+		if match(c) {
+			role, _ := parseRPCRole(c.Role)
+			return role
+		}
+	}
+	return rpcRoleNone
+}
+
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// require returns a handler that serves the request with h only if the client has the role.
+func (a *rpcAuth) require(role rpcRole, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.check(w, r, role) == rpcRoleNone {
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// requireRPC returns a handler for JSON-RPC requests that checks the role of the client for each called method.
+func (a *rpcAuth) requireRPC(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		role := a.check(w, r, rpcRoleReadOnly)
+		if role == rpcRoleNone {
+			return
+		}
+		if role == rpcRoleReadOnly {
+			body, err := io.ReadAll(io.LimitReader(r.Body, maxReadOnlyRequestSize))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			for _, method := range rpcMethods(body) {
+				if _, ok := rpcReadOnlyMethods[method]; !ok {
+					http.Error(w, "method not allowed for read-only client: "+method, http.StatusForbidden)
+					return
+				}
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// check returns the role of the client if it has the required role.
+// Otherwise, it writes an error response and returns rpcRoleNone.
+func (a *rpcAuth) check(w http.ResponseWriter, r *http.Request, required rpcRole) rpcRole {
+	role := a.role(r)
+	if role == rpcRoleNone {
+		w.Header().Set("WWW-Authenticate", `Basic realm="rain"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return rpcRoleNone
+	}
+	if role < required {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return rpcRoleNone
+	}
+	return role
+}
+
+// rpcMethods returns the names of the methods called in a JSON-RPC request or batch.
+// An empty name is returned for the requests that cannot be parsed.
+func rpcMethods(body []byte) []string {
+	type request struct {
+		Method string `json:"method"`
+	}
+	var req request
+	if err := json.Unmarshal(body, &req); err == nil {
+		return []string{req.Method}
+	}
+	var batch []request
+	if err := json.Unmarshal(body, &batch); err != nil || len(batch) == 0 {
+		return []string{""}
+	}
+	methods := make([]string, 0, len(batch))
+	for _, req := range batch {
+		methods = append(methods, req.Method)
+	}
+	return methods
+}
@@ -0,0 +1,51 @@
+package torrent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPCAuth(t *testing.T) {
+	auth := &rpcAuth{credentials: []RPCCredential{
+		{Token: "admin-token", Role: "admin"},
+		{Username: "viewer", Password: "secret", Role: "readonly"},
+	}}
+	h := auth.requireRPC(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	call := func(body string, setAuth func(r *http.Request)) int {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		if setAuth != nil {
+			setAuth(r)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	admin := func(r *http.Request) { r.Header.Set("Authorization", "Bearer admin-token") }
+	viewer := func(r *http.Request) { r.SetBasicAuth("viewer", "secret") }
+	wrong := func(r *http.Request) { r.SetBasicAuth("viewer", "wrong") }
+
+	list := `{"jsonrpc":"2.0","id":1,"method":"Session.ListTorrents","params":{}}`
+	remove := `{"jsonrpc":"2.0","id":1,"method":"Session.RemoveTorrent","params":{"ID":"foo"}}`
+	batch := "[" + list + "," + remove + "]"
+
+	assert.Equal(t, http.StatusUnauthorized, call(list, nil))
+	assert.Equal(t, http.StatusUnauthorized, call(list, wrong))
+	assert.Equal(t, http.StatusOK, call(list, viewer))
+	assert.Equal(t, http.StatusForbidden, call(remove, viewer))
+	assert.Equal(t, http.StatusForbidden, call(batch, viewer))
+	assert.Equal(t, http.StatusOK, call(remove, admin))
+	assert.Equal(t, http.StatusOK, call(batch, admin))
+}
+
+func TestRPCAuthDisabled(t *testing.T) {
+	auth := &rpcAuth{}
+	h := auth.require(rpcRoleAdmin, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/move-torrent", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
@@ -10,7 +10,9 @@ import (
 	"io/fs"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -46,6 +48,8 @@ func (h *rpcHandler) AddTorrent(args *rpctypes.AddTorrentRequest, reply *rpctype
 		ID:                args.AddTorrentOptions.ID,
 		StopAfterDownload: args.StopAfterDownload,
 		StopAfterMetadata: args.StopAfterMetadata,
+		ChokingAlgorithm:  args.ChokingAlgorithm,
+		SuperSeeding:      args.SuperSeeding,
 	}
 	t, err := h.session.AddTorrent(r, opt)
 	var e *InputError
@@ -65,6 +69,8 @@ func (h *rpcHandler) AddURI(args *rpctypes.AddURIRequest, reply *rpctypes.AddURI
 		ID:                args.AddTorrentOptions.ID,
 		StopAfterDownload: args.StopAfterDownload,
 		StopAfterMetadata: args.StopAfterMetadata,
+		ChokingAlgorithm:  args.ChokingAlgorithm,
+		SuperSeeding:      args.SuperSeeding,
 	}
 	t, err := h.session.AddURI(args.URI, opt)
 	var e *InputError
@@ -155,6 +161,10 @@ func (h *rpcHandler) GetSessionStats(args *rpctypes.GetSessionStatsRequest, repl
 		BytesUploaded:   s.BytesUploaded,
 		BytesRead:       s.BytesRead,
 		BytesWritten:    s.BytesWritten,
+
+		SuggestsSent:      s.SuggestsSent,
+		SuggestsReceived:  s.SuggestsReceived,
+		SuggestedRequests: s.SuggestedRequests,
 	}
 	return nil
 }
@@ -164,8 +174,12 @@ func (h *rpcHandler) GetTorrentStats(args *rpctypes.GetTorrentStatsRequest, repl
 	if t == nil {
 		return errTorrentNotFound
 	}
-	s := t.Stats()
-	reply.Stats = rpctypes.Stats{
+	reply.Stats = newStats(t.Stats())
+	return nil
+}
+
+func newStats(s Stats) rpctypes.Stats {
+	ret := rpctypes.Stats{
 		InfoHash: s.InfoHash.String(),
 		Port:     s.Port,
 		Status:   s.Status.String(),
@@ -222,11 +236,13 @@ func (h *rpcHandler) GetTorrentStats(args *rpctypes.GetTorrentStatsRequest, repl
 			Tracker int
 			DHT     int
 			PEX     int
+			Cache   int
 		}{
 			Total:   s.Addresses.Total,
 			Tracker: s.Addresses.Tracker,
 			DHT:     s.Addresses.DHT,
 			PEX:     s.Addresses.PEX,
+			Cache:   s.Addresses.Cache,
 		},
 		Downloads: struct {
 			Total   int
@@ -262,14 +278,22 @@ func (h *rpcHandler) GetTorrentStats(args *rpctypes.GetTorrentStatsRequest, repl
 		},
 	}
 	if s.Error != nil {
-		reply.Stats.Error = s.Error.Error()
+		ret.Error = s.Error.Error()
 	}
 	if s.ETA != nil {
-		reply.Stats.ETA = int(*s.ETA / time.Second)
+		ret.ETA = int(*s.ETA / time.Second)
 	} else {
-		reply.Stats.ETA = -1
+		ret.ETA = -1
 	}
-	return nil
+	ret.ConnectionAttempts = s.ConnectionAttempts
+	ret.SuperSeeding = s.SuperSeeding
+	ret.LastHook.Event = s.LastHook.Event
+	ret.LastHook.ExitCode = s.LastHook.ExitCode
+	ret.LastHook.Time = rpctypes.Time{Time: s.LastHook.Time}
+	if s.LastHook.Error != nil {
+		ret.LastHook.Error = s.LastHook.Error.Error()
+	}
+	return ret
 }
 
 func (h *rpcHandler) GetTorrentTrackers(args *rpctypes.GetTorrentTrackersRequest, reply *rpctypes.GetTorrentTrackersResponse) error {
@@ -307,29 +331,27 @@ func (h *rpcHandler) GetTorrentPeers(args *rpctypes.GetTorrentPeersRequest, repl
 	if t == nil {
 		return errTorrentNotFound
 	}
-	peers := t.Peers()
-	reply.Peers = make([]rpctypes.Peer, len(peers))
+	reply.Peers = newRPCPeers(t.Peers())
+	return nil
+}
+
+func (h *rpcHandler) GetTorrentDisconnectedPeers(args *rpctypes.GetTorrentDisconnectedPeersRequest, reply *rpctypes.GetTorrentDisconnectedPeersResponse) error {
+	t := h.session.GetTorrent(args.ID)
+	if t == nil {
+		return errTorrentNotFound
+	}
+	reply.Peers = newRPCPeers(t.DisconnectedPeers())
+	return nil
+}
+
+func newRPCPeers(peers []Peer) []rpctypes.Peer {
+	ret := make([]rpctypes.Peer, len(peers))
 	for i, p := range peers {
-		var source string
-		switch p.Source {
-		case SourceTracker:
-			source = "TRACKER"
-		case SourceDHT:
-			source = "DHT"
-		case SourcePEX:
-			source = "PEX"
-		case SourceIncoming:
-			source = "INCOMING"
-		case SourceManual:
-			source = "MANUAL"
-		default:
-			panic("unhandled peer source")
-		}
-		reply.Peers[i] = rpctypes.Peer{
+		ret[i] = rpctypes.Peer{
 			ID:                 hex.EncodeToString(p.ID[:]),
 			Client:             p.Client,
 			Addr:               p.Addr.String(),
-			Source:             source,
+			Source:             peerSourceToString(p.Source),
 			ConnectedAt:        rpctypes.Time{Time: p.ConnectedAt},
 			Downloading:        p.Downloading,
 			ClientInterested:   p.ClientInterested,
@@ -342,6 +364,58 @@ func (h *rpcHandler) GetTorrentPeers(args *rpctypes.GetTorrentPeersRequest, repl
 			EncryptedStream:    p.EncryptedStream,
 			DownloadSpeed:      p.DownloadSpeed,
 			UploadSpeed:        p.UploadSpeed,
+			BytesDownloaded:    p.BytesDownloaded,
+			BytesUploaded:      p.BytesUploaded,
+			RequestsIn:         p.RequestsIn,
+			RequestsOut:        p.RequestsOut,
+			Completion:         p.Completion,
+			LastMessageAt:      rpctypes.Time{Time: p.LastMessageAt},
+			RequestQueue:       p.RequestQueue,
+			Extensions:         p.Extensions,
+			DisconnectedAt:     rpctypes.Time{Time: p.DisconnectedAt},
+			DisconnectReason:   p.DisconnectReason,
+		}
+	}
+	return ret
+}
+
+func peerSourceToString(s PeerSource) string {
+	switch s {
+	case SourceTracker:
+		return "TRACKER"
+	case SourceDHT:
+		return "DHT"
+	case SourcePEX:
+		return "PEX"
+	case SourceIncoming:
+		return "INCOMING"
+	case SourceManual:
+		return "MANUAL"
+	case SourceCache:
+		return "CACHE"
+	case SourceHolepunch:
+		return "HOLEPUNCH"
+	default:
+		panic("unhandled peer source")
+	}
+}
+
+func (h *rpcHandler) GetTorrentConnectionAttempts(args *rpctypes.GetTorrentConnectionAttemptsRequest, reply *rpctypes.GetTorrentConnectionAttemptsResponse) error {
+	t := h.session.GetTorrent(args.ID)
+	if t == nil {
+		return errTorrentNotFound
+	}
+	attempts := t.ConnectionAttempts()
+	reply.ConnectionAttempts = make([]rpctypes.ConnectionAttempt, len(attempts))
+	for i, a := range attempts {
+		reply.ConnectionAttempts[i] = rpctypes.ConnectionAttempt{
+			Addr:   a.Addr.String(),
+			Source: peerSourceToString(a.Source),
+			Time:   rpctypes.Time{Time: a.Time},
+			Result: a.Result.String(),
+		}
+		if a.Error != nil {
+			reply.ConnectionAttempts[i].Error = a.Error.Error()
 		}
 	}
 	return nil
@@ -456,6 +530,43 @@ func (h *rpcHandler) AddPeer(args *rpctypes.AddPeerRequest, reply *rpctypes.AddP
 	return t.AddPeer(args.Addr)
 }
 
+func (h *rpcHandler) ListBans(args *rpctypes.ListBansRequest, reply *rpctypes.ListBansResponse) error {
+	bans := h.session.ListBans()
+	reply.Bans = make([]rpctypes.Ban, len(bans))
+	for i, b := range bans {
+		reply.Bans[i] = rpctypes.Ban{
+			IP:           b.IP,
+			PeerIDPrefix: b.PeerIDPrefix,
+			Reason:       b.Reason,
+			CreatedAt:    rpctypes.Time{Time: b.CreatedAt},
+			Automatic:    b.Automatic,
+		}
+	}
+	return nil
+}
+
+func (h *rpcHandler) AddBan(args *rpctypes.AddBanRequest, reply *rpctypes.AddBanResponse) error {
+	err := h.session.AddBan(Ban{
+		IP:           args.Ban.IP,
+		PeerIDPrefix: args.Ban.PeerIDPrefix,
+		Reason:       args.Ban.Reason,
+	})
+	var e *InputError
+	if errors.As(err, &e) {
+		return jsonrpc2.NewError(2, e.Error())
+	}
+	return err
+}
+
+func (h *rpcHandler) RemoveBan(args *rpctypes.RemoveBanRequest, reply *rpctypes.RemoveBanResponse) error {
+	err := h.session.RemoveBan(Ban{IP: args.IP, PeerIDPrefix: args.PeerIDPrefix})
+	var e *InputError
+	if errors.As(err, &e) {
+		return jsonrpc2.NewError(2, e.Error())
+	}
+	return err
+}
+
 func (h *rpcHandler) AddTracker(args *rpctypes.AddTrackerRequest, reply *rpctypes.AddTrackerResponse) error {
 	t := h.session.GetTorrent(args.ID)
 	if t == nil {
@@ -473,6 +584,10 @@ func (h *rpcHandler) MoveTorrent(args *rpctypes.MoveTorrentRequest, reply *rpcty
 }
 
 func (h *rpcHandler) handleMoveTorrent(w http.ResponseWriter, r *http.Request) {
+	if h.session.config.StorageProvider != nil || h.session.config.StorageLayout == "pieces" {
+		http.Error(w, "cannot move torrent into session with custom storage", http.StatusNotImplemented)
+		return
+	}
 	port, err := h.session.getPort()
 	if err != nil {
 		h.session.log.Error(err)
@@ -579,6 +694,7 @@ func (h *rpcHandler) handleMoveTorrent(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	h.session.notifyAdded(t)
 	if started {
 		err = t.Start()
 		if err != nil {
@@ -621,3 +737,293 @@ func readData(r io.Reader, dir string, perm fs.FileMode) error {
 	}
 	return nil
 }
+
+// eventStreamPingInterval is the interval for sending comments to keep the idle event stream connections alive.
+const eventStreamPingInterval = 15 * time.Second
+
+// handleEvents streams the events of the Session as Server-Sent Events.
+func (h *rpcHandler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	events, unsubscribe := h.session.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ping := time.NewTicker(eventStreamPingInterval)
+	defer ping.Stop()
+	enc := json.NewEncoder(w)
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			_, _ = io.WriteString(w, "event: "+e.Type.String()+"\ndata: ")
+			// Encode writes a newline after the JSON object.
+			err := enc.Encode(newEvent(e))
+			if err != nil {
+				return
+			}
+			_, err = io.WriteString(w, "\n")
+			if err != nil {
+				return
+			}
+		case <-ping.C:
+			_, err := io.WriteString(w, ": ping\n\n")
+			if err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func newEvent(e Event) rpctypes.Event {
+	ev := rpctypes.Event{
+		Type:      e.Type.String(),
+		TorrentID: e.TorrentID,
+		Time:      rpctypes.Time{Time: e.Time},
+		Tracker:   e.Tracker,
+		Peers:     e.Peers,
+	}
+	if e.Type == EventStatusChanged {
+		ev.Status = e.Status.String()
+	}
+	if e.Error != nil {
+		ev.Error = e.Error.Error()
+	}
+	return ev
+}
+
+var errEmptyFilter = errors.New("filter must not be empty")
+
+// selectTorrents returns the torrents matching the filter sorted by ID.
+// IDs in the filter that do not exist in the Session are returned in notFound.
+func (h *rpcHandler) selectTorrents(f rpctypes.TorrentFilter) (torrents []*Torrent, notFound []string, err error) {
+	if len(f.IDs) == 0 && f.Status == "" && f.Name == "" && f.AddedSince.IsZero() {
+		return nil, nil, errEmptyFilter
+	}
+	if _, err = path.Match(f.Name, ""); err != nil {
+		return nil, nil, err
+	}
+	status := normalizeStatus(f.Status)
+	if status != "" && !isValidStatus(status) {
+		return nil, nil, errors.New("invalid status: " + f.Status)
+	}
+	var candidates []*Torrent
+	if len(f.IDs) > 0 {
+		for _, id := range f.IDs {
+			t := h.session.GetTorrent(id)
+			if t == nil {
+				notFound = append(notFound, id)
+				continue
+			}
+			candidates = append(candidates, t)
+		}
+	} else {
+		candidates = h.session.ListTorrents()
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID() < candidates[j].ID() })
+	for _, t := range candidates {
+		if f.Name != "" {
+			if ok, _ := path.Match(f.Name, t.Name()); !ok {
+				continue
+			}
+		}
+		if !f.AddedSince.IsZero() && t.AddedAt().Before(f.AddedSince.Time) {
+			continue
+		}
+		if status != "" && normalizeStatus(t.Stats().Status.String()) != status {
+			continue
+		}
+		torrents = append(torrents, t)
+	}
+	return torrents, notFound, nil
+}
+
+// normalizeStatus converts status names like "Downloading Metadata" and "downloading-metadata" to the same form.
+func normalizeStatus(s string) string {
+	s = strings.ToLower(s)
+	return strings.NewReplacer("-", " ", "_", " ").Replace(s)
+}
+
+func isValidStatus(s string) bool {
+	for st := Stopped; st <= LowDiskSpace; st++ {
+		if normalizeStatus(st.String()) == s {
+			return true
+		}
+	}
+	return false
+}
+
+// batch calls fn for each torrent matching the filter and collects the results.
+func (h *rpcHandler) batch(f rpctypes.TorrentFilter, reply *rpctypes.BatchResponse, fn func(t *Torrent) error) error {
+	torrents, notFound, err := h.selectTorrents(f)
+	if err != nil {
+		return err
+	}
+	reply.Results = make([]rpctypes.BatchResult, 0, len(torrents)+len(notFound))
+	for _, t := range torrents {
+		res := rpctypes.BatchResult{ID: t.ID()}
+		if err := fn(t); err != nil {
+			res.Error = err.Error()
+		}
+		reply.Results = append(reply.Results, res)
+	}
+	for _, id := range notFound {
+		reply.Results = append(reply.Results, rpctypes.BatchResult{ID: id, Error: errTorrentNotFound.Error()})
+	}
+	return nil
+}
+
+func (h *rpcHandler) RemoveTorrents(args *rpctypes.BatchRequest, reply *rpctypes.BatchResponse) error {
+	return h.batch(args.Filter, reply, func(t *Torrent) error { return h.session.RemoveTorrent(t.ID()) })
+}
+
+func (h *rpcHandler) StartTorrents(args *rpctypes.BatchRequest, reply *rpctypes.BatchResponse) error {
+	return h.batch(args.Filter, reply, func(t *Torrent) error { return t.Start() })
+}
+
+func (h *rpcHandler) StopTorrents(args *rpctypes.BatchRequest, reply *rpctypes.BatchResponse) error {
+	return h.batch(args.Filter, reply, func(t *Torrent) error { return t.Stop() })
+}
+
+func (h *rpcHandler) VerifyTorrents(args *rpctypes.BatchRequest, reply *rpctypes.BatchResponse) error {
+	return h.batch(args.Filter, reply, func(t *Torrent) error { return t.Verify() })
+}
+
+func (h *rpcHandler) AnnounceTorrents(args *rpctypes.BatchRequest, reply *rpctypes.BatchResponse) error {
+	return h.batch(args.Filter, reply, func(t *Torrent) error {
+		t.Announce()
+		return nil
+	})
+}
+
+func (h *rpcHandler) GetTorrentsStats(args *rpctypes.GetTorrentsStatsRequest, reply *rpctypes.GetTorrentsStatsResponse) error {
+	torrents, notFound, err := h.selectTorrents(args.Filter)
+	if err != nil {
+		return err
+	}
+	reply.Results = make([]rpctypes.StatsResult, 0, len(torrents)+len(notFound))
+	for _, t := range torrents {
+		reply.Results = append(reply.Results, rpctypes.StatsResult{ID: t.ID(), Stats: newStats(t.Stats())})
+	}
+	for _, id := range notFound {
+		reply.Results = append(reply.Results, rpctypes.StatsResult{ID: id, Error: errTorrentNotFound.Error()})
+	}
+	return nil
+}
+
+// torrentWithStatsFields contains setters for the fields of rpctypes.TorrentWithStats that can be requested in ListTorrentsWithStats.
+// Stats of the torrents are not fetched if none of the requested fields need them.
+var torrentWithStatsFields = map[string]struct {
+	needStats bool
+	set       func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats)
+}{
+	"Name":     {false, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.Name = t.Name() }},
+	"InfoHash": {false, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.InfoHash = t.InfoHash().String() }},
+	"Port":     {false, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.Port = t.Port() }},
+	"AddedAt": {false, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) {
+		r.AddedAt = &rpctypes.Time{Time: t.AddedAt()}
+	}},
+	"Status":          {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.Status = s.Status.String() }},
+	"Progress":        {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.Progress = progressPercent(s) }},
+	"BytesTotal":      {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.BytesTotal = s.Bytes.Total }},
+	"BytesCompleted":  {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.BytesCompleted = s.Bytes.Completed }},
+	"BytesDownloaded": {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.BytesDownloaded = s.Bytes.Downloaded }},
+	"BytesUploaded":   {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.BytesUploaded = s.Bytes.Uploaded }},
+	"Peers":           {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.Peers = s.Peers.Total }},
+	"SpeedDownload":   {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.SpeedDownload = s.Speed.Download }},
+	"SpeedUpload":     {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) { r.SpeedUpload = s.Speed.Upload }},
+	"SeededFor": {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) {
+		r.SeededFor = uint(s.SeededFor / time.Second)
+	}},
+	"Error": {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) {
+		if s.Error != nil {
+			r.Error = s.Error.Error()
+		}
+	}},
+	"ETA": {true, func(r *rpctypes.TorrentWithStats, t *Torrent, s *Stats) {
+		eta := -1
+		if s.ETA != nil {
+			eta = int(*s.ETA / time.Second)
+		}
+		r.ETA = &eta
+	}},
+}
+
+// progressPercent returns the percentage of the current operation of the torrent.
+func progressPercent(s *Stats) int {
+	if s.Pieces.Total == 0 {
+		return 0
+	}
+	switch s.Status {
+	case Verifying:
+		return int(s.Pieces.Checked * 100 / s.Pieces.Total)
+	case Allocating:
+		return int(s.Bytes.Allocated * 100 / s.Bytes.Total)
+	default:
+		return int(s.Pieces.Have * 100 / s.Pieces.Total)
+	}
+}
+
+func (h *rpcHandler) ListTorrentsWithStats(args *rpctypes.ListTorrentsWithStatsRequest, reply *rpctypes.ListTorrentsWithStatsResponse) error {
+	if args.Offset < 0 || args.Limit < 0 {
+		return errors.New("offset and limit must not be negative")
+	}
+	fields := args.Fields
+	if len(fields) == 0 {
+		fields = make([]string, 0, len(torrentWithStatsFields))
+		for name := range torrentWithStatsFields {
+			fields = append(fields, name)
+		}
+	}
+	var needStats bool
+	for _, name := range fields {
+		f, ok := torrentWithStatsFields[name]
+		if !ok && name != "ID" {
+			return errors.New("invalid field: " + name)
+		}
+		needStats = needStats || f.needStats
+	}
+
+	torrents := h.session.ListTorrents()
+	h.session.statsCache.Retain(torrents)
+	sort.Slice(torrents, func(i, j int) bool {
+		a, b := torrents[i], torrents[j]
+		if a.AddedAt().Equal(b.AddedAt()) {
+			return a.ID() < b.ID()
+		}
+		return a.AddedAt().Before(b.AddedAt())
+	})
+	reply.Total = len(torrents)
+	torrents = torrents[min(args.Offset, len(torrents)):]
+	if args.Limit > 0 && args.Limit < len(torrents) {
+		torrents = torrents[:args.Limit]
+	}
+
+	reply.Torrents = make([]rpctypes.TorrentWithStats, len(torrents))
+	for i, t := range torrents {
+		var stats Stats
+		if needStats {
+			stats = h.session.statsCache.Get(t)
+		}
+		r := &reply.Torrents[i]
+		r.ID = t.ID()
+		for _, name := range fields {
+			if f, ok := torrentWithStatsFields[name]; ok {
+				f.set(r, t, &stats)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package torrent
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/rpctypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchMethods(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	h := &rpcHandler{session: s}
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		f, err := os.Open(torrentFile)
+		require.NoError(t, err)
+		tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+		f.Close()
+		require.NoError(t, err)
+		ids = append(ids, tor.ID())
+	}
+
+	var reply rpctypes.BatchResponse
+	err := h.StopTorrents(&rpctypes.BatchRequest{}, &reply)
+	assert.Equal(t, errEmptyFilter, err)
+
+	err = h.StartTorrents(&rpctypes.BatchRequest{Filter: rpctypes.TorrentFilter{Status: "bogus"}}, &reply)
+	assert.Error(t, err)
+
+	err = h.StopTorrents(&rpctypes.BatchRequest{Filter: rpctypes.TorrentFilter{IDs: []string{ids[0], "missing"}}}, &reply)
+	require.NoError(t, err)
+	assert.Equal(t, []rpctypes.BatchResult{{ID: ids[0]}, {ID: "missing", Error: errTorrentNotFound.Error()}}, reply.Results)
+
+	var statsReply rpctypes.GetTorrentsStatsResponse
+	filter := rpctypes.TorrentFilter{Status: "stopped", Name: "sample_*", AddedSince: rpctypes.Time{Time: time.Now().Add(-time.Hour)}}
+	err = h.GetTorrentsStats(&rpctypes.GetTorrentsStatsRequest{Filter: filter}, &statsReply)
+	require.NoError(t, err)
+	assert.Len(t, statsReply.Results, 3)
+	for _, r := range statsReply.Results {
+		assert.Empty(t, r.Error)
+		assert.Equal(t, "Stopped", r.Stats.Status)
+	}
+
+	filter.AddedSince = rpctypes.Time{Time: time.Now().Add(time.Hour)}
+	err = h.GetTorrentsStats(&rpctypes.GetTorrentsStatsRequest{Filter: filter}, &statsReply)
+	require.NoError(t, err)
+	assert.Empty(t, statsReply.Results)
+
+	err = h.RemoveTorrents(&rpctypes.BatchRequest{Filter: rpctypes.TorrentFilter{Name: "sample_*"}}, &reply)
+	require.NoError(t, err)
+	assert.Len(t, reply.Results, 3)
+	assert.Empty(t, s.ListTorrents())
+}
+
+func TestListTorrentsWithStats(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	h := &rpcHandler{session: s}
+
+	var ids []string
+	for i := 0; i < 3; i++ {
+		f, err := os.Open(torrentFile)
+		require.NoError(t, err)
+		tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+		f.Close()
+		require.NoError(t, err)
+		ids = append(ids, tor.ID())
+	}
+
+	var reply rpctypes.ListTorrentsWithStatsResponse
+	err := h.ListTorrentsWithStats(&rpctypes.ListTorrentsWithStatsRequest{Fields: []string{"Foo"}}, &reply)
+	assert.Error(t, err)
+
+	args := &rpctypes.ListTorrentsWithStatsRequest{Fields: []string{"Name", "Status", "ETA"}, Offset: 1, Limit: 1}
+	err = h.ListTorrentsWithStats(args, &reply)
+	require.NoError(t, err)
+	assert.Equal(t, 3, reply.Total)
+	require.Len(t, reply.Torrents, 1)
+	r := reply.Torrents[0]
+	assert.Equal(t, ids[1], r.ID)
+	assert.Equal(t, torrentName, r.Name)
+	assert.Equal(t, "Stopped", r.Status)
+	require.NotNil(t, r.ETA)
+	assert.Equal(t, -1, *r.ETA)
+	assert.Empty(t, r.InfoHash)
+	assert.Nil(t, r.AddedAt)
+
+	err = h.ListTorrentsWithStats(&rpctypes.ListTorrentsWithStatsRequest{Offset: 5}, &reply)
+	require.NoError(t, err)
+	assert.Equal(t, 3, reply.Total)
+	assert.Empty(t, reply.Torrents)
+
+	require.NoError(t, s.RemoveTorrent(ids[0]))
+	err = h.ListTorrentsWithStats(&rpctypes.ListTorrentsWithStatsRequest{}, &reply)
+	require.NoError(t, err)
+	assert.Len(t, reply.Torrents, 2)
+	assert.NotNil(t, reply.Torrents[0].AddedAt)
+	assert.Len(t, s.statsCache.entries, 2)
+}
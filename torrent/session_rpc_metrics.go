@@ -0,0 +1,122 @@
+package torrent
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// metricsPrefix is prepended to the names of all series in /metrics endpoint.
+const metricsPrefix = "rain_"
+
+// handleMetrics writes session metrics and, if enabled in Config, torrent metrics in Prometheus text format.
+func (h *rpcHandler) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	bw := bufio.NewWriter(w)
+	defer bw.Flush()
+
+	writeSessionMetrics(bw, h.session.metrics.registry)
+	if h.session.config.RPCMetricsPerTorrent {
+		writeTorrentMetrics(bw, h.session.ListTorrents())
+	}
+}
+
+func writeSessionMetrics(w *bufio.Writer, r metrics.Registry) {
+	all := make(map[string]interface{})
+	r.Each(func(name string, i interface{}) { all[name] = i })
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		name2 := metricsPrefix + name
+		switch m := all[name].(type) {
+		case metrics.Gauge:
+			writeMetric(w, name2, "gauge", "", float64(m.Value()))
+		case metrics.Counter:
+			writeMetric(w, name2, "gauge", "", float64(m.Count()))
+		case metrics.Meter:
+			s := m.Snapshot()
+			writeMetric(w, name2+"_total", "counter", "", float64(s.Count()))
+			writeMetric(w, name2+"_rate1m", "gauge", "", s.Rate1())
+		}
+	}
+}
+
+type torrentMetric struct {
+	name  string
+	help  string
+	value func(s *Stats) float64
+}
+
+var torrentMetrics = []torrentMetric{
+	{"torrent_download_speed_bytes", "Download speed in bytes per second.", func(s *Stats) float64 { return float64(s.Speed.Download) }},
+	{"torrent_upload_speed_bytes", "Upload speed in bytes per second.", func(s *Stats) float64 { return float64(s.Speed.Upload) }},
+	{"torrent_peers", "Number of connected peers.", func(s *Stats) float64 { return float64(s.Peers.Total) }},
+	{"torrent_progress", "Ratio of completed bytes to torrent size.", func(s *Stats) float64 {
+		if s.Bytes.Total == 0 {
+			return 0
+		}
+		return float64(s.Bytes.Completed) / float64(s.Bytes.Total)
+	}},
+	{"torrent_ratio", "Ratio of uploaded bytes to torrent size.", func(s *Stats) float64 {
+		if s.Bytes.Total == 0 {
+			return 0
+		}
+		return float64(s.Bytes.Uploaded) / float64(s.Bytes.Total)
+	}},
+}
+
+func writeTorrentMetrics(w *bufio.Writer, torrents []*Torrent) {
+	sort.Slice(torrents, func(i, j int) bool { return torrents[i].ID() < torrents[j].ID() })
+	stats := make([]Stats, len(torrents))
+	for i, t := range torrents {
+		stats[i] = t.Stats()
+	}
+	for _, tm := range torrentMetrics {
+		fmt.Fprintf(w, "# HELP %s%s %s\n# TYPE %s%s gauge\n", metricsPrefix, tm.name, tm.help, metricsPrefix, tm.name)
+		for i, t := range torrents {
+			labels := torrentLabels(t.ID(), stats[i].Name)
+			writeSample(w, metricsPrefix+tm.name, labels, tm.value(&stats[i]))
+		}
+	}
+	name := metricsPrefix + "torrent_tracker_status"
+	fmt.Fprintf(w, "# HELP %s Status of the tracker is given in status label.\n# TYPE %s gauge\n", name, name)
+	for i, t := range torrents {
+		labels := torrentLabels(t.ID(), stats[i].Name)
+		for _, tr := range t.Trackers() {
+			l := labels + `,tracker="` + escapeLabel(tr.URL) + `",status="` + escapeLabel(trackerStatusToString(tr.Status)) + `"`
+			writeSample(w, name, l, 1)
+		}
+	}
+}
+
+func torrentLabels(id, name string) string {
+	return `id="` + escapeLabel(id) + `",name="` + escapeLabel(name) + `"`
+}
+
+func writeMetric(w *bufio.Writer, name, typ, labels string, value float64) {
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+	writeSample(w, name, labels, value)
+}
+
+func writeSample(w *bufio.Writer, name, labels string, value float64) {
+	_, _ = w.WriteString(name)
+	if labels != "" {
+		_, _ = w.WriteString("{" + labels + "}")
+	}
+	_, _ = w.WriteString(" " + strconv.FormatFloat(value, 'g', -1, 64) + "\n")
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
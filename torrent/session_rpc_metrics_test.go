@@ -0,0 +1,41 @@
+package torrent
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	h := &rpcHandler{session: s}
+
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	h.handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE rain_torrents gauge\nrain_torrents 1\n")
+	assert.Contains(t, body, "rain_speed_download_total 0\n")
+	assert.NotContains(t, body, "rain_torrent_peers")
+
+	s.config.RPCMetricsPerTorrent = true
+	rec = httptest.NewRecorder()
+	h.handleMetrics(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body = rec.Body.String()
+	labels := `{id="` + tor.ID() + `",name="` + torrentName + `"}`
+	assert.Contains(t, body, "rain_torrent_peers"+labels+" 0\n")
+	assert.Contains(t, body, "rain_torrent_progress"+labels+" 0\n")
+}
+
+func TestEscapeLabel(t *testing.T) {
+	assert.Equal(t, `a\"b\\c\nd`, escapeLabel("a\"b\\c\nd"))
+}
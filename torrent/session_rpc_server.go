@@ -2,11 +2,17 @@ package torrent
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"expvar"
+	"io/fs"
 	"net"
 	"net/http"
 	"net/rpc"
+	"os"
+	"path/filepath"
 	"strconv"
+	"syscall"
 	"time"
 
 	"github.com/cenkalti/rain/internal/logger"
@@ -14,9 +20,12 @@ import (
 )
 
 type rpcServer struct {
-	rpcServer  *rpc.Server
-	httpServer http.Server
-	log        logger.Logger
+	rpcServer   *rpc.Server
+	httpServer  http.Server
+	tlsCertFile string
+	tlsKeyFile  string
+	unixSocket  string
+	log         logger.Logger
 }
 
 func newRPCServer(ses *Session) *rpcServer {
@@ -24,17 +33,28 @@ func newRPCServer(ses *Session) *rpcServer {
 	srv := rpc.NewServer()
 	_ = srv.RegisterName("Session", h)
 
+	auth := &rpcAuth{credentials: ses.config.RPCCredentials}
 	mux := http.NewServeMux()
-	mux.Handle("/debug/vars", expvar.Handler())
-	mux.HandleFunc("/move-torrent", h.handleMoveTorrent)
-	mux.Handle("/", jsonrpc2.HTTPHandler(srv))
+	mux.Handle("/debug/vars", auth.require(rpcRoleReadOnly, expvar.Handler()))
+	mux.Handle("/metrics", auth.require(rpcRoleReadOnly, http.HandlerFunc(h.handleMetrics)))
+	mux.Handle("/events", auth.require(rpcRoleReadOnly, http.HandlerFunc(h.handleEvents)))
+	mux.Handle("/move-torrent", auth.require(rpcRoleAdmin, http.HandlerFunc(h.handleMoveTorrent)))
+	if ses.config.RPCWebUIEnabled {
+		mux.Handle(webUIPath, auth.require(rpcRoleReadOnly, newWebUIHandler()))
+	}
+	if ses.config.RPCTransmissionEnabled {
+		mux.Handle(transmissionRPCPath, newTransmissionHandler(ses, auth))
+	}
+	mux.Handle("/", auth.requireRPC(jsonrpc2.HTTPHandler(srv)))
 
 	return &rpcServer{
 		rpcServer: srv,
 		httpServer: http.Server{
 			Handler: mux,
 		},
-		log: logger.New("rpc server"),
+		tlsCertFile: ses.config.RPCTLSCertFile,
+		tlsKeyFile:  ses.config.RPCTLSKeyFile,
+		log:         logger.New("rpc server"),
 	}
 }
 
@@ -44,8 +64,83 @@ func (s *rpcServer) Start(host string, port int) error {
 	if err != nil {
 		return err
 	}
+	return s.serve(listener, addr, true)
+}
+
+// StartUnix starts the server on a Unix domain socket at path.
+// Stale socket file left from a previous run is removed before listening.
+// An error is returned if another process is listening on the socket or path is not a socket.
+// The socket is served over plain HTTP. Access is controlled by the permissions of the socket file.
+func (s *rpcServer) StartUnix(path string, perm fs.FileMode) error {
+	err := removeStaleSocket(path)
+	if err != nil {
+		return err
+	}
+	// The socket is created in a directory that only we can access and moved to path after setting its permissions.
+	// Otherwise other users could connect before the permissions are set.
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".rain-rpc-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(dir)
+	tmpPath := filepath.Join(dir, "rpc.sock")
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: tmpPath, Net: "unix"})
+	if err != nil {
+		return err
+	}
+	// Socket file is removed in Stop because it is not at the path that the listener is created at.
+	listener.SetUnlinkOnClose(false)
+	err = os.Chmod(tmpPath, perm)
+	if err == nil {
+		// Link fails if a file is created at path in the meantime, unlike Rename.
+		err = os.Link(tmpPath, path)
+	}
+	_ = os.Remove(tmpPath)
+	if err != nil {
+		listener.Close()
+		return err
+	}
+	s.unixSocket = path
+	return s.serve(listener, path, false)
+}
+
+// removeStaleSocket removes the socket file at path if no process is listening on it.
+func removeStaleSocket(path string) error {
+	fi, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.Mode()&fs.ModeSocket == 0 {
+		return errors.New("rpc socket path exists and is not a socket: " + path)
+	}
+	conn, err := net.Dial("unix", path)
+	if err == nil {
+		conn.Close()
+		return errors.New("another process is listening on rpc socket: " + path)
+	}
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return err
+	}
+	return os.Remove(path)
+}
+
+func (s *rpcServer) serve(listener net.Listener, addr string, useTLS bool) error {
+	if useTLS && (s.tlsCertFile != "" || s.tlsKeyFile != "") {
+		cert, err := tls.LoadX509KeyPair(s.tlsCertFile, s.tlsKeyFile)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		listener = tls.NewListener(listener, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12,
+		})
+	}
 
-	s.log.Infoln("RPC server is listening on", listener.Addr().String())
+	s.log.Infoln("RPC server is listening on", addr)
 
 	go func() {
 		err := s.httpServer.Serve(listener)
@@ -61,5 +156,9 @@ func (s *rpcServer) Start(host string, port int) error {
 func (s *rpcServer) Stop(timeout time.Duration) error {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	if s.unixSocket != "" {
+		_ = os.Remove(s.unixSocket)
+	}
+	return err
 }
@@ -0,0 +1,109 @@
+package torrent
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRPCUnixSocket(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.PEXEnabled = false
+	cfg.Host = "127.0.0.1"
+	cfg.RPCUnixSocket = filepath.Join(tmp, "rpc.sock")
+	// TLS is not used on Unix domain socket.
+	cfg.RPCTLSCertFile = filepath.Join(tmp, "missing.crt")
+	cfg.RPCTLSKeyFile = filepath.Join(tmp, "missing.key")
+	s2, err := NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := false
+	defer func() {
+		if !closed {
+			s2.Close()
+		}
+	}()
+
+	fi, err := os.Stat(cfg.RPCUnixSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = tor.Move("unix://" + cfg.RPCUnixSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, s.ListTorrents())
+
+	torrents := s2.ListTorrents()
+	assert.Len(t, torrents, 1)
+	assert.Equal(t, tor.ID(), torrents[0].ID())
+
+	err = s2.Close()
+	closed = true
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = os.Lstat(cfg.RPCUnixSocket)
+	assert.True(t, os.IsNotExist(err))
+	entries, err := os.ReadDir(tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		assert.NotContains(t, e.Name(), ".rain-rpc-")
+	}
+}
+
+func TestRPCUnixSocketExisting(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	path := filepath.Join(tmp, "rpc.sock")
+
+	// Regular file is not replaced.
+	require.NoError(t, os.WriteFile(path, []byte("foo"), 0o600))
+	assert.Error(t, newRPCServer(s).StartUnix(path, 0o600))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", string(b))
+	require.NoError(t, os.Remove(path))
+
+	// Socket of a running server is not taken over.
+	l, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	assert.Error(t, newRPCServer(s).StartUnix(path, 0o600))
+	_, err = os.Lstat(path)
+	assert.NoError(t, err)
+
+	// Stale socket is removed.
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, l.Close())
+	srv := newRPCServer(s)
+	require.NoError(t, srv.StartUnix(path, 0o600))
+	require.NoError(t, srv.Stop(timeout))
+}
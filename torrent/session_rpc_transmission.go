@@ -0,0 +1,710 @@
+package torrent
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/rain/internal/magnet"
+	"github.com/cenkalti/rain/internal/metainfo"
+)
+
+// Subset of Transmission RPC protocol is implemented for compatibility with existing clients.
+// Spec: https://github.com/transmission/transmission/blob/main/docs/rpc-spec.md
+
+const (
+	transmissionRPCPath         = "/transmission/rpc"
+	transmissionSessionIDHeader = "X-Transmission-Session-Id"
+	// Version reported to clients. Some clients refuse to talk to versions older than they support.
+	transmissionVersion    = "3.00"
+	transmissionRPCVersion = 15
+	// Requests may contain base64 encoded torrent files.
+	transmissionMaxRequestSize = 64 << 20
+)
+
+// Torrent status values in Transmission RPC.
+const (
+	transmissionStatusStopped  = 0
+	transmissionStatusCheck    = 2
+	transmissionStatusDownload = 4
+	transmissionStatusSeed     = 6
+)
+
+// Removed torrents are reported in "recently-active" torrent-get requests for this duration, same as Transmission.
+const transmissionRecentlyActive = 60 * time.Second
+
+// Values of seedRatioMode and seedIdleMode fields meaning there is no limit.
+const transmissionLimitUnlimited = 2
+
+var errTransmissionNoTorrent = errors.New("no filename or metainfo specified")
+
+// transmissionHandler serves Transmission RPC requests on top of Session.
+type transmissionHandler struct {
+	session   *Session
+	auth      *rpcAuth
+	sessionID string
+
+	// Transmission identifies torrents with integers.
+	// A number is assigned to each torrent when it is seen for the first time.
+	m          sync.Mutex
+	ids        map[string]int
+	torrentIDs map[int]string
+	lastID     int
+	// Removal times of the torrents that are no longer in the session.
+	removed map[int]time.Time
+}
+
+type transmissionMethod struct {
+	role rpcRole
+	fn   func(h *transmissionHandler, args json.RawMessage) (interface{}, error)
+}
+
+var transmissionMethods = map[string]transmissionMethod{
+	"torrent-get":        {rpcRoleReadOnly, (*transmissionHandler).torrentGet},
+	"torrent-add":        {rpcRoleAdmin, (*transmissionHandler).torrentAdd},
+	"torrent-start":      {rpcRoleAdmin, (*transmissionHandler).torrentStart},
+	"torrent-start-now":  {rpcRoleAdmin, (*transmissionHandler).torrentStart},
+	"torrent-stop":       {rpcRoleAdmin, (*transmissionHandler).torrentStop},
+	"torrent-verify":     {rpcRoleAdmin, (*transmissionHandler).torrentVerify},
+	"torrent-reannounce": {rpcRoleAdmin, (*transmissionHandler).torrentReannounce},
+	"torrent-remove":     {rpcRoleAdmin, (*transmissionHandler).torrentRemove},
+	"session-get":        {rpcRoleReadOnly, (*transmissionHandler).sessionGet},
+	"session-set":        {rpcRoleAdmin, (*transmissionHandler).sessionSet},
+	"session-stats":      {rpcRoleReadOnly, (*transmissionHandler).sessionStats},
+}
+
+type transmissionRequest struct {
+	Method    string          `json:"method"`
+	Arguments json.RawMessage `json:"arguments"`
+	Tag       json.RawMessage `json:"tag,omitempty"`
+}
+
+type transmissionResponse struct {
+	Result    string          `json:"result"`
+	Arguments interface{}     `json:"arguments"`
+	Tag       json.RawMessage `json:"tag,omitempty"`
+}
+
+func newTransmissionHandler(s *Session, auth *rpcAuth) *transmissionHandler {
+	b := make([]byte, 24)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+	return &transmissionHandler{
+		session:    s,
+		auth:       auth,
+		sessionID:  hex.EncodeToString(b),
+		ids:        make(map[string]int),
+		torrentIDs: make(map[int]string),
+		removed:    make(map[int]time.Time),
+	}
+}
+
+func (h *transmissionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	role := h.auth.check(w, r, rpcRoleReadOnly)
+	if role == rpcRoleNone {
+		return
+	}
+	// Clients must echo the session id to protect against CSRF attacks.
+	if r.Header.Get(transmissionSessionIDHeader) != h.sessionID {
+		w.Header().Set(transmissionSessionIDHeader, h.sessionID)
+		http.Error(w, "invalid session id", http.StatusConflict)
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req transmissionRequest
+	err := json.NewDecoder(io.LimitReader(r.Body, transmissionMaxRequestSize)).Decode(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m, ok := transmissionMethods[req.Method]
+	if ok && role < m.role {
+		http.Error(w, "method not allowed for read-only client: "+req.Method, http.StatusForbidden)
+		return
+	}
+	resp := transmissionResponse{
+		Result:    "success",
+		Arguments: struct{}{},
+		Tag:       req.Tag,
+	}
+	if !ok {
+		resp.Result = "method name not recognized"
+	} else if args, err := m.fn(h, req.Arguments); err != nil {
+		resp.Result = err.Error()
+	} else if args != nil {
+		resp.Arguments = args
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func decodeTransmissionArgs(b json.RawMessage, v interface{}) error {
+	if len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+// id returns the Transmission id of the torrent, assigning a new one if the torrent has not been seen before.
+func (h *transmissionHandler) id(torrentID string) int {
+	h.m.Lock()
+	defer h.m.Unlock()
+	id, ok := h.ids[torrentID]
+	if !ok {
+		h.lastID++
+		id = h.lastID
+		h.ids[torrentID] = id
+		h.torrentIDs[id] = torrentID
+	}
+	return id
+}
+
+// recentlyRemoved returns the ids of the torrents that are removed from the session in transmissionRecentlyActive.
+// Each client polling with "recently-active" gets the same list, so removals are kept until the duration passes.
+func (h *transmissionHandler) recentlyRemoved() []int {
+	now := time.Now()
+	removed := make([]int, 0)
+	h.m.Lock()
+	defer h.m.Unlock()
+	for torrentID, id := range h.ids {
+		if h.session.GetTorrent(torrentID) == nil {
+			delete(h.ids, torrentID)
+			delete(h.torrentIDs, id)
+			h.removed[id] = now
+		}
+	}
+	for id, t := range h.removed {
+		if now.Sub(t) > transmissionRecentlyActive {
+			delete(h.removed, id)
+			continue
+		}
+		removed = append(removed, id)
+	}
+	sort.Ints(removed)
+	return removed
+}
+
+// listTorrents returns the torrents in the order they are added so that ids are assigned in the same order.
+func (h *transmissionHandler) listTorrents() []*Torrent {
+	torrents := h.session.ListTorrents()
+	sort.Slice(torrents, func(i, j int) bool {
+		a, b := torrents[i].AddedAt(), torrents[j].AddedAt()
+		if a.Equal(b) {
+			return torrents[i].ID() < torrents[j].ID()
+		}
+		return a.Before(b)
+	})
+	for _, t := range torrents {
+		h.id(t.ID())
+	}
+	return torrents
+}
+
+// selectTorrents returns the torrents referred by the "ids" argument.
+// The argument may be a single id, a list of ids and info hashes or "recently-active".
+// All torrents are returned if the argument is missing.
+func (h *transmissionHandler) selectTorrents(ids json.RawMessage) (torrents []*Torrent, recentlyActive bool, err error) {
+	torrents = h.listTorrents()
+	if len(ids) == 0 || string(ids) == "null" {
+		return torrents, false, nil
+	}
+	var list []interface{}
+	var s string
+	var n int
+	switch {
+	case json.Unmarshal(ids, &n) == nil:
+		list = []interface{}{float64(n)}
+	case json.Unmarshal(ids, &s) == nil && s == "recently-active":
+		// Torrents that are not stopped are considered active.
+		active := torrents[:0]
+		for _, t := range torrents {
+			if h.session.statsCache.Get(t).Status != Stopped {
+				active = append(active, t)
+			}
+		}
+		return active, true, nil
+	case json.Unmarshal(ids, &s) == nil:
+		list = []interface{}{s}
+	case json.Unmarshal(ids, &list) == nil:
+	default:
+		return nil, false, errors.New("invalid ids")
+	}
+	selected := make(map[string]struct{}, len(list))
+	for _, v := range list {
+		switch v := v.(type) {
+		case float64:
+			h.m.Lock()
+			torrentID, ok := h.torrentIDs[int(v)]
+			h.m.Unlock()
+			if ok {
+				selected[torrentID] = struct{}{}
+			}
+		case string:
+			// Either a torrent id or an info hash in hex.
+			selected[v] = struct{}{}
+			selected[strings.ToLower(v)] = struct{}{}
+		default:
+			return nil, false, errors.New("invalid ids")
+		}
+	}
+	ret := make([]*Torrent, 0, len(selected))
+	for _, t := range torrents {
+		_, byID := selected[t.ID()]
+		_, byHash := selected[t.InfoHash().String()]
+		if byID || byHash {
+			ret = append(ret, t)
+		}
+	}
+	return ret, false, nil
+}
+
+type transmissionFile struct {
+	Name           string `json:"name"`
+	Length         int64  `json:"length"`
+	BytesCompleted int64  `json:"bytesCompleted"`
+}
+
+type transmissionFileStats struct {
+	BytesCompleted int64 `json:"bytesCompleted"`
+	Wanted         bool  `json:"wanted"`
+	Priority       int   `json:"priority"`
+}
+
+type transmissionTracker struct {
+	ID       int    `json:"id"`
+	Announce string `json:"announce"`
+	Tier     int    `json:"tier"`
+}
+
+type transmissionTorrentRef struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	HashString string `json:"hashString"`
+}
+
+// transmissionTorrentFields maps the supported fields of torrent-get method to their values.
+// Unknown fields are ignored as Transmission does.
+var transmissionTorrentFields = map[string]func(h *transmissionHandler, t *Torrent, s *Stats) interface{}{
+	"id":             func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return h.id(t.ID()) },
+	"hashString":     func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.InfoHash.String() },
+	"name":           func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Name },
+	"status":         func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return transmissionStatus(s.Status) },
+	"addedDate":      func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return t.AddedAt().Unix() },
+	"downloadDir":    func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return t.Dir() },
+	"isPrivate":      func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Private },
+	"totalSize":      func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Bytes.Total },
+	"sizeWhenDone":   func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Bytes.Total },
+	"leftUntilDone":  func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Bytes.Incomplete },
+	"haveValid":      func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Bytes.Completed },
+	"downloadedEver": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Bytes.Downloaded },
+	"uploadedEver":   func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Bytes.Uploaded },
+	"rateDownload":   func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Speed.Download },
+	"rateUpload":     func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Speed.Upload },
+	"peersConnected": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.Peers.Total },
+	"fileCount":      func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return s.FileCount },
+	"secondsSeeding": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		return int64(s.SeededFor / time.Second)
+	},
+	"percentDone": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		return fraction(s.Bytes.Completed, s.Bytes.Total)
+	},
+	"recheckProgress": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		if s.Status != Verifying {
+			return 0
+		}
+		return fraction(int64(s.Pieces.Checked), int64(s.Pieces.Total))
+	},
+	"metadataPercentComplete": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		if s.Pieces.Total == 0 {
+			return 0
+		}
+		return 1
+	},
+	"uploadRatio": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		if s.Bytes.Total == 0 {
+			return -1
+		}
+		return fraction(s.Bytes.Uploaded, s.Bytes.Total)
+	},
+	"eta": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		if s.ETA == nil {
+			return -1
+		}
+		return int64(*s.ETA / time.Second)
+	},
+	"isFinished": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		return s.Status == Stopped && s.Bytes.Total > 0 && s.Bytes.Incomplete == 0
+	},
+	"error": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		if s.Error != nil {
+			return 3 // local error
+		}
+		return 0
+	},
+	"errorString": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		if s.Error != nil {
+			return s.Error.Error()
+		}
+		return ""
+	},
+	"magnetLink": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		m, _ := t.Magnet()
+		return m
+	},
+	"files": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		fs := fileStatsOrFiles(t)
+		files := make([]transmissionFile, len(fs))
+		for i, f := range fs {
+			files[i] = transmissionFile{Name: f.Path(), Length: f.Length(), BytesCompleted: f.BytesCompleted}
+		}
+		return files
+	},
+	"fileStats": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		fs := fileStatsOrFiles(t)
+		stats := make([]transmissionFileStats, len(fs))
+		for i, f := range fs {
+			stats[i] = transmissionFileStats{BytesCompleted: f.BytesCompleted, Wanted: true}
+		}
+		return stats
+	},
+	"trackers": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} {
+		trackers := t.Trackers()
+		ret := make([]transmissionTracker, len(trackers))
+		for i, tr := range trackers {
+			ret[i] = transmissionTracker{ID: i, Announce: tr.URL}
+		}
+		return ret
+	},
+	// Torrents have no labels or seeding limits.
+	"labels":         func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return []string{} },
+	"seedRatioLimit": func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return 0 },
+	"seedRatioMode":  func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return transmissionLimitUnlimited },
+	"seedIdleLimit":  func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return 0 },
+	"seedIdleMode":   func(h *transmissionHandler, t *Torrent, s *Stats) interface{} { return transmissionLimitUnlimited },
+}
+
+// fileStatsOrFiles returns the files with zero completed bytes if the torrent is not running.
+func fileStatsOrFiles(t *Torrent) []FileStats {
+	if fs, err := t.FileStats(); err == nil {
+		return fs
+	}
+	files, _ := t.Files()
+	fs := make([]FileStats, len(files))
+	for i, f := range files {
+		fs[i] = FileStats{File: f}
+	}
+	return fs
+}
+
+func transmissionStatus(s Status) int {
+	switch s {
+	case Allocating, Verifying:
+		return transmissionStatusCheck
+	case DownloadingMetadata, Downloading, LowDiskSpace:
+		return transmissionStatusDownload
+	case Seeding:
+		return transmissionStatusSeed
+	default:
+		return transmissionStatusStopped
+	}
+}
+
+func fraction(a, b int64) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
+
+func (h *transmissionHandler) torrentGet(b json.RawMessage) (interface{}, error) {
+	var args struct {
+		IDs    json.RawMessage `json:"ids"`
+		Fields []string        `json:"fields"`
+		Format string          `json:"format"`
+	}
+	if err := decodeTransmissionArgs(b, &args); err != nil {
+		return nil, err
+	}
+	if len(args.Fields) == 0 {
+		return nil, errors.New("no fields specified")
+	}
+	if args.Format != "" && args.Format != "objects" {
+		return nil, errors.New("unsupported format: " + args.Format)
+	}
+	torrents, recentlyActive, err := h.selectTorrents(args.IDs)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]map[string]interface{}, 0, len(torrents))
+	for _, t := range torrents {
+		stats := h.session.statsCache.Get(t)
+		obj := make(map[string]interface{}, len(args.Fields))
+		for _, f := range args.Fields {
+			if value, ok := transmissionTorrentFields[f]; ok {
+				obj[f] = value(h, t, &stats)
+			}
+		}
+		objects = append(objects, obj)
+	}
+	reply := map[string]interface{}{"torrents": objects}
+	if recentlyActive {
+		reply["removed"] = h.recentlyRemoved()
+	}
+	return reply, nil
+}
+
+// torrentAdd adds a torrent from a magnet link, an URL or base64 encoded metainfo.
+// The "download-dir" argument is ignored because the directories of torrents are determined by Config.
+func (h *transmissionHandler) torrentAdd(b json.RawMessage) (interface{}, error) {
+	var args struct {
+		Filename string `json:"filename"`
+		Metainfo string `json:"metainfo"`
+		Paused   bool   `json:"paused"`
+	}
+	if err := decodeTransmissionArgs(b, &args); err != nil {
+		return nil, err
+	}
+	opt := &AddTorrentOptions{Stopped: args.Paused}
+	var t *Torrent
+	switch {
+	case args.Metainfo != "":
+		data, err := base64.StdEncoding.DecodeString(args.Metainfo)
+		if err != nil {
+			return nil, err
+		}
+		if mi, err := metainfo.New(bytes.NewReader(data)); err == nil {
+			if dup := h.findTorrent(mi.Info.Hash); dup != nil {
+				return map[string]interface{}{"torrent-duplicate": h.torrentRef(dup)}, nil
+			}
+		}
+		t, err = h.session.AddTorrent(bytes.NewReader(data), opt)
+		if err != nil {
+			return nil, err
+		}
+	case args.Filename != "":
+		if m, err := magnet.New(args.Filename); err == nil {
+			if dup := h.findTorrent(m.InfoHash); dup != nil {
+				return map[string]interface{}{"torrent-duplicate": h.torrentRef(dup)}, nil
+			}
+		}
+		var err error
+		t, err = h.session.AddURI(args.Filename, opt)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errTransmissionNoTorrent
+	}
+	return map[string]interface{}{"torrent-added": h.torrentRef(t)}, nil
+}
+
+func (h *transmissionHandler) findTorrent(ih [20]byte) *Torrent {
+	for _, t := range h.session.ListTorrents() {
+		if t.InfoHash() == ih {
+			return t
+		}
+	}
+	return nil
+}
+
+func (h *transmissionHandler) torrentRef(t *Torrent) transmissionTorrentRef {
+	return transmissionTorrentRef{
+		ID:         h.id(t.ID()),
+		Name:       t.Name(),
+		HashString: t.InfoHash().String(),
+	}
+}
+
+// forEach calls fn for each torrent referred by the "ids" argument.
+func (h *transmissionHandler) forEach(b json.RawMessage, fn func(t *Torrent) error) (interface{}, error) {
+	var args struct {
+		IDs json.RawMessage `json:"ids"`
+	}
+	if err := decodeTransmissionArgs(b, &args); err != nil {
+		return nil, err
+	}
+	torrents, _, err := h.selectTorrents(args.IDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range torrents {
+		if err = fn(t); err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
+func (h *transmissionHandler) torrentStart(b json.RawMessage) (interface{}, error) {
+	return h.forEach(b, (*Torrent).Start)
+}
+
+func (h *transmissionHandler) torrentStop(b json.RawMessage) (interface{}, error) {
+	return h.forEach(b, (*Torrent).Stop)
+}
+
+func (h *transmissionHandler) torrentVerify(b json.RawMessage) (interface{}, error) {
+	return h.forEach(b, (*Torrent).Verify)
+}
+
+func (h *transmissionHandler) torrentReannounce(b json.RawMessage) (interface{}, error) {
+	return h.forEach(b, func(t *Torrent) error {
+		t.Announce()
+		return nil
+	})
+}
+
+func (h *transmissionHandler) torrentRemove(b json.RawMessage) (interface{}, error) {
+	var args struct {
+		IDs             json.RawMessage `json:"ids"`
+		DeleteLocalData bool            `json:"delete-local-data"`
+	}
+	if err := decodeTransmissionArgs(b, &args); err != nil {
+		return nil, err
+	}
+	return h.forEach(b, func(t *Torrent) error {
+		return h.session.removeTorrent(t.ID(), !args.DeleteLocalData)
+	})
+}
+
+// sessionSettings returns the values of session arguments in Transmission RPC.
+func (h *transmissionHandler) sessionSettings() map[string]interface{} {
+	cfg := &h.session.config
+	encryption := "preferred"
+	if cfg.ForceIncomingEncryption && cfg.ForceOutgoingEncryption {
+		encryption = "required"
+	} else if cfg.DisableOutgoingEncryption {
+		encryption = "tolerated"
+	}
+	return map[string]interface{}{
+		"version":                    transmissionVersion + " (rain " + Version + ")",
+		"rpc-version":                transmissionRPCVersion,
+		"rpc-version-minimum":        1,
+		"session-id":                 h.sessionID,
+		"download-dir":               cfg.DataDir,
+		"incomplete-dir-enabled":     false,
+		"start-added-torrents":       true,
+		"peer-port":                  cfg.PortBegin,
+		"dht-enabled":                cfg.DHTEnabled,
+		"pex-enabled":                cfg.PEXEnabled,
+		"blocklist-enabled":          cfg.BlocklistURL != "",
+		"encryption":                 encryption,
+		"speed-limit-down":           cfg.SpeedLimitDownload,
+		"speed-limit-down-enabled":   cfg.SpeedLimitDownload > 0,
+		"speed-limit-up":             cfg.SpeedLimitUpload,
+		"speed-limit-up-enabled":     cfg.SpeedLimitUpload > 0,
+		"seedRatioLimit":             0,
+		"seedRatioLimited":           false,
+		"idle-seeding-limit":         0,
+		"idle-seeding-limit-enabled": false,
+	}
+}
+
+func (h *transmissionHandler) sessionGet(b json.RawMessage) (interface{}, error) {
+	var args struct {
+		Fields []string `json:"fields"`
+	}
+	if err := decodeTransmissionArgs(b, &args); err != nil {
+		return nil, err
+	}
+	settings := h.sessionSettings()
+	if len(args.Fields) == 0 {
+		return settings, nil
+	}
+	ret := make(map[string]interface{}, len(args.Fields))
+	for _, f := range args.Fields {
+		if v, ok := settings[f]; ok {
+			ret[f] = v
+		}
+	}
+	return ret, nil
+}
+
+// sessionSet accepts all arguments but changes nothing because Config cannot be changed while Session is running.
+// Clients commonly send their preferences after connecting and show an error if the request fails.
+func (h *transmissionHandler) sessionSet(b json.RawMessage) (interface{}, error) {
+	var args map[string]json.RawMessage
+	if err := decodeTransmissionArgs(b, &args); err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(args))
+	for k := range args {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	settings := h.sessionSettings()
+	for _, k := range keys {
+		v, ok := settings[k]
+		if !ok || !jsonEqual(v, args[k]) {
+			h.session.log.Debugln("ignoring session argument in transmission rpc:", k)
+		}
+	}
+	return nil, nil
+}
+
+func jsonEqual(v interface{}, b json.RawMessage) bool {
+	a, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	var va, vb interface{}
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+type transmissionStats struct {
+	UploadedBytes   int64 `json:"uploadedBytes"`
+	DownloadedBytes int64 `json:"downloadedBytes"`
+	FilesAdded      int   `json:"filesAdded"`
+	SessionCount    int   `json:"sessionCount"`
+	SecondsActive   int64 `json:"secondsActive"`
+}
+
+func (h *transmissionHandler) sessionStats(b json.RawMessage) (interface{}, error) {
+	torrents := h.session.ListTorrents()
+	var active, paused int
+	// History of removed torrents is not kept, so cumulative stats are the totals of torrents in the session.
+	var cumulative transmissionStats
+	for _, t := range torrents {
+		stats := h.session.statsCache.Get(t)
+		if stats.Status == Stopped {
+			paused++
+		} else {
+			active++
+		}
+		cumulative.DownloadedBytes += stats.Bytes.Downloaded
+		cumulative.UploadedBytes += stats.Bytes.Uploaded
+	}
+	ss := h.session.Stats()
+	return map[string]interface{}{
+		"torrentCount":       len(torrents),
+		"activeTorrentCount": active,
+		"pausedTorrentCount": paused,
+		"downloadSpeed":      ss.SpeedDownload,
+		"uploadSpeed":        ss.SpeedUpload,
+		"cumulative-stats":   cumulative,
+		"current-stats": transmissionStats{
+			UploadedBytes:   ss.BytesUploaded,
+			DownloadedBytes: ss.BytesDownloaded,
+			SessionCount:    1,
+			SecondsActive:   int64(ss.Uptime / time.Second),
+		},
+	}, nil
+}
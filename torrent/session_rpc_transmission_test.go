@@ -0,0 +1,124 @@
+package torrent
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransmissionRPC(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	h := newTransmissionHandler(s, &rpcAuth{})
+
+	call := func(sessionID, method string, args interface{}) (*httptest.ResponseRecorder, transmissionResponse) {
+		b, err := json.Marshal(map[string]interface{}{"method": method, "arguments": args, "tag": 7})
+		require.NoError(t, err)
+		req := httptest.NewRequest(http.MethodPost, transmissionRPCPath, strings.NewReader(string(b)))
+		req.Header.Set(transmissionSessionIDHeader, sessionID)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		var resp transmissionResponse
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Equal(t, "7", string(resp.Tag))
+		}
+		return rec, resp
+	}
+
+	// Handshake
+	rec, _ := call("", "session-get", nil)
+	require.Equal(t, http.StatusConflict, rec.Code)
+	sessionID := rec.Header().Get(transmissionSessionIDHeader)
+	require.NotEmpty(t, sessionID)
+	rpc := func(method string, args interface{}) map[string]interface{} {
+		rec, resp := call(sessionID, method, args)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "success", resp.Result)
+		return resp.Arguments.(map[string]interface{})
+	}
+
+	b, err := os.ReadFile(torrentFile)
+	require.NoError(t, err)
+	metainfo := base64.StdEncoding.EncodeToString(b)
+	added := rpc("torrent-add", map[string]interface{}{"metainfo": metainfo, "paused": true})["torrent-added"].(map[string]interface{})
+	assert.Equal(t, float64(1), added["id"])
+	assert.Equal(t, torrentName, added["name"])
+	dup := rpc("torrent-add", map[string]interface{}{"metainfo": metainfo})
+	assert.Contains(t, dup, "torrent-duplicate")
+	require.Len(t, s.ListTorrents(), 1)
+	tor := s.ListTorrents()[0]
+
+	torrents := rpc("torrent-get", map[string]interface{}{
+		"ids":    []interface{}{tor.InfoHash().String()},
+		"fields": []string{"id", "name", "status", "totalSize", "files", "unknown"},
+	})["torrents"].([]interface{})
+	require.Len(t, torrents, 1)
+	obj := torrents[0].(map[string]interface{})
+	assert.Equal(t, float64(1), obj["id"])
+	assert.Equal(t, torrentName, obj["name"])
+	assert.Equal(t, float64(transmissionStatusStopped), obj["status"])
+	assert.Len(t, obj["files"], 6)
+	assert.NotContains(t, obj, "unknown")
+
+	active := rpc("torrent-get", map[string]interface{}{"ids": "recently-active", "fields": []string{"id"}})
+	assert.Empty(t, active["torrents"])
+	assert.Empty(t, active["removed"])
+
+	settings := rpc("session-get", map[string]interface{}{"fields": []string{"rpc-version", "download-dir"}})
+	assert.Equal(t, float64(transmissionRPCVersion), settings["rpc-version"])
+	assert.Equal(t, s.config.DataDir, settings["download-dir"])
+	// Arguments that cannot be applied are ignored.
+	rpc("session-set", map[string]interface{}{"download-dir": s.config.DataDir})
+	rpc("session-set", map[string]interface{}{"download-dir": "/elsewhere", "alt-speed-enabled": true})
+	assert.Equal(t, s.config.DataDir, rpc("session-get", nil)["download-dir"])
+
+	stats := rpc("session-stats", nil)
+	assert.Equal(t, float64(1), stats["torrentCount"])
+	assert.Equal(t, float64(1), stats["pausedTorrentCount"])
+
+	_, resp := call(sessionID, "torrent-rename-path", nil)
+	assert.Equal(t, "method name not recognized", resp.Result)
+
+	// Files are kept unless delete-local-data is set.
+	dir := tor.Dir()
+	require.NoError(t, os.MkdirAll(dir, 0o750))
+	rpc("torrent-remove", map[string]interface{}{"ids": 1})
+	assert.Empty(t, s.ListTorrents())
+	assert.DirExists(t, dir)
+	active = rpc("torrent-get", map[string]interface{}{"ids": "recently-active", "fields": []string{"id"}})
+	assert.Equal(t, []interface{}{float64(1)}, active["removed"])
+
+	// Other clients polling in the same window see the removal too.
+	active = rpc("torrent-get", map[string]interface{}{"ids": "recently-active", "fields": []string{"id"}})
+	assert.Equal(t, []interface{}{float64(1)}, active["removed"])
+	h.m.Lock()
+	h.removed[1] = h.removed[1].Add(-transmissionRecentlyActive - time.Second)
+	h.m.Unlock()
+	active = rpc("torrent-get", map[string]interface{}{"ids": "recently-active", "fields": []string{"id"}})
+	assert.Empty(t, active["removed"])
+}
+
+func TestTransmissionRPCReadOnly(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	auth := &rpcAuth{credentials: []RPCCredential{{Token: "ro", Role: "readonly"}}}
+	h := newTransmissionHandler(s, auth)
+
+	for method, code := range map[string]int{"torrent-get": http.StatusOK, "torrent-stop": http.StatusForbidden} {
+		req := httptest.NewRequest(http.MethodPost, transmissionRPCPath, strings.NewReader(`{"method":"`+method+`","arguments":{"fields":["id"]}}`))
+		req.Header.Set("Authorization", "Bearer ro")
+		req.Header.Set(transmissionSessionIDHeader, h.sessionID)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, code, rec.Code, method)
+	}
+}
@@ -0,0 +1,23 @@
+package torrent
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webUIPath is the URL path of the web UI on RPC server.
+const webUIPath = "/ui/"
+
+//go:embed webui
+var webUIFiles embed.FS
+
+// newWebUIHandler returns a handler that serves the static files of the web UI.
+// The UI talks to the JSON-RPC endpoint at the root path of the server.
+func newWebUIHandler() http.Handler {
+	files, err := fs.Sub(webUIFiles, "webui")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix(webUIPath, http.FileServer(http.FS(files)))
+}
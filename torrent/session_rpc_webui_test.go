@@ -0,0 +1,27 @@
+package torrent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebUI(t *testing.T) {
+	h := newWebUIHandler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, webUIPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `<script src="app.js">`)
+
+	for _, name := range []string{"app.js", "style.css"} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, webUIPath+name, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, name)
+	}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, webUIPath+"missing.js", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
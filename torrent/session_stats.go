@@ -73,6 +73,14 @@ type SessionStats struct {
 	BytesRead int64
 	// Number of bytes written to disk.
 	BytesWritten int64
+
+	// Number of suggest piece messages sent to peers for the pieces in read cache.
+	SuggestsSent int64
+	// Number of suggest piece messages received from peers.
+	SuggestsReceived int64
+	// Number of block requests received for the pieces that are suggested to the requesting peer.
+	// These requests are likely to be served from the read cache.
+	SuggestedRequests int64
 }
 
 // Stats returns current statistics about the Session.
@@ -111,6 +119,10 @@ func (s *Session) Stats() SessionStats {
 		BytesUploaded:   s.metrics.SpeedUpload.Count(),
 		BytesRead:       s.metrics.SpeedRead.Count(),
 		BytesWritten:    s.metrics.SpeedWrite.Count(),
+
+		SuggestsSent:      s.metrics.SuggestsSent.Count(),
+		SuggestsReceived:  s.metrics.SuggestsReceived.Count(),
+		SuggestedRequests: s.metrics.SuggestedRequests.Count(),
 	}
 }
 
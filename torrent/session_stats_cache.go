@@ -0,0 +1,56 @@
+package torrent
+
+import (
+	"sync"
+	"time"
+)
+
+// statsCache keeps the recent stats of torrents for serving list requests without querying each torrent loop every time.
+type statsCache struct {
+	ttl     time.Duration
+	m       sync.Mutex
+	entries map[string]statsCacheEntry
+}
+
+type statsCacheEntry struct {
+	stats     Stats
+	updatedAt time.Time
+}
+
+func newStatsCache(ttl time.Duration) *statsCache {
+	return &statsCache{
+		ttl:     ttl,
+		entries: make(map[string]statsCacheEntry),
+	}
+}
+
+// Get returns the stats of the torrent from the cache or from the torrent if the entry is expired.
+func (c *statsCache) Get(t *Torrent) Stats {
+	id := t.ID()
+	c.m.Lock()
+	e, ok := c.entries[id]
+	c.m.Unlock()
+	if ok && time.Since(e.updatedAt) < c.ttl {
+		return e.stats
+	}
+	e = statsCacheEntry{stats: t.Stats(), updatedAt: time.Now()}
+	c.m.Lock()
+	c.entries[id] = e
+	c.m.Unlock()
+	return e.stats
+}
+
+// Retain deletes the entries of torrents that are not in the list.
+func (c *statsCache) Retain(torrents []*Torrent) {
+	ids := make(map[string]struct{}, len(torrents))
+	for _, t := range torrents {
+		ids[t.ID()] = struct{}{}
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	for id := range c.entries {
+		if _, ok := ids[id]; !ok {
+			delete(c.entries, id)
+		}
+	}
+}
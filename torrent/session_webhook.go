@@ -0,0 +1,215 @@
+package torrent
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v3"
+	"github.com/cenkalti/rain/internal/logger"
+	"github.com/cenkalti/rain/internal/rpctypes"
+)
+
+// webhookSignatureHeader contains the hex encoded HMAC-SHA256 of the request body if the webhook has a secret.
+const webhookSignatureHeader = "X-Rain-Signature"
+
+// webhookEvents are the events that can be sent to webhooks, keyed by their names in Webhook.Events.
+var webhookEvents = map[string]EventType{
+	EventTorrentAdded.String():     EventTorrentAdded,
+	EventMetadataReceived.String(): EventMetadataReceived,
+	EventCompleted.String():        EventCompleted,
+	EventError.String():            EventError,
+	EventTorrentRemoved.String():   EventTorrentRemoved,
+	EventRatioReached.String():     EventRatioReached,
+}
+
+type webhook struct {
+	url    string
+	events map[EventType]struct{}
+	secret []byte
+}
+
+// webhookSender posts torrent events to the webhooks in Config.
+type webhookSender struct {
+	hooks         []webhook
+	client        http.Client
+	maxAttempts   int
+	retryInterval time.Duration
+
+	// Cancelled on close to abort pending deliveries.
+	ctx    context.Context
+	cancel context.CancelFunc
+	m      sync.Mutex
+	wg     sync.WaitGroup
+
+	log logger.Logger
+}
+
+// newWebhookSender returns nil if there are no webhooks in cfg.
+func newWebhookSender(cfg *Config) (*webhookSender, error) {
+	if len(cfg.Webhooks) == 0 {
+		return nil, nil
+	}
+	if cfg.WebhookMaxAttempts < 1 {
+		return nil, errors.New("webhook max attempts must be at least 1")
+	}
+	hooks := make([]webhook, 0, len(cfg.Webhooks))
+	for _, wh := range cfg.Webhooks {
+		u, err := url.Parse(wh.URL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid webhook url: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, errors.New("invalid webhook url: " + wh.URL)
+		}
+		h := webhook{
+			url:    wh.URL,
+			events: make(map[EventType]struct{}),
+		}
+		if wh.Secret != "" {
+			h.secret = []byte(wh.Secret)
+		}
+		names := wh.Events
+		if len(names) == 0 {
+			for name := range webhookEvents {
+				names = append(names, name)
+			}
+		}
+		for _, name := range names {
+			et, ok := webhookEvents[name]
+			if !ok {
+				return nil, errors.New("invalid webhook event: " + name)
+			}
+			h.events[et] = struct{}{}
+		}
+		hooks = append(hooks, h)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookSender{
+		hooks:         hooks,
+		client:        http.Client{Timeout: cfg.WebhookTimeout},
+		maxAttempts:   cfg.WebhookMaxAttempts,
+		retryInterval: cfg.WebhookRetryInterval,
+		ctx:           ctx,
+		cancel:        cancel,
+		log:           logger.New("webhook"),
+	}, nil
+}
+
+// wants returns true if any of the webhooks must be notified for the event type.
+// Torrent stats are collected for the payload only when this returns true.
+func (w *webhookSender) wants(et EventType) bool {
+	if w == nil {
+		return false
+	}
+	for _, h := range w.hooks {
+		if _, ok := h.events[et]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// notify sends the event to webhooks in background.
+func (w *webhookSender) notify(e Event, stats Stats) {
+	if w == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	payload := rpctypes.WebhookPayload{
+		Event:     e.Type.String(),
+		TorrentID: e.TorrentID,
+		Time:      rpctypes.Time{Time: e.Time},
+		Stats:     newStats(stats),
+	}
+	if e.Error != nil {
+		payload.Error = e.Error.Error()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		panic(err)
+	}
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.ctx.Err() != nil {
+		return
+	}
+	for i := range w.hooks {
+		h := &w.hooks[i]
+		if _, ok := h.events[e.Type]; !ok {
+			continue
+		}
+		w.wg.Add(1)
+		go w.deliver(h, body)
+	}
+}
+
+func (w *webhookSender) deliver(h *webhook, body []byte) {
+	defer w.wg.Done()
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = w.retryInterval
+	bo.MaxElapsedTime = 0
+
+	ticker := backoff.NewTicker(backoff.WithContext(backoff.WithMaxRetries(bo, uint64(w.maxAttempts-1)), w.ctx))
+	defer ticker.Stop()
+
+	var err error
+	for range ticker.C {
+		err = w.post(h, body)
+		if err == nil {
+			return
+		}
+		w.log.Debugf("webhook request to %s failed: %s", h.url, err)
+	}
+	if w.ctx.Err() == nil {
+		w.log.Errorf("cannot deliver event to webhook %s: %s", h.url, err)
+	}
+}
+
+func (w *webhookSender) post(h *webhook, body []byte) error {
+	req, err := http.NewRequestWithContext(w.ctx, http.MethodPost, h.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Rain/"+Version)
+	if h.secret != nil {
+		mac := hmac.New(sha256.New, h.secret)
+		mac.Write(body)
+		req.Header.Set(webhookSignatureHeader, hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
+
+// close aborts pending deliveries and waits for them to return.
+func (w *webhookSender) close() {
+	if w == nil {
+		return
+	}
+	w.m.Lock()
+	w.cancel()
+	w.m.Unlock()
+	w.wg.Wait()
+}
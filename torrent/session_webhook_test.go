@@ -0,0 +1,108 @@
+package torrent
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/rpctypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhooks(t *testing.T) {
+	const secret = "s3cret"
+	var m sync.Mutex
+	received := make(map[string][]rpctypes.WebhookPayload)
+	failed := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		defer m.Unlock()
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		if r.URL.Path == "/signed" {
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write(body)
+			assert.Equal(t, hex.EncodeToString(mac.Sum(nil)), r.Header.Get(webhookSignatureHeader))
+		} else {
+			assert.Empty(t, r.Header.Get(webhookSignatureHeader))
+			if !failed {
+				failed = true
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+		var p rpctypes.WebhookPayload
+		require.NoError(t, json.Unmarshal(body, &p))
+		received[r.URL.Path] = append(received[r.URL.Path], p)
+	}))
+	defer srv.Close()
+
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+	cfg.WebhookRetryInterval = 10 * time.Millisecond
+	cfg.Webhooks = []Webhook{
+		{URL: srv.URL + "/signed", Events: []string{"removed"}, Secret: secret},
+		{URL: srv.URL + "/all"},
+	}
+	s, err := NewSession(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+	require.NoError(t, s.RemoveTorrent(tor.ID()))
+
+	deadline := time.Now().Add(timeout)
+	for {
+		m.Lock()
+		n := len(received["/signed"]) + len(received["/all"])
+		m.Unlock()
+		if n == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("webhooks are not delivered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	assert.True(t, failed)
+	require.Len(t, received["/signed"], 1)
+	assert.Equal(t, "removed", received["/signed"][0].Event)
+	events := make(map[string]bool)
+	for _, p := range received["/all"] {
+		events[p.Event] = true
+		assert.Equal(t, tor.ID(), p.TorrentID)
+		assert.Equal(t, tor.InfoHash().String(), p.Stats.InfoHash)
+		assert.Equal(t, torrentName, p.Stats.Name)
+	}
+	assert.True(t, events["added"])
+	assert.True(t, events["removed"])
+}
+
+func TestWebhookInvalidEvent(t *testing.T) {
+	cfg := DefaultConfig
+	cfg.Webhooks = []Webhook{{URL: "http://127.0.0.1/hook", Events: []string{"peers"}}}
+	_, err := newWebhookSender(&cfg)
+	assert.Error(t, err)
+}
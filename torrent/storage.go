@@ -0,0 +1,69 @@
+package torrent
+
+import (
+	"path/filepath"
+
+	"github.com/cenkalti/rain/internal/storage"
+	"github.com/cenkalti/rain/internal/storage/filestorage"
+	"github.com/cenkalti/rain/internal/storage/piecestorage"
+)
+
+// Storage is an interface for reading/writing the files in a torrent.
+// Open is called for each file in the torrent when the torrent is started.
+// RootDir returns the directory that contains the files. It must return empty string if the files are not on local disk.
+type Storage = storage.Storage
+
+// StorageFile is a file opened by Storage.
+// Close is called when the torrent is stopped.
+type StorageFile = storage.File
+
+// StorageReadOpener can be implemented by Storage for reading files with Torrent.OpenFile.
+// Torrent.OpenFile returns an error if the Storage does not implement it.
+type StorageReadOpener = storage.ReadOpener
+
+// StorageReadFile is a file opened by StorageReadOpener.
+type StorageReadFile = storage.ReadFile
+
+// StorageProvider creates the Storage of torrents in a Session.
+// Set Config.StorageProvider to save torrent files in a custom location instead of Config.DataDir.
+type StorageProvider interface {
+	// NewStorage returns the Storage for the torrent with ID.
+	// It is called when the torrent is added to the Session and when the Session loads existing torrents on startup.
+	NewStorage(torrentID string) (Storage, error)
+	// RemoveStorage deletes all files of the torrent with ID.
+	// It is called when the torrent is removed from the Session.
+	RemoveStorage(torrentID string) error
+}
+
+// newStorage returns the storage of a torrent. layout is the storage layout that the torrent is added with.
+// Layout of existing torrents does not change with Config.StorageLayout.
+func (s *Session) newStorage(torrentID string, inMemory bool, layout string) (Storage, error) {
+	if inMemory {
+		return s.memoryStorage.NewStorage(), nil
+	}
+	if s.config.StorageProvider != nil {
+		return s.config.StorageProvider.NewStorage(torrentID)
+	}
+	if layout == "pieces" {
+		return piecestorage.New(s.piecesDir(), s.config.FilePermissions)
+	}
+	return filestorage.New(s.getDataDir(torrentID), s.config.FilePermissions, s.allocation)
+}
+
+func (s *Session) piecesDir() string {
+	return filepath.Join(s.config.DataDir, "pieces")
+}
+
+// piecesInUse returns the hashes of pieces in torrents that are saved with "pieces" layout.
+func (s *Session) piecesInUse() map[string]struct{} {
+	hashes := make(map[string]struct{})
+	for _, t := range s.ListTorrents() {
+		if _, ok := t.torrent.storage.(*piecestorage.PieceStorage); !ok || t.torrent.info == nil {
+			continue
+		}
+		for i := uint32(0); i < t.torrent.info.NumPieces; i++ {
+			hashes[string(t.torrent.info.PieceHash(i))] = struct{}{}
+		}
+	}
+	return hashes
+}
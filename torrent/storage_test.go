@@ -0,0 +1,262 @@
+package torrent
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/storage/memorystorage"
+)
+
+type memoryStorageProvider struct {
+	m        sync.Mutex
+	storages map[string]*memorystorage.MemoryStorage
+}
+
+func (p *memoryStorageProvider) NewStorage(id string) (Storage, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	sto, ok := p.storages[id]
+	if !ok {
+		sto = memorystorage.New()
+		p.storages[id] = sto
+	}
+	return sto, nil
+}
+
+func (p *memoryStorageProvider) RemoveStorage(id string) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	delete(p.storages, id)
+	return nil
+}
+
+func (p *memoryStorageProvider) len() int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return len(p.storages)
+}
+
+func TestStorageProvider(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	provider := &memoryStorageProvider{storages: make(map[string]*memorystorage.MemoryStorage)}
+	s.config.StorageProvider = provider
+
+	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForComplete(t, tor)
+	if tor.Dir() != "" {
+		t.Fatalf("unexpected dir: %q", tor.Dir())
+	}
+	if _, err = os.Stat(filepath.Join(s.config.DataDir, tor.ID())); !os.IsNotExist(err) {
+		t.Fatal("files must not be saved under data dir")
+	}
+
+	name := filepath.Join(torrentName, "data", "file1.bin")
+	expected, err := os.ReadFile(filepath.Join(torrentDataDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sto, _ := provider.NewStorage(tor.ID())
+	f, exists, err := sto.Open(name, int64(len(expected)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists {
+		t.Fatal("file does not exist in storage")
+	}
+	actual := make([]byte, len(expected))
+	if _, err = f.ReadAt(actual, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(expected, actual) {
+		t.Fatal("file contents are different")
+	}
+
+	if err = s.RemoveTorrent(tor.ID()); err != nil {
+		t.Fatal(err)
+	}
+	if provider.len() != 0 {
+		t.Fatal("storage is not removed")
+	}
+}
+
+func TestDownloadInMemory(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	opt := &AddTorrentOptions{InMemory: true, StopAfterDownload: true}
+	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+addr, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForComplete(t, tor)
+	if _, err = os.Stat(filepath.Join(s.config.DataDir, tor.ID())); !os.IsNotExist(err) {
+		t.Fatal("files must not be saved under data dir")
+	}
+
+	name := filepath.Join(torrentName, "data", "file1.bin")
+	expected, err := os.ReadFile(filepath.Join(torrentDataDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := tor.OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if !bytes.Equal(expected, actual) {
+		t.Fatal("file contents are different")
+	}
+
+	if err = s.RemoveTorrent(tor.ID()); err != nil {
+		t.Fatal(err)
+	}
+	if size := s.memoryStorage.Size(); size != 0 {
+		t.Fatalf("memory is not released: %d", size)
+	}
+}
+
+func TestPieceStorageLayout(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.StorageLayout = "pieces"
+
+	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForComplete(t, tor)
+	if tor.Dir() != filepath.Join(s.config.DataDir, "pieces") {
+		t.Fatalf("unexpected dir: %q", tor.Dir())
+	}
+
+	// Second torrent finds all the pieces on disk without connecting to any peer.
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor2, err := s.AddTorrent(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForComplete(t, tor2)
+
+	err = s.RemoveTorrent(tor.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := filepath.Join(torrentName, "data", "file1.bin")
+	expected, err := os.ReadFile(filepath.Join(torrentDataDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf, err := tor2.OpenFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := io.ReadAll(rf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rf.Close()
+	if !bytes.Equal(expected, actual) {
+		t.Fatal("file contents are different")
+	}
+}
+
+func TestStorageLayoutSaved(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+	cfg.StorageLayout = "pieces"
+	s, err := NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	waitForComplete(t, tor)
+	id := tor.ID()
+	if err = s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Existing torrent keeps its layout after the config is changed.
+	cfg.StorageLayout = "files"
+	s, err = NewSession(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	tor = s.GetTorrent(id)
+	if tor == nil {
+		t.Fatal("torrent is not loaded")
+	}
+	if tor.Dir() != filepath.Join(tmp, "pieces") {
+		t.Fatalf("unexpected dir: %q", tor.Dir())
+	}
+	waitForComplete(t, tor)
+}
+
+func TestOpenFileMissing(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Opening a file that is not downloaded yet must not create it.
+	name := filepath.Join(torrentName, "data", "file1.bin")
+	_, err = tor.OpenFile(name)
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(s.config.DataDir, tor.ID(), name)); !os.IsNotExist(err) {
+		t.Fatal("file must not be created")
+	}
+}
+
+func waitForComplete(t *testing.T, tor *Torrent) {
+	select {
+	case <-tor.NotifyComplete():
+	case err := <-tor.torrent.NotifyError():
+		t.Fatal(err)
+	case <-time.After(timeout):
+		t.Fatal("download did not finish")
+	}
+}
@@ -3,9 +3,11 @@ package torrent
 import (
 	"crypto/rand"
 	"errors"
+	"io"
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/rain/internal/acceptor"
@@ -29,6 +31,7 @@ import (
 	"github.com/cenkalti/rain/internal/piecepicker"
 	"github.com/cenkalti/rain/internal/piecewriter"
 	"github.com/cenkalti/rain/internal/resumer"
+	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
 	"github.com/cenkalti/rain/internal/storage"
 	"github.com/cenkalti/rain/internal/suspendchan"
 	"github.com/cenkalti/rain/internal/tracker"
@@ -98,11 +101,19 @@ type torrent struct {
 	incomingPeers map[*peer.Peer]struct{}
 	outgoingPeers map[*peer.Peer]struct{}
 
+	// Recently disconnected peers with the reason of disconnect. Oldest peer is at the front.
+	disconnectedPeers []Peer
+
+	// Recent connection attempts. Oldest attempt is at the front.
+	connectionAttempts []ConnectionAttempt
+	// Number of all connection attempts by result.
+	connectionResults map[ConnectionResult]int
+
 	// Keep recently seen peers to fill underpopulated PEX lists.
 	recentlySeen pexlist.RecentlySeen
 
 	// Unchoker implements an algorithm to select peers to unchoke based on their download speed.
-	unchoker *unchoker.Unchoker
+	unchoker unchoker.Algorithm
 
 	// Active piece downloads are kept in this map.
 	pieceDownloaders        map[*peer.Peer]*piecedownloader.PieceDownloader
@@ -143,18 +154,23 @@ type torrent struct {
 	doneC chan struct{}
 
 	// These are the channels for sending a message to run() loop.
-	statsCommandC        chan statsRequest        // Stats()
-	trackersCommandC     chan trackersRequest     // Trackers()
-	peersCommandC        chan peersRequest        // Peers()
-	webseedsCommandC     chan webseedsRequest     // Webseeds()
-	startCommandC        chan struct{}            // Start()
-	stopCommandC         chan struct{}            // Stop()
-	announceCommandC     chan struct{}            // Announce()
-	verifyCommandC       chan struct{}            // Verify()
-	notifyErrorCommandC  chan notifyErrorCommand  // NotifyError()
-	notifyListenCommandC chan notifyListenCommand // NotifyListen()
-	addPeersCommandC     chan []*net.TCPAddr      // AddPeers()
-	addTrackersCommandC  chan []tracker.Tracker   // AddTrackers()
+	statsCommandC              chan statsRequest              // Stats()
+	trackersCommandC           chan trackersRequest           // Trackers()
+	peersCommandC              chan peersRequest              // Peers()
+	disconnectedPeersCommandC  chan peersRequest              // DisconnectedPeers()
+	connectionAttemptsCommandC chan connectionAttemptsRequest // ConnectionAttempts()
+	webseedsCommandC           chan webseedsRequest           // Webseeds()
+	openFileCommandC           chan openFileRequest           // OpenFile()
+	cachedPeersCommandC        chan cachedPeersRequest        // CachedPeers()
+	startCommandC              chan struct{}                  // Start()
+	stopCommandC               chan struct{}                  // Stop()
+	announceCommandC           chan struct{}                  // Announce()
+	verifyCommandC             chan struct{}                  // Verify()
+	notifyErrorCommandC        chan notifyErrorCommand        // NotifyError()
+	notifyListenCommandC       chan notifyListenCommand       // NotifyListen()
+	addPeersCommandC           chan []*net.TCPAddr            // AddPeers()
+	addTrackersCommandC        chan []tracker.Tracker         // AddTrackers()
+	lowDiskSpaceCommandC       chan bool                      // SetLowDiskSpace()
 
 	// Trackers send announce responses to this channel.
 	addrsFromTrackers chan []*net.TCPAddr
@@ -225,8 +241,8 @@ type torrent struct {
 	// Holds connected peer IPs so we don't dial/accept multiple connections to/from same IP.
 	connectedPeerIPs map[string]struct{}
 
-	// Peers that are sending corrupt data are banned.
-	bannedPeerIPs map[string]struct{}
+	// Holepunch rendezvous messages that we have sent, keyed by target address. See BEP 55.
+	holepunchPending map[string]holepunchRendezvous
 
 	// A signal sent to run() loop when announcers are stopped.
 	announcersStoppedC chan struct{}
@@ -248,6 +264,18 @@ type torrent struct {
 	webseedRetryC          chan *webseedsource.WebseedSource
 	webseedActiveDownloads int
 
+	// True when free space on the disk is less than Config.MinFreeDiskSpace.
+	// New piece downloads are not started until enough space becomes available.
+	lowDiskSpace bool
+
+	// True when the torrent has missing pieces but downloading is paused.
+	// Read by announcers for sending "paused" event to trackers (BEP 21).
+	partialSeed atomic.Bool
+
+	// Running state and number of connections that are last reported to the session for sharing Config.MaxConnections.
+	reportedRunning     bool
+	reportedConnections int
+
 	// Set to true when manual verification is requested
 	doVerify bool
 
@@ -260,6 +288,42 @@ type torrent struct {
 	// True means that completeCmd has run before.
 	completeCmdRun bool
 
+	// Outcome of the last hook command. Hook goroutines send results to lastHookC.
+	lastHook  hookResult
+	lastHookC chan hookResult
+
+	// Addresses of outgoing peers that we have connected before, most recent first.
+	cachedPeers []boltdbresumer.CachedPeer
+
+	// Receives a value when the ban list of the session changes.
+	bansChangedC chan struct{}
+
+	// Receives a value when the torrent must close connections for other torrents sharing Config.MaxConnections.
+	closeConnectionsC chan struct{}
+
+	// If true, files are kept in memory instead of disk.
+	inMemory bool
+
+	// Storage layout that the torrent is added with. Empty means "files".
+	storageLayout string
+
+	// Choking algorithm set when the torrent is added. Empty means Config.ChokingAlgorithm.
+	chokingAlgorithm string
+
+	// If true, BEP 16 super-seeding is done when the torrent is completed.
+	// It is set to false after all pieces are distributed to peers.
+	superSeeding bool
+	superSeeder  *superSeeder
+
+	// Indexes of the pieces in the read cache and the cache generation that they are read at.
+	cachedPieceIndexes     []uint32
+	cachedPiecesGeneration uint64
+
+	// Last values published to event subscribers.
+	lastEventStatus Status
+	lastEventPeers  int
+	lastEventRatio  bool
+
 	log logger.Logger
 }
 
@@ -282,6 +346,7 @@ func newTorrent2(
 	stopAfterDownload bool,
 	stopAfterMetadata bool,
 	completeCmdRun bool,
+	chokingAlgorithm string, // overrides Config.ChokingAlgorithm if not empty
 ) (*torrent, error) {
 	if len(infoHash) != 20 {
 		return nil, errors.New("invalid infoHash (must be 20 bytes)")
@@ -290,79 +355,88 @@ func newTorrent2(
 	var ih [20]byte
 	copy(ih[:], infoHash)
 	t := &torrent{
-		session:                   s,
-		id:                        id,
-		addedAt:                   addedAt,
-		infoHash:                  ih,
-		trackers:                  trackers,
-		fixedPeers:                fixedPeers,
-		name:                      name,
-		storage:                   sto,
-		port:                      port,
-		info:                      info,
-		bitfield:                  bf,
-		log:                       logger.New("torrent " + id),
-		peerDisconnectedC:         make(chan *peer.Peer),
-		messages:                  make(chan peer.Message),
-		pieceMessagesC:            suspendchan.New[peer.PieceMessage](0),
-		peers:                     make(map[*peer.Peer]struct{}),
-		incomingPeers:             make(map[*peer.Peer]struct{}),
-		outgoingPeers:             make(map[*peer.Peer]struct{}),
-		pieceDownloaders:          make(map[*peer.Peer]*piecedownloader.PieceDownloader),
-		pieceDownloadersSnubbed:   make(map[*peer.Peer]*piecedownloader.PieceDownloader),
-		pieceDownloadersChoked:    make(map[*peer.Peer]*piecedownloader.PieceDownloader),
-		peerSnubbedC:              make(chan *peer.Peer),
-		infoDownloaders:           make(map[*peer.Peer]*infodownloader.InfoDownloader),
-		infoDownloadersSnubbed:    make(map[*peer.Peer]*infodownloader.InfoDownloader),
-		pieceWriterResultC:        make(chan *piecewriter.PieceWriter),
-		completeC:                 make(chan struct{}),
-		completeMetadataC:         make(chan struct{}),
-		closeC:                    make(chan struct{}),
-		startCommandC:             make(chan struct{}),
-		stopCommandC:              make(chan struct{}),
-		announceCommandC:          make(chan struct{}),
-		verifyCommandC:            make(chan struct{}),
-		statsCommandC:             make(chan statsRequest),
-		trackersCommandC:          make(chan trackersRequest),
-		peersCommandC:             make(chan peersRequest),
-		webseedsCommandC:          make(chan webseedsRequest),
-		notifyErrorCommandC:       make(chan notifyErrorCommand),
-		notifyListenCommandC:      make(chan notifyListenCommand),
-		addPeersCommandC:          make(chan []*net.TCPAddr),
-		addTrackersCommandC:       make(chan []tracker.Tracker),
-		addrsFromTrackers:         make(chan []*net.TCPAddr),
-		peerIDs:                   make(map[[20]byte]struct{}),
-		incomingConnC:             make(chan net.Conn),
-		sKeyHash:                  mse.HashSKey(ih[:]),
-		infoDownloaderResultC:     make(chan *infodownloader.InfoDownloader),
-		incomingHandshakers:       make(map[*incominghandshaker.IncomingHandshaker]struct{}),
-		outgoingHandshakers:       make(map[*outgoinghandshaker.OutgoingHandshaker]struct{}),
-		incomingHandshakerResultC: make(chan *incominghandshaker.IncomingHandshaker),
-		outgoingHandshakerResultC: make(chan *outgoinghandshaker.OutgoingHandshaker),
-		allocatorProgressC:        make(chan allocator.Progress),
-		allocatorResultC:          make(chan *allocator.Allocator),
-		verifierProgressC:         make(chan verifier.Progress),
-		verifierResultC:           make(chan *verifier.Verifier),
-		connectedPeerIPs:          make(map[string]struct{}),
-		bannedPeerIPs:             make(map[string]struct{}),
-		announcersStoppedC:        make(chan struct{}),
-		dhtPeersC:                 make(chan []*net.TCPAddr, 1),
-		externalIP:                externalip.FirstExternalIP(),
-		downloadSpeed:             metrics.NilMeter{},
-		uploadSpeed:               metrics.NilMeter{},
-		bytesDownloaded:           metrics.NewCounter(),
-		bytesUploaded:             metrics.NewCounter(),
-		bytesWasted:               metrics.NewCounter(),
-		seededFor:                 metrics.NewCounter(),
-		ramNotifyC:                make(chan *peer.Peer),
-		webseedClient:             &s.webseedClient,
-		webseedSources:            ws,
-		webseedPieceResultC:       suspendchan.New[*urldownloader.PieceResult](0),
-		webseedRetryC:             make(chan *webseedsource.WebseedSource),
-		doneC:                     make(chan struct{}),
-		stopAfterDownload:         stopAfterDownload,
-		stopAfterMetadata:         stopAfterMetadata,
-		completeCmdRun:            completeCmdRun,
+		session:                    s,
+		id:                         id,
+		addedAt:                    addedAt,
+		infoHash:                   ih,
+		trackers:                   trackers,
+		fixedPeers:                 fixedPeers,
+		name:                       name,
+		storage:                    sto,
+		port:                       port,
+		info:                       info,
+		bitfield:                   bf,
+		log:                        logger.New("torrent " + id),
+		peerDisconnectedC:          make(chan *peer.Peer),
+		messages:                   make(chan peer.Message),
+		pieceMessagesC:             suspendchan.New[peer.PieceMessage](0),
+		peers:                      make(map[*peer.Peer]struct{}),
+		incomingPeers:              make(map[*peer.Peer]struct{}),
+		outgoingPeers:              make(map[*peer.Peer]struct{}),
+		pieceDownloaders:           make(map[*peer.Peer]*piecedownloader.PieceDownloader),
+		pieceDownloadersSnubbed:    make(map[*peer.Peer]*piecedownloader.PieceDownloader),
+		pieceDownloadersChoked:     make(map[*peer.Peer]*piecedownloader.PieceDownloader),
+		peerSnubbedC:               make(chan *peer.Peer),
+		infoDownloaders:            make(map[*peer.Peer]*infodownloader.InfoDownloader),
+		infoDownloadersSnubbed:     make(map[*peer.Peer]*infodownloader.InfoDownloader),
+		pieceWriterResultC:         make(chan *piecewriter.PieceWriter),
+		completeC:                  make(chan struct{}),
+		completeMetadataC:          make(chan struct{}),
+		closeC:                     make(chan struct{}),
+		startCommandC:              make(chan struct{}),
+		stopCommandC:               make(chan struct{}),
+		announceCommandC:           make(chan struct{}),
+		verifyCommandC:             make(chan struct{}),
+		statsCommandC:              make(chan statsRequest),
+		trackersCommandC:           make(chan trackersRequest),
+		peersCommandC:              make(chan peersRequest),
+		disconnectedPeersCommandC:  make(chan peersRequest),
+		connectionAttemptsCommandC: make(chan connectionAttemptsRequest),
+		connectionResults:          make(map[ConnectionResult]int),
+		webseedsCommandC:           make(chan webseedsRequest),
+		openFileCommandC:           make(chan openFileRequest),
+		cachedPeersCommandC:        make(chan cachedPeersRequest),
+		notifyErrorCommandC:        make(chan notifyErrorCommand),
+		notifyListenCommandC:       make(chan notifyListenCommand),
+		addPeersCommandC:           make(chan []*net.TCPAddr),
+		addTrackersCommandC:        make(chan []tracker.Tracker),
+		lowDiskSpaceCommandC:       make(chan bool),
+		lastHookC:                  make(chan hookResult),
+		bansChangedC:               make(chan struct{}, 1),
+		closeConnectionsC:          make(chan struct{}, 1),
+		addrsFromTrackers:          make(chan []*net.TCPAddr),
+		peerIDs:                    make(map[[20]byte]struct{}),
+		incomingConnC:              make(chan net.Conn),
+		sKeyHash:                   mse.HashSKey(ih[:]),
+		infoDownloaderResultC:      make(chan *infodownloader.InfoDownloader),
+		incomingHandshakers:        make(map[*incominghandshaker.IncomingHandshaker]struct{}),
+		outgoingHandshakers:        make(map[*outgoinghandshaker.OutgoingHandshaker]struct{}),
+		incomingHandshakerResultC:  make(chan *incominghandshaker.IncomingHandshaker),
+		outgoingHandshakerResultC:  make(chan *outgoinghandshaker.OutgoingHandshaker),
+		allocatorProgressC:         make(chan allocator.Progress),
+		allocatorResultC:           make(chan *allocator.Allocator),
+		verifierProgressC:          make(chan verifier.Progress),
+		verifierResultC:            make(chan *verifier.Verifier),
+		connectedPeerIPs:           make(map[string]struct{}),
+		holepunchPending:           make(map[string]holepunchRendezvous),
+		announcersStoppedC:         make(chan struct{}),
+		dhtPeersC:                  make(chan []*net.TCPAddr, 1),
+		externalIP:                 externalip.FirstExternalIP(),
+		downloadSpeed:              metrics.NilMeter{},
+		uploadSpeed:                metrics.NilMeter{},
+		bytesDownloaded:            metrics.NewCounter(),
+		bytesUploaded:              metrics.NewCounter(),
+		bytesWasted:                metrics.NewCounter(),
+		seededFor:                  metrics.NewCounter(),
+		ramNotifyC:                 make(chan *peer.Peer),
+		webseedClient:              &s.webseedClient,
+		webseedSources:             ws,
+		webseedPieceResultC:        suspendchan.New[*urldownloader.PieceResult](0),
+		webseedRetryC:              make(chan *webseedsource.WebseedSource),
+		doneC:                      make(chan struct{}),
+		stopAfterDownload:          stopAfterDownload,
+		stopAfterMetadata:          stopAfterMetadata,
+		completeCmdRun:             completeCmdRun,
 	}
 	if len(t.webseedSources) > s.config.WebseedMaxSources {
 		t.webseedSources = t.webseedSources[:10]
@@ -384,7 +458,16 @@ func newTorrent2(
 	if err != nil {
 		return nil, err
 	}
-	t.unchoker = unchoker.New(cfg.UnchokedPeers, cfg.OptimisticUnchokedPeers)
+	t.chokingAlgorithm = chokingAlgorithm
+	if chokingAlgorithm == "" {
+		chokingAlgorithm = cfg.ChokingAlgorithm
+	}
+	t.unchoker, err = newUnchoker(chokingAlgorithm, cfg.UnchokedPeers, cfg.OptimisticUnchokedPeers)
+	if err != nil {
+		return nil, err
+	}
+	// Do not emit EventRatioReached again for torrents loaded from the resume database.
+	t.lastEventRatio = t.ratioReached()
 	go t.run()
 	return t, nil
 }
@@ -473,6 +556,56 @@ type FileStats struct {
 	BytesCompleted int64
 }
 
+// OpenFile opens the file in storage for reading. The storage and file list are taken from the run loop.
+// The file must exist in storage. It is not created or resized.
+func (t *torrent) OpenFile(path string) (io.ReadSeekCloser, error) {
+	req := openFileRequest{Path: path, Response: make(chan openFileResponse, 1)}
+	var resp openFileResponse
+	select {
+	case t.openFileCommandC <- req:
+	case <-t.closeC:
+		return nil, errClosed
+	}
+	select {
+	case resp = <-req.Response:
+	case <-t.closeC:
+		return nil, errClosed
+	}
+	if resp.Error != nil {
+		return nil, resp.Error
+	}
+	ro, ok := resp.Storage.(storage.ReadOpener)
+	if !ok {
+		return nil, errors.New("storage does not support reading files")
+	}
+	sf, err := ro.OpenRead(path, resp.Length)
+	if err != nil {
+		return nil, err
+	}
+	return fileReader{io.NewSectionReader(sf, 0, resp.Length), sf}, nil
+}
+
+// getFileToOpen is called in run loop to find the file for OpenFile.
+func (t *torrent) getFileToOpen(path string) openFileResponse {
+	if t.info == nil {
+		return openFileResponse{Error: errors.New("torrent metadata not ready")}
+	}
+	if is, ok := t.storage.(storage.InfoSetter); ok {
+		is.SetInfo(t.info)
+	}
+	for _, f := range t.info.Files {
+		if !f.Padding && f.Path == path {
+			return openFileResponse{Storage: t.storage, Length: f.Length}
+		}
+	}
+	return openFileResponse{Error: errors.New("file not found in torrent: " + path)}
+}
+
+type fileReader struct {
+	*io.SectionReader
+	io.Closer
+}
+
 type File struct {
 	path   string
 	length int64
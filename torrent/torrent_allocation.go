@@ -16,7 +16,7 @@ func (t *torrent) handleAllocationDone(al *allocator.Allocator) {
 	t.allocator = nil
 
 	if al.Error != nil {
-		t.stop(fmt.Errorf("file allocation error: %s", al.Error))
+		t.stop(&AllocationError{err: al.Error})
 		return
 	}
 
@@ -57,8 +57,10 @@ func (t *torrent) handleAllocationDone(al *allocator.Allocator) {
 			t.stopAndSetStoppedOnComplete()
 			return
 		}
+		t.startSuperSeeding()
 		t.processQueuedMessages()
 		t.addFixedPeers()
+		t.addCachedPeers()
 		t.startAcceptor()
 		t.startAnnouncers()
 		t.startPieceDownloaders()
@@ -72,6 +74,7 @@ func (t *torrent) handleAllocationDone(al *allocator.Allocator) {
 		t.mBitfield.Unlock()
 		t.processQueuedMessages()
 		t.addFixedPeers()
+		t.addCachedPeers()
 		t.startAcceptor()
 		t.startAnnouncers()
 		t.startPieceDownloaders()
@@ -23,6 +23,7 @@ func (t *torrent) announcerFields() tracker.Torrent {
 		Port:            t.port,
 		BytesDownloaded: t.bytesDownloaded.Count(),
 		BytesUploaded:   t.bytesUploaded.Count(),
+		PartialSeed:     t.partialSeed.Load(),
 	}
 	// t.bytesComplete() uses t.bitfied for calculation.
 	t.mBitfield.RLock()
@@ -0,0 +1,18 @@
+package torrent
+
+// notifyBansChanged wakes up the event loop to disconnect newly banned peers.
+func (t *torrent) notifyBansChanged() {
+	select {
+	case t.bansChangedC <- struct{}{}:
+	default:
+	}
+}
+
+func (t *torrent) closeBannedPeers() {
+	for pe := range t.peers {
+		if t.session.isPeerBanned(pe.IP(), pe.ID) {
+			pe.Logger().Infoln("disconnecting banned peer")
+			t.closePeer(pe, closeMisbehaved, "banned")
+		}
+	}
+}
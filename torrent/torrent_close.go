@@ -2,6 +2,9 @@ package torrent
 
 import (
 	"errors"
+	"io"
+	"os"
+	"time"
 
 	"github.com/cenkalti/rain/internal/infodownloader"
 	"github.com/cenkalti/rain/internal/peer"
@@ -11,6 +14,9 @@ import (
 
 var errClosed = errors.New("torrent is closed")
 
+// Number of peers kept in the recently disconnected peers list of a torrent.
+const maxDisconnectedPeers = 50
+
 func (t *torrent) close() {
 	// Stop if running.
 	t.stop(errClosed)
@@ -24,10 +30,27 @@ func (t *torrent) close() {
 	t.uploadSpeed.Stop()
 }
 
-func (t *torrent) closePeer(pe *peer.Peer) {
+// closeKind tells why a peer connection is closed. The reason text is only for display.
+type closeKind int
+
+const (
+	// closeClean is used when the peer or we end the connection normally.
+	closeClean closeKind = iota
+	// closeStopped is used when the connection is closed because the torrent is stopped.
+	closeStopped
+	// closeError is used for connection errors that are not the fault of the peer.
+	closeError
+	// closeMisbehaved is used when the peer violates the protocol, sends bad data or is banned.
+	closeMisbehaved
+)
+
+// closePeer closes the connection to the peer and records it in the recently disconnected peers list with the reason.
+func (t *torrent) closePeer(pe *peer.Peer, kind closeKind, reason string) {
 	if pe.Closed {
 		return
 	}
+	t.addDisconnectedPeer(pe, reason)
+	t.updatePeerCache(pe, kind)
 	pe.Close()
 	pe.Closed = true
 	if pd, ok := t.pieceDownloaders[pe]; ok {
@@ -45,11 +68,38 @@ func (t *torrent) closePeer(pe *peer.Peer) {
 		t.piecePicker.HandleDisconnect(pe)
 	}
 	t.unchoker.HandleDisconnect(pe)
+	if t.superSeeder != nil {
+		delete(t.superSeeder.peers, pe)
+	}
 	t.pexDropPeer(pe.Addr())
 	t.dialAddresses()
 	t.session.metrics.Peers.Dec(1)
 }
 
+func (t *torrent) addDisconnectedPeer(pe *peer.Peer, reason string) {
+	p := t.newPeer(pe)
+	p.DisconnectedAt = time.Now()
+	p.DisconnectReason = reason
+	if len(t.disconnectedPeers) >= maxDisconnectedPeers {
+		t.disconnectedPeers = append(t.disconnectedPeers[:0], t.disconnectedPeers[1:]...)
+	}
+	t.disconnectedPeers = append(t.disconnectedPeers, p)
+}
+
+// connectionClosedReason returns the disconnect kind and reason for a peer connection that is closed with err.
+func connectionClosedReason(err error) (closeKind, string) {
+	switch {
+	case err == nil, errors.Is(err, io.EOF):
+		return closeClean, "connection closed by peer"
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return closeError, "connection closed by peer unexpectedly"
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return closeError, "read timeout"
+	default:
+		return closeError, err.Error()
+	}
+}
+
 func (t *torrent) closeWebseedDownloader(src *webseedsource.WebseedSource) {
 	t.piecePicker.CloseWebseedDownloader(src)
 }
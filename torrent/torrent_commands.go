@@ -7,6 +7,7 @@ import (
 
 	"github.com/cenkalti/rain/internal/magnet"
 	"github.com/cenkalti/rain/internal/metainfo"
+	"github.com/cenkalti/rain/internal/storage"
 	"github.com/cenkalti/rain/internal/tracker"
 )
 
@@ -238,6 +239,26 @@ type Peer struct {
 	EncryptedStream    bool
 	DownloadSpeed      int
 	UploadSpeed        int
+	// Number of piece bytes received from the peer.
+	BytesDownloaded int64
+	// Number of piece bytes sent to the peer.
+	BytesUploaded int64
+	// Requests received from the peer that are waiting to be served.
+	RequestsIn int
+	// Requests sent to the peer that are waiting for a response.
+	RequestsOut int
+	// Percentage of the pieces that the peer has. Zero if the bitfield of the peer is not known yet.
+	Completion int
+	// Time of the last message (including keep-alive) received from the peer.
+	LastMessageAt time.Time
+	// Number of outstanding requests that the peer accepts ("reqq" in extension handshake).
+	RequestQueue int
+	// Names of the extensions that the peer supports (e.g. "ut_metadata", "ut_pex").
+	Extensions []string
+	// Time when the peer is disconnected. Zero for connected peers.
+	DisconnectedAt time.Time
+	// Reason of the disconnect. Empty for connected peers.
+	DisconnectReason string
 }
 
 // PeerSource indicates that how the peer is found.
@@ -254,6 +275,10 @@ const (
 	SourceIncoming
 	// SourceManual indicates that the peer is added manually via AddPeer method.
 	SourceManual
+	// SourceCache indicates that the peer is loaded from the cache of peers that we have connected before.
+	SourceCache
+	// SourceHolepunch indicates that the peer is connected with the help of another peer that relayed the connection request.
+	SourceHolepunch
 )
 
 type peersRequest struct {
@@ -274,6 +299,20 @@ func (t *torrent) Peers() []Peer {
 	return peers
 }
 
+func (t *torrent) DisconnectedPeers() []Peer {
+	var peers []Peer
+	req := peersRequest{Response: make(chan []Peer, 1)}
+	select {
+	case t.disconnectedPeersCommandC <- req:
+	case <-t.closeC:
+	}
+	select {
+	case peers = <-req.Response:
+	case <-t.closeC:
+	}
+	return peers
+}
+
 // Webseed is a HTTP source defined in Torrent.
 // Client can download from these sources along with peers from the swarm.
 type Webseed struct {
@@ -282,6 +321,17 @@ type Webseed struct {
 	DownloadSpeed int
 }
 
+type openFileRequest struct {
+	Path     string
+	Response chan openFileResponse
+}
+
+type openFileResponse struct {
+	Storage storage.Storage
+	Length  int64
+	Error   error
+}
+
 type webseedsRequest struct {
 	Response chan []Webseed
 }
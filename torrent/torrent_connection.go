@@ -2,13 +2,18 @@ package torrent
 
 import (
 	"net"
+	"sort"
 
 	"github.com/cenkalti/rain/internal/handshaker/incominghandshaker"
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peersource"
 )
 
 func (t *torrent) handleNewConnection(conn net.Conn) {
-	if len(t.incomingHandshakers)+len(t.incomingPeers) >= t.session.config.MaxPeerAccept {
+	numIncoming := len(t.incomingHandshakers) + len(t.incomingPeers)
+	if numIncoming >= t.session.config.MaxPeerAccept || !t.sourceAllowed(peersource.Incoming, numIncoming) || !t.connectionAllowed() {
 		t.log.Debugln("peer limit reached, rejecting peer", conn.RemoteAddr().String())
+		t.addConnectionAttempt(conn.RemoteAddr(), peersource.Incoming, ConnectionPeerLimit, nil)
 		conn.Close()
 		return
 	}
@@ -16,16 +21,19 @@ func (t *torrent) handleNewConnection(conn net.Conn) {
 	ipstr := ip.String()
 	if t.session.config.BlocklistEnabledForIncomingConnections && t.session.blocklist != nil && t.session.blocklist.Blocked(ip) {
 		t.log.Debugln("peer is blocked:", conn.RemoteAddr().String())
+		t.addConnectionAttempt(conn.RemoteAddr(), peersource.Incoming, ConnectionBlocklisted, nil)
 		conn.Close()
 		return
 	}
 	if _, ok := t.connectedPeerIPs[ipstr]; ok {
 		t.log.Debugln("received duplicate connection from same IP: ", ipstr)
+		t.addConnectionAttempt(conn.RemoteAddr(), peersource.Incoming, ConnectionDuplicate, nil)
 		conn.Close()
 		return
 	}
-	if _, ok := t.bannedPeerIPs[ipstr]; ok {
+	if t.session.isIPBannedInTorrent(t, ipstr) {
 		t.log.Debugln("connection attempt from banned IP: ", ipstr)
+		t.addConnectionAttempt(conn.RemoteAddr(), peersource.Incoming, ConnectionBanned, nil)
 		conn.Close()
 		return
 	}
@@ -42,3 +50,136 @@ func (t *torrent) handleNewConnection(conn net.Conn) {
 		t.session.config.ForceIncomingEncryption,
 	)
 }
+
+// dialLimitReached returns true if a new outgoing connection is not allowed by Config.MaxPeerDial,
+// Config.MaxConnectionsPerTorrent or Config.MaxConnections.
+func (t *torrent) dialLimitReached() bool {
+	return len(t.outgoingPeers)+len(t.outgoingHandshakers) >= t.session.config.MaxPeerDial || !t.connectionAllowed()
+}
+
+// connectionAllowed returns true if a new connection of the torrent does not exceed
+// Config.MaxConnectionsPerTorrent and Config.MaxConnections.
+func (t *torrent) connectionAllowed() bool {
+	if limit := t.session.config.MaxConnectionsPerTorrent; limit > 0 && t.numConnections() >= limit {
+		return false
+	}
+	if t.session.config.MaxConnections <= 0 {
+		return true
+	}
+	t.reportConnections()
+	return t.session.connectionAllowed(t)
+}
+
+// numConnections returns the number of connected peers and handshakes in progress.
+func (t *torrent) numConnections() int {
+	return len(t.peers) + len(t.incomingHandshakers) + len(t.outgoingHandshakers)
+}
+
+// reportConnections updates the number of connections of the torrent in the session.
+func (t *torrent) reportConnections() {
+	status := t.status()
+	running := status != Stopped && status != Stopping
+	var n int
+	if running {
+		n = t.numConnections()
+	}
+	if running == t.reportedRunning && n == t.reportedConnections {
+		return
+	}
+	t.session.mConnections.Lock()
+	if running {
+		t.session.torrentConnections[t] = n
+	} else {
+		delete(t.session.torrentConnections, t)
+		delete(t.session.connectionsToClose, t)
+	}
+	t.session.mConnections.Unlock()
+	t.reportedRunning = running
+	t.reportedConnections = n
+}
+
+// closeConnectionsForOthers closes the connections that the session has taken from the torrent for other torrents.
+// Peers that we download from slowest are closed first.
+func (t *torrent) closeConnectionsForOthers() {
+	t.session.mConnections.Lock()
+	n := t.session.connectionsToClose[t]
+	delete(t.session.connectionsToClose, t)
+	t.session.mConnections.Unlock()
+	if n <= 0 {
+		return
+	}
+	peers := make([]*peer.Peer, 0, len(t.peers))
+	for pe := range t.peers {
+		peers = append(peers, pe)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].DownloadSpeed() < peers[j].DownloadSpeed() })
+	for _, pe := range peers[:min(n, len(peers))] {
+		t.closePeer(pe, closeClean, "connection given to another torrent")
+	}
+}
+
+// connectionAllowed returns true if torrent t can open one more connection within Config.MaxConnections.
+// Each running torrent has an equal share of the limit. The part of the shares that torrents do not use is reserved for them,
+// so a torrent can use up to the limit minus the unused shares of the other torrents.
+// If the limit is reached while t is below its share, a connection of the torrent that is furthest over its share is closed.
+func (s *Session) connectionAllowed(t *torrent) bool {
+	limit := s.config.MaxConnections
+	s.mConnections.Lock()
+	defer s.mConnections.Unlock()
+	share := max(limit/max(len(s.torrentConnections), 1), 1)
+	own := s.torrentConnections[t]
+	var total, reserved int
+	for t2, n := range s.torrentConnections {
+		total += n
+		if t2 != t {
+			reserved += max(share-n, 0)
+		}
+	}
+	if own >= limit-reserved {
+		return false
+	}
+	if total < limit {
+		return true
+	}
+	if own >= share {
+		return false
+	}
+	var victim *torrent
+	var over int
+	for t2, n := range s.torrentConnections {
+		if n-share > over {
+			victim, over = t2, n-share
+		}
+	}
+	if victim == nil {
+		return false
+	}
+	// Count the connection as closed now, so the slot is not given to more than one torrent.
+	// The victim reports the real number after it closes the connection.
+	s.torrentConnections[victim]--
+	s.connectionsToClose[victim]++
+	select {
+	case victim.closeConnectionsC <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// sourceAllowed returns true if a new connection to a peer from src does not exceed Config.MaxPeersPerSource.
+// count is the number of current connections to the peers from src.
+func (t *torrent) sourceAllowed(src peersource.Source, count int) bool {
+	limit, ok := t.session.maxPeersBySource[src]
+	return !ok || count < limit
+}
+
+// connectionsBySource returns the number of connected peers and outgoing handshakes for each peer source.
+func (t *torrent) connectionsBySource() map[peersource.Source]int {
+	m := make(map[peersource.Source]int)
+	for pe := range t.peers {
+		m[pe.Source]++
+	}
+	for h := range t.outgoingHandshakers {
+		m[h.Source]++
+	}
+	return m
+}
@@ -0,0 +1,164 @@
+package torrent
+
+import (
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peersource"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaxPeersPerSourceIncoming(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.maxPeersBySource = map[peersource.Source]int{peersource.Incoming: 1}
+	tor, port := startSeeding(t, s, nil)
+
+	conn := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 2), [20]byte{1})
+	defer conn.Close()
+	assertConnectionRejected(t, tor, port, net.IPv4(127, 0, 0, 3))
+}
+
+func TestMaxConnections(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.MaxConnections = 1
+	tor, port := startSeeding(t, s, nil)
+
+	conn := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 2), [20]byte{1})
+	defer conn.Close()
+	assertConnectionRejected(t, tor, port, net.IPv4(127, 0, 0, 3))
+	assert.Equal(t, map[*torrent]int{tor.torrent: 1}, connectionsByTorrent(s))
+
+	tor.Stop()
+	assert.Eventually(t, func() bool {
+		return len(connectionsByTorrent(s)) == 0
+	}, timeout, 10*time.Millisecond)
+}
+
+func TestMaxConnectionsPerTorrent(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.MaxConnectionsPerTorrent = 1
+	tor, port := startSeeding(t, s, nil)
+
+	conn := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 2), [20]byte{1})
+	defer conn.Close()
+	assertConnectionRejected(t, tor, port, net.IPv4(127, 0, 0, 3))
+}
+
+func TestMaxConnectionsShared(t *testing.T) {
+	s := &Session{config: DefaultConfig, torrentConnections: make(map[*torrent]int), connectionsToClose: make(map[*torrent]int)}
+	s.config.MaxConnections = 8
+	busy := &torrent{}
+	idle := []*torrent{{}, {}, {}}
+	s.torrentConnections[busy] = 0
+	for _, t2 := range idle {
+		s.torrentConnections[t2] = 0
+	}
+
+	// Unused shares of idle torrents are reserved for them.
+	for i := 0; i < 2; i++ {
+		require.True(t, s.connectionAllowed(busy), "connection %d", i)
+		s.torrentConnections[busy]++
+	}
+	assert.False(t, s.connectionAllowed(busy))
+	assert.True(t, s.connectionAllowed(idle[0]))
+
+	// Busy torrent can use the shares of the torrents that are stopped.
+	for _, t2 := range idle {
+		delete(s.torrentConnections, t2)
+	}
+	for i := 2; i < 8; i++ {
+		require.True(t, s.connectionAllowed(busy), "connection %d", i)
+		s.torrentConnections[busy]++
+	}
+	assert.False(t, s.connectionAllowed(busy))
+
+	// A torrent that is started later gets a slot from the busy torrent.
+	s.torrentConnections[idle[0]] = 0
+	assert.True(t, s.connectionAllowed(idle[0]))
+	assert.Equal(t, 7, s.torrentConnections[busy])
+	assert.Equal(t, map[*torrent]int{busy: 1}, s.connectionsToClose)
+	s.torrentConnections[idle[0]] = 1
+	assert.False(t, s.connectionAllowed(busy))
+
+	// The torrent that is furthest over its share gives the slot.
+	s.torrentConnections[busy] = 5
+	s.torrentConnections[idle[0]] = 0
+	s.torrentConnections[idle[1]] = 3
+	clear(s.connectionsToClose)
+	assert.True(t, s.connectionAllowed(idle[0]))
+	assert.Equal(t, map[*torrent]int{busy: 1}, s.connectionsToClose)
+
+	// No slot is taken for a torrent that is at its share.
+	s.torrentConnections[busy] = 4
+	s.torrentConnections[idle[0]] = 2
+	s.torrentConnections[idle[1]] = 2
+	assert.False(t, s.connectionAllowed(idle[0]))
+}
+
+func TestMaxConnectionsCloseForOthers(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.MaxConnections = 2
+	seed, port := startSeeding(t, s, nil)
+	conn1 := dialRawPeer(t, s, seed, port, net.IPv4(127, 0, 0, 2), [20]byte{1})
+	defer conn1.Close()
+	conn2 := dialRawPeer(t, s, seed, port, net.IPv4(127, 0, 0, 3), [20]byte{2})
+	defer conn2.Close()
+	assert.Eventually(t, func() bool { return len(seed.Peers()) == 2 }, timeout, 10*time.Millisecond)
+
+	// Another torrent dials a peer while the seeding torrent has all connections.
+	l, err := net.Listen("tcp4", "127.0.0.5:0")
+	require.NoError(t, err)
+	defer l.Close()
+	_, err = s.AddURI("magnet:?xt=urn:btih:0000000000000000000000000000000000000001&x.pe="+l.Addr().String(), nil)
+	require.NoError(t, err)
+	c, err := l.Accept()
+	require.NoError(t, err)
+	c.Close()
+
+	assert.Eventually(t, func() bool { return len(seed.Peers()) == 1 }, timeout, 10*time.Millisecond)
+	peers := seed.DisconnectedPeers()
+	require.Len(t, peers, 1)
+	assert.Equal(t, "connection given to another torrent", peers[0].DisconnectReason)
+}
+
+func connectionsByTorrent(s *Session) map[*torrent]int {
+	s.mConnections.Lock()
+	defer s.mConnections.Unlock()
+	m := make(map[*torrent]int, len(s.torrentConnections))
+	for t, n := range s.torrentConnections {
+		m[t] = n
+	}
+	return m
+}
+
+func TestParseMaxPeersPerSource(t *testing.T) {
+	m, err := parseMaxPeersPerSource(map[string]int{"pex": 10, "incoming": 0})
+	require.NoError(t, err)
+	assert.Equal(t, map[peersource.Source]int{peersource.PEX: 10, peersource.Incoming: 0}, m)
+
+	_, err = parseMaxPeersPerSource(map[string]int{"foo": 10})
+	assert.Error(t, err)
+	_, err = parseMaxPeersPerSource(map[string]int{"pex": -1})
+	assert.Error(t, err)
+}
+
+// assertConnectionRejected connects to the torrent from localIP and checks that the connection is closed because of the peer limit.
+func assertConnectionRejected(t *testing.T, tor *Torrent, port int, localIP net.IP) {
+	dialer := net.Dialer{Timeout: timeout, LocalAddr: &net.TCPAddr{IP: localIP}}
+	conn, err := dialer.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	require.NoError(t, err)
+	defer conn.Close()
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(timeout)))
+	_, _ = io.Copy(io.Discard, conn)
+	stats := tor.Stats()
+	assert.Equal(t, 1, stats.ConnectionAttempts.PeerLimit)
+	assert.Equal(t, 1, stats.Peers.Incoming)
+}
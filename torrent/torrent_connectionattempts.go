@@ -0,0 +1,139 @@
+package torrent
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/cenkalti/rain/internal/btconn"
+	"github.com/cenkalti/rain/internal/peersource"
+)
+
+// Number of connection attempts kept in the recent connection attempts list of a torrent.
+const maxConnectionAttempts = 100
+
+// ConnectionAttempt is an outgoing connection to a peer address or an incoming connection from a peer.
+type ConnectionAttempt struct {
+	Addr   net.Addr
+	Source PeerSource
+	Time   time.Time
+	Result ConnectionResult
+	// Error that caused the attempt to fail. Nil if the connection is rejected by us.
+	Error error
+}
+
+// ConnectionResult is the outcome of a ConnectionAttempt.
+type ConnectionResult int
+
+const (
+	// ConnectionSucceeded indicates that the protocol handshake is completed and the peer is connected.
+	ConnectionSucceeded ConnectionResult = iota
+	// ConnectionBlocklisted indicates that the IP address is in the blocklist.
+	ConnectionBlocklisted
+	// ConnectionBanned indicates that the IP address or the peer ID is banned.
+	ConnectionBanned
+	// ConnectionPeerLimit indicates that the incoming connection is rejected because Config.MaxPeerAccept,
+	// Config.MaxConnections or Config.MaxPeersPerSource is reached.
+	ConnectionPeerLimit
+	// ConnectionDuplicate indicates that we are already connected to the IP address or the peer ID.
+	ConnectionDuplicate
+	// ConnectionDialFailed indicates that the TCP connection could not be established.
+	ConnectionDialFailed
+	// ConnectionTimeout indicates that the connection or the handshake is not completed in time.
+	ConnectionTimeout
+	// ConnectionClosed indicates that the peer has closed the connection during handshake.
+	ConnectionClosed
+	// ConnectionEncryptionFailed indicates that the encryption handshake has failed or the peer does not support encryption while it is forced.
+	ConnectionEncryptionFailed
+	// ConnectionInfoHashMismatch indicates that the peer is not in the swarm of the torrent.
+	ConnectionInfoHashMismatch
+	// ConnectionProtocolError indicates that the peer has sent an invalid handshake.
+	ConnectionProtocolError
+	// ConnectionError indicates that the connection is failed with an unclassified error.
+	ConnectionError
+)
+
+func (r ConnectionResult) String() string {
+	m := map[ConnectionResult]string{
+		ConnectionSucceeded:        "Succeeded",
+		ConnectionBlocklisted:      "Blocklisted",
+		ConnectionBanned:           "Banned",
+		ConnectionPeerLimit:        "Peer limit",
+		ConnectionDuplicate:        "Duplicate",
+		ConnectionDialFailed:       "Dial failed",
+		ConnectionTimeout:          "Timeout",
+		ConnectionClosed:           "Closed",
+		ConnectionEncryptionFailed: "Encryption failed",
+		ConnectionInfoHashMismatch: "Info hash mismatch",
+		ConnectionProtocolError:    "Protocol error",
+		ConnectionError:            "Error",
+	}
+	return m[r]
+}
+
+// connectionResult classifies the error returned from a handshaker.
+func connectionResult(err error) ConnectionResult {
+	var opErr *net.OpError
+	var netErr net.Error
+	var handshakeErr *btconn.HandshakeError
+	var encryptionErr *btconn.EncryptionError
+	switch {
+	case err == nil:
+		return ConnectionSucceeded
+	case errors.Is(err, os.ErrDeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return ConnectionTimeout
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNRESET):
+		return ConnectionClosed
+	case errors.Is(err, btconn.ErrInvalidInfoHash):
+		return ConnectionInfoHashMismatch
+	case errors.Is(err, btconn.ErrNotEncrypted), errors.As(err, &encryptionErr):
+		return ConnectionEncryptionFailed
+	case errors.As(err, &handshakeErr):
+		return ConnectionProtocolError
+	case errors.As(err, &opErr) && opErr.Op == "dial":
+		return ConnectionDialFailed
+	default:
+		return ConnectionError
+	}
+}
+
+func (t *torrent) addConnectionAttempt(addr net.Addr, source peersource.Source, result ConnectionResult, err error) {
+	t.connectionResults[result]++
+	if len(t.connectionAttempts) >= maxConnectionAttempts {
+		t.connectionAttempts = append(t.connectionAttempts[:0], t.connectionAttempts[1:]...)
+	}
+	t.connectionAttempts = append(t.connectionAttempts, ConnectionAttempt{
+		Addr:   addr,
+		Source: t.peerSource(source),
+		Time:   time.Now(),
+		Result: result,
+		Error:  err,
+	})
+}
+
+func (t *torrent) getConnectionAttempts() []ConnectionAttempt {
+	attempts := make([]ConnectionAttempt, len(t.connectionAttempts))
+	copy(attempts, t.connectionAttempts)
+	return attempts
+}
+
+type connectionAttemptsRequest struct {
+	Response chan []ConnectionAttempt
+}
+
+func (t *torrent) ConnectionAttempts() []ConnectionAttempt {
+	var attempts []ConnectionAttempt
+	req := connectionAttemptsRequest{Response: make(chan []ConnectionAttempt, 1)}
+	select {
+	case t.connectionAttemptsCommandC <- req:
+	case <-t.closeC:
+	}
+	select {
+	case attempts = <-req.Response:
+	case <-t.closeC:
+	}
+	return attempts
+}
@@ -0,0 +1,78 @@
+package torrent
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/btconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnectionResult(t *testing.T) {
+	cases := []struct {
+		err    error
+		result ConnectionResult
+	}{
+		{nil, ConnectionSucceeded},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, ConnectionDialFailed},
+		{&net.OpError{Op: "read", Err: os.ErrDeadlineExceeded}, ConnectionTimeout},
+		{io.EOF, ConnectionClosed},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), ConnectionClosed},
+		{btconn.ErrInvalidInfoHash, ConnectionInfoHashMismatch},
+		{btconn.ErrNotEncrypted, ConnectionEncryptionFailed},
+		{&btconn.EncryptionError{Err: errors.New("invalid SKEY hash")}, ConnectionEncryptionFailed},
+		{btconn.ErrOwnConnection, ConnectionProtocolError},
+		{errors.New("foo"), ConnectionError},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.result, connectionResult(c.err), "%v", c.err)
+	}
+}
+
+func TestConnectionAttempts(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	// Nothing is listening on this address.
+	l, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := l.Addr().String()
+	l.Close()
+
+	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+closedAddr+"&x.pe="+addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCompleted(t, tor)
+
+	var attempts []ConnectionAttempt
+	deadline := time.Now().Add(timeout)
+	for len(attempts) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("connection attempts are not recorded")
+		}
+		time.Sleep(10 * time.Millisecond)
+		attempts = tor.ConnectionAttempts()
+	}
+	results := make(map[string]ConnectionResult)
+	for _, a := range attempts {
+		assert.Equal(t, SourceManual, a.Source)
+		results[a.Addr.String()] = a.Result
+	}
+	assert.Equal(t, ConnectionSucceeded, results[addr])
+	assert.Equal(t, ConnectionDialFailed, results[closedAddr])
+
+	stats := tor.Stats()
+	assert.Equal(t, 1, stats.ConnectionAttempts.Succeeded)
+	// Address may be dialed again after the metadata is downloaded.
+	assert.GreaterOrEqual(t, stats.ConnectionAttempts.DialFailed, 1)
+}
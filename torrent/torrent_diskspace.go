@@ -0,0 +1,34 @@
+package torrent
+
+// SetLowDiskSpace pauses or resumes downloading depending on the free space on the disk.
+func (t *torrent) SetLowDiskSpace(low bool) {
+	select {
+	case t.lowDiskSpaceCommandC <- low:
+	case <-t.closeC:
+	}
+}
+
+func (t *torrent) handleLowDiskSpace(low bool) {
+	if low == t.lowDiskSpace {
+		return
+	}
+	t.lowDiskSpace = low
+	t.updateUploadOnly()
+	if low {
+		// Running piece downloads are not cancelled, they are written when finished.
+		t.log.Warning("free disk space is low, pausing downloads")
+		return
+	}
+	t.log.Info("free disk space is enough, resuming downloads")
+	if s := t.status(); s == Stopped || s == Stopping || t.info == nil {
+		return
+	}
+	if t.pieces == nil {
+		// Allocation is delayed in startAllocator because of low space.
+		if t.allocator == nil && t.verifier == nil {
+			t.startAllocator()
+		}
+		return
+	}
+	t.startPieceDownloaders()
+}
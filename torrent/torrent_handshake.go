@@ -22,6 +22,7 @@ func (t *torrent) checkInfoHash(infoHash [20]byte) bool {
 func (t *torrent) handleIncomingHandshakeDone(ih *incominghandshaker.IncomingHandshaker) {
 	delete(t.incomingHandshakers, ih)
 	if ih.Error != nil {
+		t.addConnectionAttempt(ih.Conn.RemoteAddr(), peersource.Incoming, connectionResult(ih.Error), ih.Error)
 		delete(t.connectedPeerIPs, ih.Conn.RemoteAddr().(*net.TCPAddr).IP.String())
 		return
 	}
@@ -31,6 +32,7 @@ func (t *torrent) handleIncomingHandshakeDone(ih *incominghandshaker.IncomingHan
 func (t *torrent) handleOutgoingHandshakeDone(oh *outgoinghandshaker.OutgoingHandshaker) {
 	delete(t.outgoingHandshakers, oh)
 	if oh.Error != nil {
+		t.addConnectionAttempt(oh.Addr, oh.Source, connectionResult(oh.Error), oh.Error)
 		delete(t.connectedPeerIPs, oh.Addr.IP.String())
 		t.dialAddresses()
 		return
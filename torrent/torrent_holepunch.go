@@ -0,0 +1,162 @@
+package torrent
+
+import (
+	"net"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/cenkalti/rain/internal/peersource"
+)
+
+// holepunchTimeout is the duration that we wait for a connect message after sending a rendezvous message.
+const holepunchTimeout = time.Minute
+
+// holepunchRendezvous is a rendezvous message that we have sent to a relay.
+type holepunchRendezvous struct {
+	relay   *peer.Peer
+	expires time.Time
+}
+
+// handlePEXPeers is called with the added peers in a PEX message.
+// Peers that do not accept incoming connections are connected with the help of the peer that sent the message (BEP 55).
+func (t *torrent) handlePEXPeers(pe *peer.Peer, addrs []*net.TCPAddr, flags string) {
+	if len(flags) != len(addrs) || !supportsHolepunch(pe) {
+		t.handleNewPeers(addrs, peersource.PEX)
+		return
+	}
+	reachable := make([]*net.TCPAddr, 0, len(addrs))
+	var unreachable []*net.TCPAddr
+	for i, addr := range addrs {
+		f := flags[i]
+		if f&peerprotocol.PEXFlagHolepunch != 0 && f&peerprotocol.PEXFlagReachable == 0 {
+			unreachable = append(unreachable, addr)
+		} else {
+			reachable = append(reachable, addr)
+		}
+	}
+	t.handleNewPeers(reachable, peersource.PEX)
+	for _, addr := range unreachable {
+		t.startHolepunch(pe, addr)
+	}
+}
+
+// startHolepunch asks the relay peer to make us and the peer at addr connect to each other at the same time.
+func (t *torrent) startHolepunch(relay *peer.Peer, addr *net.TCPAddr) {
+	if t.completed || !t.canDialHolepunch(addr) {
+		return
+	}
+	now := time.Now()
+	for k, r := range t.holepunchPending {
+		if now.After(r.expires) {
+			delete(t.holepunchPending, k)
+		}
+	}
+	relay.Logger().Debugln("sending holepunch rendezvous for", addr)
+	t.holepunchPending[addr.String()] = holepunchRendezvous{relay: relay, expires: now.Add(holepunchTimeout)}
+	t.sendHolepunchMessage(relay, peerprotocol.HolepunchRendezvous, addr, 0)
+}
+
+// holepunchRequested returns true if we have sent a rendezvous message to the relay for addr and it is not expired.
+// A connect message is only valid as a response to our rendezvous, otherwise peers could make us dial any address.
+func (t *torrent) holepunchRequested(relay *peer.Peer, addr *net.TCPAddr) bool {
+	key := addr.String()
+	r, ok := t.holepunchPending[key]
+	if !ok || r.relay != relay {
+		return false
+	}
+	delete(t.holepunchPending, key)
+	return time.Now().Before(r.expires)
+}
+
+func (t *torrent) handleHolepunchMessage(pe *peer.Peer, msg peerprotocol.ExtensionHolepunchMessage) {
+	switch msg.Type {
+	case peerprotocol.HolepunchRendezvous:
+		t.handleHolepunchRendezvous(pe, msg.Addr)
+	case peerprotocol.HolepunchConnect:
+		pe.Logger().Debugln("received holepunch connect for", msg.Addr)
+		if !t.holepunchRequested(pe, msg.Addr) {
+			pe.Logger().Debugln("holepunch connect is not requested for", msg.Addr)
+			break
+		}
+		if t.canDialHolepunch(msg.Addr) {
+			// The other end is dialing us at the same time. NAT devices on both sides
+			// see outgoing packets and let the incoming packets of the other end pass.
+			t.dialAddr(msg.Addr, peersource.Holepunch)
+		}
+	case peerprotocol.HolepunchError:
+		pe.Logger().Debugf("holepunch error for %s: %s", msg.Addr, peerprotocol.HolepunchErrorString(msg.Error))
+	default:
+		pe.Logger().Debugln("unknown holepunch message type:", msg.Type)
+	}
+}
+
+// handleHolepunchRendezvous is called when we act as a relay between the peer and the target.
+func (t *torrent) handleHolepunchRendezvous(pe *peer.Peer, target *net.TCPAddr) {
+	if !supportsHolepunch(pe) {
+		// Cannot send a response to the peer.
+		return
+	}
+	if target.IP.Equal(pe.Addr().IP) && target.Port == pe.Addr().Port {
+		t.sendHolepunchMessage(pe, peerprotocol.HolepunchError, target, peerprotocol.HolepunchErrNoSelf)
+		return
+	}
+	var targetPeer *peer.Peer
+	for pe2 := range t.peers {
+		if target.IP.Equal(pe2.Addr().IP) && target.Port == pe2.Addr().Port {
+			targetPeer = pe2
+			break
+		}
+	}
+	if targetPeer == nil {
+		t.sendHolepunchMessage(pe, peerprotocol.HolepunchError, target, peerprotocol.HolepunchErrNotConnected)
+		return
+	}
+	if !supportsHolepunch(targetPeer) {
+		t.sendHolepunchMessage(pe, peerprotocol.HolepunchError, target, peerprotocol.HolepunchErrNoSupport)
+		return
+	}
+	pe.Logger().Debugln("relaying holepunch connect to", target)
+	t.sendHolepunchMessage(targetPeer, peerprotocol.HolepunchConnect, pe.Addr(), 0)
+	t.sendHolepunchMessage(pe, peerprotocol.HolepunchConnect, target, 0)
+}
+
+// canDialHolepunch returns true if a new connection can be made to addr.
+func (t *torrent) canDialHolepunch(addr *net.TCPAddr) bool {
+	if status := t.status(); status == Stopped || status == Stopping {
+		return false
+	}
+	ip := addr.IP.String()
+	if _, ok := t.connectedPeerIPs[ip]; ok {
+		return false
+	}
+	if t.session.isIPBannedInTorrent(t, ip) {
+		return false
+	}
+	// Holepunch addresses do not go through the address list, so the blocklist is checked here.
+	if t.session.config.BlocklistEnabledForOutgoingConnections && t.session.blocklist.Blocked(addr.IP) {
+		t.addConnectionAttempt(addr, peersource.Holepunch, ConnectionBlocklisted, nil)
+		return false
+	}
+	return !t.dialLimitReached() && t.sourceAllowed(peersource.Holepunch, t.connectionsBySource()[peersource.Holepunch])
+}
+
+func (t *torrent) sendHolepunchMessage(pe *peer.Peer, msgType uint8, addr *net.TCPAddr, errCode uint32) {
+	msg := peerprotocol.ExtensionMessage{
+		ExtendedMessageID: pe.ExtensionHandshake.M[peerprotocol.ExtensionKeyHolepunch],
+		Payload: peerprotocol.ExtensionHolepunchMessage{
+			Type:  msgType,
+			Addr:  addr,
+			Error: errCode,
+		},
+	}
+	pe.SendMessage(msg)
+}
+
+func supportsHolepunch(pe *peer.Peer) bool {
+	if pe.ExtensionHandshake == nil {
+		return false
+	}
+	id, ok := pe.ExtensionHandshake.M[peerprotocol.ExtensionKeyHolepunch]
+	return ok && id != 0
+}
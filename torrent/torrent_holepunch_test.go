@@ -0,0 +1,179 @@
+package torrent
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/cenkalti/rain/internal/tracker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ID of holepunch messages sent to raw peers in tests.
+const testHolepunchID = 7
+
+func TestHolepunchRelay(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	tor, port := startSeeding(t, s, nil)
+
+	ext := peerprotocol.NewExtensionHandshake(0, "test", nil, 0, false)
+	ext.M = map[string]uint8{peerprotocol.ExtensionKeyHolepunch: testHolepunchID}
+	conn1 := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 2), [20]byte{1})
+	defer conn1.Close()
+	writePeerMessage(t, conn1, peerprotocol.ExtensionMessage{ExtendedMessageID: peerprotocol.ExtensionIDHandshake, Payload: ext})
+	conn2 := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 3), [20]byte{2})
+	defer conn2.Close()
+	writePeerMessage(t, conn2, peerprotocol.ExtensionMessage{ExtendedMessageID: peerprotocol.ExtensionIDHandshake, Payload: ext})
+
+	// Wait until both extension handshakes are processed.
+	assert.Eventually(t, func() bool {
+		peers := tor.Peers()
+		return len(peers) == 2 && slices.Contains(peers[0].Extensions, peerprotocol.ExtensionKeyHolepunch) && slices.Contains(peers[1].Extensions, peerprotocol.ExtensionKeyHolepunch)
+	}, timeout, 10*time.Millisecond)
+
+	addr1 := conn1.LocalAddr().(*net.TCPAddr)
+	addr2 := conn2.LocalAddr().(*net.TCPAddr)
+
+	// Rendezvous for a peer that the relay is not connected to.
+	unknown := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1).To4(), Port: 1}
+	writeHolepunchMessage(t, conn1, peerprotocol.HolepunchRendezvous, unknown)
+	msg := readHolepunchMessage(t, conn1)
+	assert.Equal(t, peerprotocol.HolepunchError, msg.Type)
+	assert.Equal(t, peerprotocol.HolepunchErrNotConnected, msg.Error)
+
+	// Rendezvous for ourselves.
+	writeHolepunchMessage(t, conn1, peerprotocol.HolepunchRendezvous, addr1)
+	msg = readHolepunchMessage(t, conn1)
+	assert.Equal(t, peerprotocol.HolepunchError, msg.Type)
+	assert.Equal(t, peerprotocol.HolepunchErrNoSelf, msg.Error)
+
+	// Relay sends connect messages to both ends.
+	writeHolepunchMessage(t, conn1, peerprotocol.HolepunchRendezvous, addr2)
+	msg = readHolepunchMessage(t, conn1)
+	assert.Equal(t, peerprotocol.HolepunchConnect, msg.Type)
+	assert.Equal(t, addr2.String(), msg.Addr.String())
+	msg = readHolepunchMessage(t, conn2)
+	assert.Equal(t, peerprotocol.HolepunchConnect, msg.Type)
+	assert.Equal(t, addr1.String(), msg.Addr.String())
+}
+
+func TestHolepunchConnect(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.PEXEnabled = true
+	_, err := s.blocklist.Reload(strings.NewReader("10.1.2.0/24\n"))
+	require.NoError(t, err)
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err := s.AddTorrent(f, &AddTorrentOptions{Stopped: true})
+	require.NoError(t, err)
+	tor.torrent.trackers = nil
+	require.NoError(t, tor.Start())
+	var port int
+	select {
+	case port = <-tor.torrent.NotifyListen():
+	case err = <-tor.torrent.NotifyError():
+		t.Fatal(err)
+	case <-time.After(timeout):
+		t.Fatal("torrent is not ready")
+	}
+
+	// Listeners that count the connections made by the torrent.
+	listen := func(ip net.IP) (*net.TCPAddr, *atomic.Int32) {
+		l, err := net.ListenTCP("tcp4", &net.TCPAddr{IP: ip})
+		require.NoError(t, err)
+		t.Cleanup(func() { l.Close() })
+		var accepted atomic.Int32
+		go func() {
+			for {
+				c, err := l.Accept()
+				if err != nil {
+					return
+				}
+				accepted.Add(1)
+				c.Close()
+			}
+		}()
+		return l.Addr().(*net.TCPAddr), &accepted
+	}
+	target, targetAccepted := listen(net.IPv4(127, 0, 0, 5))
+	other, otherAccepted := listen(net.IPv4(127, 0, 0, 6))
+
+	ext := peerprotocol.NewExtensionHandshake(0, "test", nil, 0, false)
+	ext.M = map[string]uint8{peerprotocol.ExtensionKeyHolepunch: testHolepunchID}
+	conn := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 2), [20]byte{1})
+	defer conn.Close()
+	writePeerMessage(t, conn, peerprotocol.ExtensionMessage{ExtendedMessageID: peerprotocol.ExtensionIDHandshake, Payload: ext})
+
+	// Connect messages that are not a response to our rendezvous are ignored.
+	writeHolepunchMessage(t, conn, peerprotocol.HolepunchConnect, other)
+
+	// Peer tells us about peers that can only be reached with holepunch.
+	blocked := &net.TCPAddr{IP: net.IPv4(10, 1, 2, 3).To4(), Port: 6881}
+	var added []byte
+	for _, addr := range []*net.TCPAddr{blocked, target} {
+		b, err := tracker.NewCompactPeer(addr).MarshalBinary()
+		require.NoError(t, err)
+		added = append(added, b...)
+	}
+	flags := string([]byte{peerprotocol.PEXFlagHolepunch, peerprotocol.PEXFlagHolepunch})
+	writePeerMessage(t, conn, peerprotocol.ExtensionMessage{
+		ExtendedMessageID: peerprotocol.ExtensionIDPEX,
+		Payload:           peerprotocol.ExtensionPEXMessage{Added: string(added), AddedFlags: flags},
+	})
+
+	// Rendezvous is not sent for the blocklisted address.
+	msg := readHolepunchMessage(t, conn)
+	assert.Equal(t, peerprotocol.HolepunchRendezvous, msg.Type)
+	assert.Equal(t, target.String(), msg.Addr.String())
+	var blocklisted bool
+	for _, a := range tor.ConnectionAttempts() {
+		if a.Addr.String() == blocked.String() {
+			blocklisted = a.Result == ConnectionBlocklisted
+		}
+	}
+	assert.True(t, blocklisted)
+
+	// Connect for the requested address is dialed.
+	writeHolepunchMessage(t, conn, peerprotocol.HolepunchConnect, target)
+	assert.Eventually(t, func() bool { return targetAccepted.Load() > 0 }, timeout, 10*time.Millisecond)
+	assert.Zero(t, otherAccepted.Load())
+}
+
+func writeHolepunchMessage(t *testing.T, conn net.Conn, msgType uint8, addr *net.TCPAddr) {
+	writePeerMessage(t, conn, peerprotocol.ExtensionMessage{
+		ExtendedMessageID: peerprotocol.ExtensionIDHolepunch,
+		Payload:           peerprotocol.ExtensionHolepunchMessage{Type: msgType, Addr: addr},
+	})
+}
+
+// readHolepunchMessage skips other messages until a holepunch message is received.
+func readHolepunchMessage(t *testing.T, conn net.Conn) peerprotocol.ExtensionHolepunchMessage {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(timeout)))
+	for {
+		var length uint32
+		require.NoError(t, binary.Read(conn, binary.BigEndian, &length))
+		if length == 0 {
+			continue
+		}
+		buf := make([]byte, length)
+		_, err := io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		if peerprotocol.MessageID(buf[0]) != peerprotocol.Extension || buf[1] != testHolepunchID {
+			continue
+		}
+		var msg peerprotocol.ExtensionHolepunchMessage
+		require.NoError(t, msg.UnmarshalBinary(buf[2:]))
+		return msg
+	}
+}
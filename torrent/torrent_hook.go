@@ -0,0 +1,39 @@
+package torrent
+
+import "github.com/cenkalti/rain/internal/resumer/boltdbresumer"
+
+// runHook runs the hook command configured for the event, if any.
+func (t *torrent) runHook(e Event) {
+	if len(t.session.hookCommand(e.Type)) == 0 {
+		return
+	}
+	t.session.runHook(t, e, t.stats())
+}
+
+// setLastHook is called from hook goroutines when the command exits.
+func (t *torrent) setLastHook(res hookResult) {
+	select {
+	case t.lastHookC <- res:
+	case <-t.closeC:
+	}
+}
+
+func (t *torrent) handleHookResult(res hookResult) {
+	t.lastHook = res
+	err := t.session.resumer.WriteLastHook(t.id, res.spec())
+	if err != nil {
+		t.log.Errorf("cannot write hook result to resume db: %s", err)
+	}
+}
+
+func (r hookResult) spec() boltdbresumer.HookResult {
+	ret := boltdbresumer.HookResult{
+		Event:    r.event,
+		ExitCode: r.exitCode,
+		Time:     r.time,
+	}
+	if r.err != nil {
+		ret.Error = r.err.Error()
+	}
+	return ret
+}
@@ -27,14 +27,14 @@ func (t *torrent) handlePieceMessage(pm peer.PieceMessage) {
 	if t.pieces == nil || t.bitfield == nil {
 		pe.Logger().Error("piece received but we don't have info")
 		t.bytesWasted.Inc(l)
-		t.closePeer(pe)
+		t.closePeer(pe, closeMisbehaved, "piece received but we don't have info")
 		msg.Buffer.Release()
 		return
 	}
 	if msg.Index >= uint32(len(t.pieces)) {
 		pe.Logger().Errorln("invalid piece index:", msg.Index)
 		t.bytesWasted.Inc(l)
-		t.closePeer(pe)
+		t.closePeer(pe, closeMisbehaved, "invalid piece index")
 		msg.Buffer.Release()
 		return
 	}
@@ -58,7 +58,7 @@ func (t *torrent) handlePieceMessage(pm peer.PieceMessage) {
 	case piecedownloader.ErrBlockInvalid:
 		pe.Logger().Errorln("received invalid piece index:", msg.Index, "begin:", msg.Begin, "length:", len(msg.Buffer.Data))
 		t.bytesWasted.Inc(l)
-		t.closePeer(pe)
+		t.closePeer(pe, closeMisbehaved, "invalid block")
 		msg.Buffer.Release()
 		return
 	case piecedownloader.ErrBlockDuplicate:
@@ -84,7 +84,7 @@ func (t *torrent) handlePieceMessage(pm peer.PieceMessage) {
 	case nil:
 	default:
 		pe.Logger().Error(err)
-		t.closePeer(pe)
+		t.closePeer(pe, closeMisbehaved, err.Error())
 		msg.Buffer.Release()
 		return
 	}
@@ -117,6 +117,16 @@ func (t *torrent) handlePieceMessage(pm peer.PieceMessage) {
 	go pw.Run(t.pieceWriterResultC, t.doneC, t.session.metrics.WritesPerSecond, t.session.metrics.SpeedWrite, t.session.semWrite)
 }
 
+// handleHave records that the peer has the piece.
+// Piece picker is nil while seeding but bitfields of peers are still needed for choking and peer stats.
+func (t *torrent) handleHave(pe *peer.Peer, i uint32) {
+	if t.piecePicker != nil {
+		t.piecePicker.HandleHave(pe, i)
+	} else {
+		pe.Bitfield.Set(i)
+	}
+}
+
 func (t *torrent) handlePeerMessage(pm peer.Message) {
 	pe := pm.Peer
 	switch msg := pm.Message.(type) {
@@ -128,14 +138,18 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 		}
 		if msg.Index >= t.info.NumPieces {
 			pe.Logger().Errorln("unexpected piece index:", msg.Index)
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "invalid have index")
 			break
 		}
 		// pe.Logger().Debug("Peer ", pe.String(), " has piece #", pi.Index)
-		if t.piecePicker != nil {
-			t.piecePicker.HandleHave(pe, msg.Index)
+		t.handleHave(pe, msg.Index)
+		if t.superSeeder != nil {
+			t.superSeedHandleHave(pe, msg.Index)
 		}
 		t.updateInterestedState(pe)
+		if t.closeIfRedundant(pe) {
+			break
+		}
 		t.startPieceDownloaderFor(pe)
 	case peerprotocol.BitfieldMessage:
 		// Save bitfield messages while we don't have info yet.
@@ -150,30 +164,32 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 		bf, err := bitfield.NewBytes(msg.Data, t.info.NumPieces)
 		if err != nil {
 			pe.Logger().Errorf("%s [len(bitfield)=%d] [numPieces=%d]", err, len(msg.Data), t.info.NumPieces)
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "invalid bitfield")
 			break
 		}
 		pe.Logger().Debugln("Received bitfield:", bf.Hex())
-		if t.piecePicker != nil {
-			for i := uint32(0); i < bf.Len(); i++ {
-				if bf.Test(i) {
-					t.piecePicker.HandleHave(pe, i)
-				}
+		for i := uint32(0); i < bf.Len(); i++ {
+			if bf.Test(i) {
+				t.handleHave(pe, i)
 			}
 		}
 		t.updateInterestedState(pe)
+		if t.closeIfRedundant(pe) {
+			break
+		}
 		t.startPieceDownloaderFor(pe)
 	case peerprotocol.HaveAllMessage:
 		if t.pieces == nil || t.bitfield == nil {
 			pe.Messages = append(pe.Messages, msg)
 			break
 		}
-		if t.piecePicker != nil {
-			for _, pi := range t.pieces {
-				t.piecePicker.HandleHave(pe, pi.Index)
-			}
+		for _, pi := range t.pieces {
+			t.handleHave(pe, pi.Index)
 		}
 		t.updateInterestedState(pe)
+		if t.closeIfRedundant(pe) {
+			break
+		}
 		t.startPieceDownloaderFor(pe)
 	case peerprotocol.HaveNoneMessage:
 	case peerprotocol.AllowedFastMessage:
@@ -183,13 +199,27 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 		}
 		if msg.Index >= t.info.NumPieces {
 			pe.Logger().Errorln("invalid allowed fast piece index:", msg.Index)
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "invalid allowed fast index")
 			break
 		}
 		pe.Logger().Debug("Peer ", pe.String(), " has allowed fast for piece #", msg.Index)
 		if t.piecePicker != nil {
 			t.piecePicker.HandleAllowedFast(pe, msg.Index)
 		}
+	case peerprotocol.SuggestPieceMessage:
+		// Suggestions are only hints for piece picker. They are ignored until we have info.
+		if t.pieces == nil || t.bitfield == nil {
+			break
+		}
+		if msg.Index >= t.info.NumPieces {
+			pe.Logger().Errorln("invalid suggest piece index:", msg.Index)
+			t.closePeer(pe, closeMisbehaved, "invalid suggest index")
+			break
+		}
+		t.session.metrics.SuggestsReceived.Mark(1)
+		if t.piecePicker != nil {
+			t.piecePicker.HandleSuggest(pe, msg.Index)
+		}
 	case peerprotocol.UnchokeMessage:
 		pe.PeerChoking = false
 		pd, ok := t.pieceDownloaders[pe]
@@ -226,28 +256,32 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 	case peerprotocol.InterestedMessage:
 		pe.PeerInterested = true
 		t.unchoker.FastUnchoke(pe)
+		t.suggestPiecesToPeer(pe)
 	case peerprotocol.NotInterestedMessage:
 		pe.PeerInterested = false
 	case peerprotocol.RequestMessage:
 		if t.pieces == nil || t.bitfield == nil {
 			pe.Logger().Error("request received but we don't have info")
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "request received but we don't have info")
 			break
 		}
 		if msg.Index >= t.info.NumPieces {
 			pe.Logger().Errorln("invalid request index:", msg.Index)
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "invalid request index")
 			break
 		}
 		if msg.Begin+msg.Length > t.pieces[msg.Index].Length {
 			pe.Logger().Errorln("invalid request length:", msg.Length)
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "invalid request length")
 			break
 		}
 		pi := &t.pieces[msg.Index]
-		if !pi.Done {
-			m := peerprotocol.RejectMessage{RequestMessage: msg}
-			pe.SendMessage(m)
+		if !pi.Done || (t.superSeeder != nil && !t.superSeedAllowed(pe, msg.Index)) {
+			// Reject message is only defined in fast extension. Other peers get no response.
+			if pe.FastEnabled {
+				m := peerprotocol.RejectMessage{RequestMessage: msg}
+				pe.SendMessage(m)
+			}
 			break
 		}
 		if pe.ClientChoking {
@@ -261,17 +295,23 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 			}
 		} else {
 			pe.SendPiece(msg, cachedpiece.New(pi, t.session.pieceCache, t.session.config.ReadCacheBlockSize, t.peerID))
+			if pe.SentSuggested.Has(pi) {
+				t.session.metrics.SuggestedRequests.Mark(1)
+			}
+			if t.superSeeder != nil {
+				t.superSeedHandleUpload(pe, msg)
+			}
 		}
 	case peerprotocol.RejectMessage:
 		if t.pieces == nil || t.bitfield == nil {
 			pe.Logger().Error("reject received but we don't have info")
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "reject received but we don't have info")
 			break
 		}
 
 		if msg.Index >= t.info.NumPieces {
 			pe.Logger().Errorln("invalid reject index:", msg.Index)
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "invalid reject index")
 			break
 		}
 		pd, ok := t.pieceDownloaders[pe]
@@ -284,13 +324,13 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 		ok = pd.Rejected(msg.Begin, msg.Length)
 		if !ok {
 			pe.Logger().Errorln("invalid reject index:", msg.Index, "begin:", msg.Begin, "length:", msg.Length)
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "invalid reject")
 			break
 		}
 	case peerprotocol.CancelMessage:
 		if t.pieces == nil || t.bitfield == nil {
 			pe.Logger().Error("cancel received but we don't have info")
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, "cancel received but we don't have info")
 			break
 		}
 
@@ -319,9 +359,20 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 		pe.Logger().Debugln("extension handshake received:", msg)
 		if pe.ExtensionHandshake != nil {
 			pe.Logger().Debugln("peer changed extensions")
+			// Peers send the handshake again when they start or stop downloading (BEP 21).
+			pe.ExtensionHandshake.UploadOnly = msg.UploadOnly
+			t.closeIfRedundant(pe)
 			break
 		}
 		pe.ExtensionHandshake = &msg
+		// Update the flags of the peer in PEX messages sent to other peers.
+		if flags := pe.PEXFlags(); flags != peer.PEXFlags(pe.Source, nil) {
+			t.pexAddPeer(pe.Addr(), flags)
+		}
+		if t.completed && msg.UploadOnly {
+			t.closePeer(pe, closeClean, "peer is upload only and we are seeding")
+			break
+		}
 
 		if len(msg.YourIP) == 4 {
 			t.externalIP = net.IP(msg.YourIP)
@@ -347,13 +398,15 @@ func (t *torrent) handlePeerMessage(pm peer.Message) {
 			t.log.Error(err)
 			break
 		}
-		t.handleNewPeers(addrs, peersource.PEX)
+		t.handlePEXPeers(pe, addrs, msg.AddedFlags)
 		addrs, err = tracker.DecodePeersCompact([]byte(msg.Dropped))
 		if err != nil {
 			t.log.Error(err)
 			break
 		}
 		t.handleNewPeers(addrs, peersource.PEX)
+	case peerprotocol.ExtensionHolepunchMessage:
+		t.handleHolepunchMessage(pe, msg)
 	default:
 		t.crash(fmt.Sprintf("unhandled peer message type: %T", msg))
 	}
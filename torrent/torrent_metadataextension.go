@@ -61,7 +61,7 @@ func (t *torrent) handleMetadataMessage(pe *peer.Peer, msg peerprotocol.Extensio
 		err := id.GotBlock(msg.Piece, msg.Data)
 		if err != nil {
 			pe.Logger().Error(err)
-			t.closePeer(pe)
+			t.closePeer(pe, closeMisbehaved, err.Error())
 			t.startInfoDownloaders()
 			break
 		}
@@ -76,7 +76,8 @@ func (t *torrent) handleMetadataMessage(pe *peer.Peer, msg peerprotocol.Extensio
 		_, _ = hash.Write(id.Bytes)
 		if !bytes.Equal(hash.Sum(nil), t.infoHash[:]) {
 			pe.Logger().Errorln("received info does not match with hash")
-			t.closePeer(id.Peer.(*peer.Peer))
+			t.closePeer(id.Peer.(*peer.Peer), closeMisbehaved, "received info does not match with hash")
+			t.session.recordHashFailure(pe.IP())
 			t.startInfoDownloaders()
 			break
 		}
@@ -103,6 +104,8 @@ func (t *torrent) handleMetadataMessage(pe *peer.Peer, msg peerprotocol.Extensio
 		default:
 			close(t.completeMetadataC)
 		}
+		t.publishEvent(Event{Type: EventMetadataReceived})
+		t.runHook(Event{Type: EventMetadataReceived})
 		if t.stopAfterMetadata {
 			t.stopAndSetStoppedOnMetadata()
 		} else {
@@ -111,7 +114,7 @@ func (t *torrent) handleMetadataMessage(pe *peer.Peer, msg peerprotocol.Extensio
 	case peerprotocol.ExtensionMetadataMessageTypeReject:
 		id, ok := t.infoDownloaders[pe]
 		if ok {
-			t.closePeer(id.Peer.(*peer.Peer))
+			t.closePeer(id.Peer.(*peer.Peer), closeClean, "metadata request rejected")
 			t.startInfoDownloaders()
 		}
 	}
@@ -71,17 +71,22 @@ func (t *torrent) handleNewPeers(addrs []*net.TCPAddr, source peersource.Source)
 		return
 	}
 	if !t.completed {
-		addrs = t.filterBannedIPs(addrs)
-		t.addrList.Push(addrs, source)
+		addrs = t.filterBannedIPs(addrs, source)
+		for _, addr := range t.addrList.Push(addrs, source) {
+			t.addConnectionAttempt(addr, source, ConnectionBlocklisted, nil)
+		}
 		t.dialAddresses()
 	}
 }
 
-func (t *torrent) filterBannedIPs(a []*net.TCPAddr) []*net.TCPAddr {
+func (t *torrent) filterBannedIPs(a []*net.TCPAddr, source peersource.Source) []*net.TCPAddr {
 	b := a[:0]
 	for _, x := range a {
-		if _, ok := t.bannedPeerIPs[x.IP.String()]; !ok {
+		ip := x.IP.String()
+		if !t.session.isIPBannedInTorrent(t, ip) {
 			b = append(b, x)
+		} else {
+			t.addConnectionAttempt(x, source, ConnectionBanned, nil)
 		}
 	}
 	return b
@@ -91,35 +96,46 @@ func (t *torrent) dialAddresses() {
 	if t.completed {
 		return
 	}
-	peersConnected := func() int {
-		return len(t.outgoingPeers) + len(t.outgoingHandshakers)
+	counts := t.connectionsBySource()
+	allowed := func(src peersource.Source) bool {
+		return t.sourceAllowed(src, counts[src])
 	}
-	for peersConnected() < t.session.config.MaxPeerDial {
-		addr, src := t.addrList.Pop()
+	for !t.dialLimitReached() {
+		addr, src := t.addrList.PopAllowed(allowed)
 		if addr == nil {
 			t.setNeedMorePeers(true)
 			return
 		}
 		ip := addr.IP.String()
 		if _, ok := t.connectedPeerIPs[ip]; ok {
+			t.addConnectionAttempt(addr, src, ConnectionDuplicate, nil)
+			continue
+		}
+		if t.session.isIPBanned(ip) {
+			t.addConnectionAttempt(addr, src, ConnectionBanned, nil)
 			continue
 		}
-		h := outgoinghandshaker.New(addr, src)
-		t.outgoingHandshakers[h] = struct{}{}
-		t.connectedPeerIPs[ip] = struct{}{}
-		go h.Run(
-			t.session.config.PeerConnectTimeout,
-			t.session.config.PeerHandshakeTimeout,
-			t.peerID,
-			t.infoHash,
-			t.outgoingHandshakerResultC,
-			t.session.extensions,
-			t.session.config.DisableOutgoingEncryption,
-			t.session.config.ForceOutgoingEncryption,
-		)
+		t.dialAddr(addr, src)
+		counts[src]++
 	}
 }
 
+func (t *torrent) dialAddr(addr *net.TCPAddr, src peersource.Source) {
+	h := outgoinghandshaker.New(addr, src)
+	t.outgoingHandshakers[h] = struct{}{}
+	t.connectedPeerIPs[addr.IP.String()] = struct{}{}
+	go h.Run(
+		t.session.config.PeerConnectTimeout,
+		t.session.config.PeerHandshakeTimeout,
+		t.peerID,
+		t.infoHash,
+		t.outgoingHandshakerResultC,
+		t.session.extensions,
+		t.session.config.DisableOutgoingEncryption,
+		t.session.config.ForceOutgoingEncryption,
+	)
+}
+
 func (t *torrent) startPeer(
 	conn net.Conn,
 	source peersource.Source,
@@ -129,16 +145,26 @@ func (t *torrent) startPeer(
 	cipher mse.CryptoMethod,
 ) {
 	addr := conn.RemoteAddr().(*net.TCPAddr)
-	t.pexAddPeer(addr)
+	if t.session.isPeerBanned(addr.IP.String(), peerID) {
+		t.log.Debugf("peer is banned. addr: %s id: %s", addr, peerID)
+		t.addConnectionAttempt(addr, source, ConnectionBanned, nil)
+		conn.Close()
+		delete(t.connectedPeerIPs, addr.IP.String())
+		t.dialAddresses()
+		return
+	}
+	t.pexAddPeer(addr, peer.PEXFlags(source, nil))
 	_, ok := t.peerIDs[peerID]
 	if ok {
 		t.log.Debugf("peer with same id already connected. addr: %s id: %s", addr, peerID)
+		t.addConnectionAttempt(addr, source, ConnectionDuplicate, nil)
 		conn.Close()
 		t.pexDropPeer(addr)
 		t.dialAddresses()
 		return
 	}
 	t.peerIDs[peerID] = struct{}{}
+	t.addConnectionAttempt(addr, source, ConnectionSucceeded, nil)
 
 	pe := peer.New(conn, source, peerID, extensions, cipher, t.session.config.PieceReadTimeout, t.session.config.RequestTimeout, t.session.config.MaxRequestsIn, t.session.bucketDownload, t.session.bucketUpload)
 	t.peers[pe] = struct{}{}
@@ -155,6 +181,8 @@ func (t *torrent) startPeer(
 func (t *torrent) sendFirstMessage(p *peer.Peer) {
 	bf := t.bitfield
 	switch {
+	case t.superSeeder != nil:
+		t.superSeedPeerStarted(p)
 	case p.FastEnabled && bf != nil && bf.All():
 		msg := peerprotocol.HaveAllMessage{}
 		p.SendMessage(msg)
@@ -167,27 +195,32 @@ func (t *torrent) sendFirstMessage(p *peer.Peer) {
 		msg := peerprotocol.BitfieldMessage{Data: bitfieldData}
 		p.SendMessage(&msg)
 	}
-	var metadataSize uint32
-	if t.info != nil {
-		metadataSize = uint32(len(t.info.Bytes))
-	}
 	if p.ExtensionsEnabled {
-		extHandshakeMsg := peerprotocol.NewExtensionHandshake(metadataSize, t.getClientVersion(), p.Addr().IP, t.session.config.MaxRequestsIn)
-		msg := peerprotocol.ExtensionMessage{
-			ExtendedMessageID: peerprotocol.ExtensionIDHandshake,
-			Payload:           extHandshakeMsg,
-		}
-		p.SendMessage(msg)
+		t.sendExtensionHandshake(p)
 	}
 	if p.DHTEnabled {
 		msg := peerprotocol.PortMessage{Port: t.session.config.DHTPort}
 		p.SendMessage(msg)
 	}
-	if p.FastEnabled && t.pieces != nil {
+	// Allowed fast set would reveal the pieces that are not advertised while super-seeding.
+	if p.FastEnabled && t.pieces != nil && t.superSeeder == nil {
 		p.GenerateAndSendAllowedFastMessages(t.session.config.AllowedFastSet, t.info.NumPieces, t.infoHash, t.pieces)
 	}
 }
 
+func (t *torrent) sendExtensionHandshake(p *peer.Peer) {
+	var metadataSize uint32
+	if t.info != nil {
+		metadataSize = uint32(len(t.info.Bytes))
+	}
+	extHandshakeMsg := peerprotocol.NewExtensionHandshake(metadataSize, t.getClientVersion(), p.Addr().IP, t.session.config.MaxRequestsIn, t.uploadOnly())
+	msg := peerprotocol.ExtensionMessage{
+		ExtendedMessageID: peerprotocol.ExtensionIDHandshake,
+		Payload:           extHandshakeMsg,
+	}
+	p.SendMessage(msg)
+}
+
 func (t *torrent) getClientVersion() string {
 	if t.info != nil && t.info.Private {
 		return t.session.config.PrivateExtensionHandshakeClientVersion
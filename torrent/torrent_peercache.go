@@ -0,0 +1,115 @@
+package torrent
+
+import (
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peersource"
+	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
+)
+
+// peerCacheable returns true if a peer closed with kind is worth dialing again on next start.
+// Peers that misbehaved are never cached. Peers that are closed by an error or
+// because the torrent is stopped must have transferred data to be cached.
+func peerCacheable(kind closeKind, transferred int64) bool {
+	switch kind {
+	case closeClean:
+		return true
+	case closeStopped, closeError:
+		return transferred > 0
+	default:
+		return false
+	}
+}
+
+// updatePeerCache moves the address of a disconnected outgoing peer to the front of the peer cache.
+// Addresses of incoming peers are not cached because their ports are not the ports they listen on.
+func (t *torrent) updatePeerCache(pe *peer.Peer, kind closeKind) {
+	if t.session.config.PeerCacheSize <= 0 || pe.Source == peersource.Incoming {
+		return
+	}
+	if !peerCacheable(kind, pe.BytesDownloaded()+pe.BytesUploaded()) {
+		return
+	}
+	var speed int64
+	if d := time.Since(pe.ConnectedAt); d >= time.Second {
+		speed = pe.BytesDownloaded() / int64(d/time.Second)
+	}
+	addr := pe.Addr().String()
+	peers := make([]boltdbresumer.CachedPeer, 0, len(t.cachedPeers)+1)
+	peers = append(peers, boltdbresumer.CachedPeer{
+		Addr:          addr,
+		DownloadSpeed: speed,
+		Time:          pe.ConnectedAt,
+	})
+	for _, cp := range t.cachedPeers {
+		if cp.Addr != addr {
+			peers = append(peers, cp)
+		}
+	}
+	if len(peers) > t.session.config.PeerCacheSize {
+		peers = peers[:t.session.config.PeerCacheSize]
+	}
+	t.cachedPeers = peers
+}
+
+func (t *torrent) writePeerCache() {
+	if t.session.config.PeerCacheSize <= 0 {
+		return
+	}
+	err := t.session.resumer.WriteCachedPeers(t.id, t.cachedPeers)
+	if err != nil {
+		t.log.Errorf("cannot write cached peers to resume db: %s", err)
+	}
+}
+
+// addCachedPeers pushes the cached peer addresses to the address list and dials them.
+func (t *torrent) addCachedPeers() {
+	if t.session.config.PeerCacheSize <= 0 || t.completed {
+		return
+	}
+	t.pushCachedPeers()
+	t.dialAddresses()
+}
+
+// pushCachedPeers pushes the cached peer addresses to the address list.
+// Cached peers are dialed before peers from other sources, and the ones that have uploaded to us faster are dialed first.
+func (t *torrent) pushCachedPeers() {
+	for _, cp := range t.cachedPeers {
+		ap, err := netip.ParseAddrPort(cp.Addr)
+		if err != nil {
+			t.log.Debugf("invalid cached peer address %q: %s", cp.Addr, err)
+			continue
+		}
+		addrs := t.filterBannedIPs([]*net.TCPAddr{net.TCPAddrFromAddrPort(ap)}, peersource.Cache)
+		// Boost is incremented so that cached peers with zero speed still come before unboosted addresses.
+		for _, addr := range t.addrList.PushBoosted(addrs, peersource.Cache, cp.DownloadSpeed+1) {
+			t.addConnectionAttempt(addr, peersource.Cache, ConnectionBlocklisted, nil)
+		}
+	}
+}
+
+type cachedPeersRequest struct {
+	Response chan []boltdbresumer.CachedPeer
+}
+
+// CachedPeers returns a copy of the peer cache of the torrent.
+func (t *torrent) CachedPeers() []boltdbresumer.CachedPeer {
+	var peers []boltdbresumer.CachedPeer
+	req := cachedPeersRequest{Response: make(chan []boltdbresumer.CachedPeer, 1)}
+	select {
+	case t.cachedPeersCommandC <- req:
+	case <-t.closeC:
+	}
+	select {
+	case peers = <-req.Response:
+	case <-t.closeC:
+	}
+	return peers
+}
+
+func (t *torrent) getCachedPeers() []boltdbresumer.CachedPeer {
+	return append([]boltdbresumer.CachedPeer(nil), t.cachedPeers...)
+}
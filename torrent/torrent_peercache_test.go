@@ -0,0 +1,88 @@
+package torrent
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/cenkalti/rain/internal/addrlist"
+	"github.com/cenkalti/rain/internal/peersource"
+	"github.com/cenkalti/rain/internal/resumer/boltdbresumer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerCache(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.PEXEnabled = false
+	cfg.RPCEnabled = false
+	cfg.Host = "127.0.0.1"
+	s, err := NewSession(cfg)
+	require.NoError(t, err)
+
+	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+addr, nil)
+	require.NoError(t, err)
+	assertCompleted(t, tor)
+	id := tor.ID()
+
+	// Cached peers are loaded from the database.
+	require.NoError(t, s.Close())
+	s, err = NewSession(cfg)
+	require.NoError(t, err)
+	defer s.Close()
+	tor = s.GetTorrent(id)
+	require.NotNil(t, tor)
+	peers := tor.torrent.CachedPeers()
+	require.Len(t, peers, 1)
+	assert.Equal(t, addr, peers[0].Addr)
+	assert.False(t, peers[0].Time.IsZero())
+}
+
+func TestCachedPeersDialedFirst(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	// The torrent is not started, so its fields can be used from the test.
+	var clientIP net.IP
+	tor := &torrent{
+		session:  s,
+		addrList: addrlist.New(10, nil, 6880, &clientIP),
+		cachedPeers: []boltdbresumer.CachedPeer{
+			{Addr: "10.0.0.6:6881", DownloadSpeed: 0},
+			{Addr: "172.16.0.7:6881", DownloadSpeed: 100},
+		},
+	}
+	tr := &net.TCPAddr{IP: net.IPv4(192, 168, 0, 5), Port: 6881}
+	tor.addrList.Push([]*net.TCPAddr{tr}, peersource.Tracker)
+	tor.pushCachedPeers()
+
+	for _, expected := range []struct {
+		addr string
+		src  peersource.Source
+	}{
+		{"172.16.0.7:6881", peersource.Cache},
+		{"10.0.0.6:6881", peersource.Cache},
+		{tr.String(), peersource.Tracker},
+	} {
+		a, src := tor.addrList.Pop()
+		require.NotNil(t, a)
+		assert.Equal(t, expected.addr, a.String())
+		assert.Equal(t, expected.src, src)
+	}
+}
+
+func TestPeerCacheable(t *testing.T) {
+	assert.True(t, peerCacheable(closeClean, 0))
+	assert.False(t, peerCacheable(closeStopped, 0))
+	assert.True(t, peerCacheable(closeStopped, 1))
+	assert.False(t, peerCacheable(closeError, 0))
+	assert.True(t, peerCacheable(closeError, 1))
+	assert.False(t, peerCacheable(closeMisbehaved, 1))
+}
@@ -2,10 +2,10 @@ package torrent
 
 import "net"
 
-func (t *torrent) pexAddPeer(addr *net.TCPAddr) {
+func (t *torrent) pexAddPeer(addr *net.TCPAddr, flags byte) {
 	for pe := range t.peers {
 		if pe.PEX != nil {
-			pe.PEX.Add(addr)
+			pe.PEX.Add(addr, flags)
 		}
 	}
 }
@@ -23,6 +23,7 @@ func (t *torrent) checkCompletion() bool {
 	}
 	t.completed = true
 	close(t.completeC)
+	t.publishEvent(Event{Type: EventCompleted})
 	for h := range t.outgoingHandshakers {
 		h.Close()
 	}
@@ -32,18 +33,20 @@ func (t *torrent) checkCompletion() bool {
 	}
 	for pe := range t.peers {
 		if !pe.PeerInterested {
-			t.closePeer(pe)
+			t.closePeer(pe, closeClean, "download completed and peer is not interested")
 		}
 	}
+	t.updateUploadOnly()
 	t.addrList.Reset()
 	for _, pd := range t.pieceDownloaders {
 		t.closePieceDownloader(pd)
 		pd.CancelPending()
 	}
 	t.piecePicker = nil
+	t.startSuperSeeding()
 	t.updateSeedDuration(time.Now())
 	if !t.completeCmdRun && len(t.session.config.OnCompleteCmd) > 0 {
-		go t.session.runOnCompleteCmd(t)
+		t.runHook(Event{Type: EventCompleted})
 		t.completeCmdRun = true
 		err := t.session.resumer.WriteCompleteCmdRun(t.id)
 		if err != nil {
@@ -15,9 +15,13 @@ func (t *torrent) run() {
 	defer t.unchokeTicker.Stop()
 
 	for {
+		// Keep the numbers in session up to date for other torrents sharing Config.MaxConnections.
+		t.reportConnections()
+
 		select {
 		case <-t.closeC:
 			t.close()
+			t.reportConnections()
 			close(t.doneC)
 			return
 		case <-t.startCommandC:
@@ -40,8 +44,16 @@ func (t *torrent) run() {
 			req.Response <- t.getTrackers()
 		case req := <-t.peersCommandC:
 			req.Response <- t.getPeers()
+		case req := <-t.disconnectedPeersCommandC:
+			req.Response <- t.getDisconnectedPeers()
+		case req := <-t.connectionAttemptsCommandC:
+			req.Response <- t.getConnectionAttempts()
 		case req := <-t.webseedsCommandC:
 			req.Response <- t.getWebseeds()
+		case req := <-t.openFileCommandC:
+			req.Response <- t.getFileToOpen(req.Path)
+		case req := <-t.cachedPeersCommandC:
+			req.Response <- t.getCachedPeers()
 		case p := <-t.allocatorProgressC:
 			t.bytesAllocated = p.AllocatedSize
 		case al := <-t.allocatorResultC:
@@ -60,6 +72,14 @@ func (t *torrent) run() {
 			t.handleNewPeers(addrs, peersource.DHT)
 		case trackers := <-t.addTrackersCommandC:
 			t.handleNewTrackers(trackers)
+		case low := <-t.lowDiskSpaceCommandC:
+			t.handleLowDiskSpace(low)
+		case res := <-t.lastHookC:
+			t.handleHookResult(res)
+		case <-t.bansChangedC:
+			t.closeBannedPeers()
+		case <-t.closeConnectionsC:
+			t.closeConnectionsForOthers()
 		case conn := <-t.incomingConnC:
 			t.handleNewConnection(conn)
 		case res := <-t.webseedPieceResultC.ReceiveC():
@@ -74,16 +94,19 @@ func (t *torrent) run() {
 			t.handlePeerSnubbed(pe)
 		case <-t.unchokeTicker.C:
 			t.unchoker.TickUnchoke(t.getPeersForUnchoker(), t.completed)
+			t.suggestPieces()
 		case ih := <-t.incomingHandshakerResultC:
 			t.handleIncomingHandshakeDone(ih)
 		case oh := <-t.outgoingHandshakerResultC:
 			t.handleOutgoingHandshakeDone(oh)
 		case pe := <-t.peerDisconnectedC:
-			t.closePeer(pe)
+			kind, reason := connectionClosedReason(pe.Err())
+			t.closePeer(pe, kind, reason)
 		case pm := <-t.pieceMessagesC.ReceiveC():
 			t.handlePieceMessage(pm)
 		case pm := <-t.messages:
 			t.handlePeerMessage(pm)
 		}
+		t.publishStateChanges()
 	}
 }
@@ -38,7 +38,10 @@ func (t *torrent) start() {
 	if t.info != nil {
 		if t.pieces != nil {
 			if t.bitfield != nil {
+				// checkCompletion does nothing if the torrent was completed before it was stopped.
+				t.startSuperSeeding()
 				t.addFixedPeers()
+				t.addCachedPeers()
 				t.startAcceptor()
 				t.startAnnouncers()
 				t.startPieceDownloaders()
@@ -50,6 +53,7 @@ func (t *torrent) start() {
 		}
 	} else {
 		t.addFixedPeers()
+		t.addCachedPeers()
 		t.startAcceptor()
 		t.startAnnouncers()
 		t.startInfoDownloaders()
@@ -71,6 +75,12 @@ func (t *torrent) startAllocator() {
 	if t.allocator != nil {
 		t.crash("allocator exists")
 	}
+	t.lowDiskSpace = t.session.isDiskSpaceLow(t.Dir())
+	if t.lowDiskSpace {
+		t.updateUploadOnly()
+		t.log.Warning("free disk space is low, file allocation is delayed")
+		return
+	}
 	t.allocator = allocator.New()
 	go t.allocator.Run(t.info, t.storage, t.allocatorProgressC, t.allocatorResultC)
 }
@@ -101,6 +111,10 @@ func (t *torrent) startNewAnnouncer(tr tracker.Tracker) {
 		t.announcerFields,
 		t.completeC,
 		t.addrsFromTrackers,
+		func(err *announcer.AnnounceError) {
+			// Called from the announcer goroutine, not from the torrent event loop.
+			t.session.publishEvent(Event{Type: EventTrackerError, TorrentID: t.id, Tracker: tr.URL(), Error: &AnnounceError{err}})
+		},
 		t.log,
 	)
 	t.announcers = append(t.announcers, an)
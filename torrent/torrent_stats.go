@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/cenkalti/rain/internal/mse"
+	"github.com/cenkalti/rain/internal/peer"
 	"github.com/cenkalti/rain/internal/peersource"
 	"github.com/cenkalti/rain/internal/stringutil"
 )
@@ -74,6 +75,38 @@ type Stats struct {
 		DHT int
 		// Peers found via peer exchange.
 		PEX int
+		// Peers loaded from the cache of peers that we have connected before.
+		Cache int
+	}
+	// Counts of connection attempts by result since the torrent is loaded.
+	ConnectionAttempts struct {
+		// Peers that completed the protocol handshake.
+		Succeeded int
+		// Addresses and incoming connections that are in the blocklist.
+		Blocklisted int
+		// Addresses, incoming connections and peer IDs that are banned.
+		Banned int
+		// Incoming connections rejected because Config.MaxPeerAccept, Config.MaxConnectionsPerTorrent, Config.MaxConnections or Config.MaxPeersPerSource is reached.
+		PeerLimit int
+		// Connections to IP addresses or peer IDs that are already connected.
+		Duplicate int
+		// TCP connections that could not be established.
+		DialFailed int
+		// Connections or handshakes that are not completed in time.
+		Timeout int
+		// Connections closed by peer during handshake.
+		Closed int
+		// Failed encryption handshakes.
+		EncryptionFailed int
+		// Peers that are not in the swarm of the torrent.
+		InfoHashMismatch int
+		// Peers that sent an invalid handshake.
+		ProtocolError int
+		// Connections failed with an unclassified error.
+		Error int
+		// True if there are Config.MaxPeerDial outgoing connections or no more connections are allowed by Config.MaxConnections.
+		// Addresses wait in the address list until one of the connections is closed.
+		DialLimitReached bool
 	}
 	Downloads struct {
 		// Number of active piece downloads.
@@ -112,6 +145,19 @@ type Stats struct {
 	}
 	// Time remaining to complete download. nil value means infinity.
 	ETA *time.Duration
+	// Outcome of the last hook command run for the torrent.
+	LastHook struct {
+		// Name of the event that triggered the hook. Empty if no hook has run.
+		Event string
+		// Exit code of the command. -1 if the command could not be started or is killed.
+		ExitCode int
+		// Set if the command could not be run or exited with non-zero status.
+		Error error
+		// Start time of the command.
+		Time time.Time
+	}
+	// True if pieces are advertised to peers one at a time with BEP 16 super-seeding.
+	SuperSeeding bool
 }
 
 func (t *torrent) stats() Stats {
@@ -126,9 +172,24 @@ func (t *torrent) stats() Stats {
 	s.Addresses.Tracker = t.addrList.LenSource(peersource.Tracker)
 	s.Addresses.DHT = t.addrList.LenSource(peersource.DHT)
 	s.Addresses.PEX = t.addrList.LenSource(peersource.PEX)
+	s.Addresses.Cache = t.addrList.LenSource(peersource.Cache)
 	s.Handshakes.Incoming = len(t.incomingHandshakers)
 	s.Handshakes.Outgoing = len(t.outgoingHandshakers)
 	s.Handshakes.Total = len(t.incomingHandshakers) + len(t.outgoingHandshakers)
+	s.ConnectionAttempts.Succeeded = t.connectionResults[ConnectionSucceeded]
+	s.ConnectionAttempts.Blocklisted = t.connectionResults[ConnectionBlocklisted]
+	s.ConnectionAttempts.Banned = t.connectionResults[ConnectionBanned]
+	s.ConnectionAttempts.PeerLimit = t.connectionResults[ConnectionPeerLimit]
+	s.ConnectionAttempts.Duplicate = t.connectionResults[ConnectionDuplicate]
+	s.ConnectionAttempts.DialFailed = t.connectionResults[ConnectionDialFailed]
+	s.ConnectionAttempts.Timeout = t.connectionResults[ConnectionTimeout]
+	s.ConnectionAttempts.Closed = t.connectionResults[ConnectionClosed]
+	s.ConnectionAttempts.EncryptionFailed = t.connectionResults[ConnectionEncryptionFailed]
+	s.ConnectionAttempts.InfoHashMismatch = t.connectionResults[ConnectionInfoHashMismatch]
+	s.ConnectionAttempts.ProtocolError = t.connectionResults[ConnectionProtocolError]
+	s.ConnectionAttempts.Error = t.connectionResults[ConnectionError]
+	s.ConnectionAttempts.DialLimitReached = t.dialLimitReached()
+	s.SuperSeeding = t.superSeeder != nil
 	s.Peers.Total = len(t.peers)
 	s.Peers.Incoming = len(t.incomingPeers)
 	s.Peers.Outgoing = len(t.outgoingPeers)
@@ -148,6 +209,10 @@ func (t *torrent) stats() Stats {
 	s.Pieces.Checked = t.checkedPieces
 	s.Speed.Download = int(t.downloadSpeed.Rate1())
 	s.Speed.Upload = int(t.uploadSpeed.Rate1())
+	s.LastHook.Event = t.lastHook.event
+	s.LastHook.ExitCode = t.lastHook.exitCode
+	s.LastHook.Error = t.lastHook.err
+	s.LastHook.Time = t.lastHook.time
 
 	if t.info != nil {
 		s.Bytes.Total = t.info.Length
@@ -237,44 +302,79 @@ func (t *torrent) getTrackers() []Tracker {
 func (t *torrent) getPeers() []Peer {
 	peers := make([]Peer, 0, len(t.peers))
 	for pe := range t.peers {
-		var source PeerSource
-		switch pe.Source {
-		case peersource.Tracker:
-			source = SourceTracker
-		case peersource.DHT:
-			source = SourceDHT
-		case peersource.PEX:
-			source = SourcePEX
-		case peersource.Incoming:
-			source = SourceIncoming
-		case peersource.Manual:
-			source = SourceManual
-		default:
-			t.crash("unhandled peer source")
-		}
-		p := Peer{
-			ID:                 pe.ID,
-			Client:             pe.Client(),
-			Addr:               pe.Addr(),
-			ConnectedAt:        pe.ConnectedAt,
-			Downloading:        pe.Downloading,
-			ClientInterested:   pe.ClientInterested,
-			ClientChoking:      pe.ClientChoking,
-			PeerInterested:     pe.PeerInterested,
-			PeerChoking:        pe.PeerChoking,
-			OptimisticUnchoked: pe.OptimisticUnchoked,
-			Snubbed:            pe.Snubbed,
-			EncryptedHandshake: pe.EncryptionCipher != 0,
-			EncryptedStream:    pe.EncryptionCipher == mse.RC4,
-			Source:             source,
-			DownloadSpeed:      pe.DownloadSpeed(),
-			UploadSpeed:        pe.UploadSpeed(),
-		}
-		peers = append(peers, p)
+		peers = append(peers, t.newPeer(pe))
 	}
 	return peers
 }
 
+func (t *torrent) getDisconnectedPeers() []Peer {
+	peers := make([]Peer, len(t.disconnectedPeers))
+	copy(peers, t.disconnectedPeers)
+	return peers
+}
+
+func (t *torrent) newPeer(pe *peer.Peer) Peer {
+	p := Peer{
+		ID:                 pe.ID,
+		Client:             pe.Client(),
+		Addr:               pe.Addr(),
+		ConnectedAt:        pe.ConnectedAt,
+		Downloading:        pe.Downloading,
+		ClientInterested:   pe.ClientInterested,
+		ClientChoking:      pe.ClientChoking,
+		PeerInterested:     pe.PeerInterested,
+		PeerChoking:        pe.PeerChoking,
+		OptimisticUnchoked: pe.OptimisticUnchoked,
+		Snubbed:            pe.Snubbed,
+		EncryptedHandshake: pe.EncryptionCipher != 0,
+		EncryptedStream:    pe.EncryptionCipher == mse.RC4,
+		Source:             t.peerSource(pe.Source),
+		DownloadSpeed:      pe.DownloadSpeed(),
+		UploadSpeed:        pe.UploadSpeed(),
+		BytesDownloaded:    pe.BytesDownloaded(),
+		BytesUploaded:      pe.BytesUploaded(),
+		RequestsIn:         pe.QueuedRequests(),
+		LastMessageAt:      pe.LastMessageAt(),
+		Extensions:         pe.Extensions(),
+	}
+	if pd, ok := t.pieceDownloaders[pe]; ok {
+		p.RequestsOut += pd.Pending()
+	}
+	if id, ok := t.infoDownloaders[pe]; ok {
+		p.RequestsOut += id.Pending()
+	}
+	if pe.Bitfield != nil && pe.Bitfield.Len() > 0 {
+		p.Completion = int(uint64(pe.Bitfield.Count()) * 100 / uint64(pe.Bitfield.Len()))
+	}
+	if pe.ExtensionHandshake != nil {
+		p.RequestQueue = pe.ExtensionHandshake.RequestQueue
+	}
+	return p
+}
+
+func (t *torrent) peerSource(src peersource.Source) PeerSource {
+	var source PeerSource
+	switch src {
+	case peersource.Tracker:
+		source = SourceTracker
+	case peersource.DHT:
+		source = SourceDHT
+	case peersource.PEX:
+		source = SourcePEX
+	case peersource.Incoming:
+		source = SourceIncoming
+	case peersource.Manual:
+		source = SourceManual
+	case peersource.Cache:
+		source = SourceCache
+	case peersource.Holepunch:
+		source = SourceHolepunch
+	default:
+		t.crash("unhandled peer source")
+	}
+	return source
+}
+
 func (t *torrent) getWebseeds() []Webseed {
 	webseeds := make([]Webseed, 0, len(t.webseedSources))
 	for _, src := range t.webseedSources {
@@ -20,6 +20,9 @@ const (
 	Seeding
 	// Stopping the torrent. This is the status after Stop() is called. All peers are disconnected and files are closed. A stop event sent to all trackers. After trackers responded the torrent switches into Stopped state.
 	Stopping
+	// LowDiskSpace indicates that downloading is paused because free space on the disk is less than Config.MinFreeDiskSpace.
+	// Downloading continues automatically after enough space becomes available.
+	LowDiskSpace
 )
 
 func (s Status) String() string {
@@ -31,6 +34,7 @@ func (s Status) String() string {
 		Downloading:         "Downloading",
 		Seeding:             "Seeding",
 		Stopping:            "Stopping",
+		LowDiskSpace:        "Low Disk Space",
 	}
 	return m[s]
 }
@@ -49,6 +53,8 @@ func (t *torrent) status() Status {
 		return Seeding
 	case t.info == nil:
 		return DownloadingMetadata
+	case t.lowDiskSpace:
+		return LowDiskSpace
 	default:
 		return Downloading
 	}
@@ -47,10 +47,15 @@ func (t *torrent) stop(err error) {
 	t.lastError = err
 	if err != nil && err != errClosed {
 		t.log.Error(err)
+		t.publishEvent(Event{Type: EventError, Error: err})
+		t.runHook(Event{Type: EventError, Error: err})
 	}
 
 	t.stopAcceptor()
 	t.stopPeers()
+	t.writePeerCache()
+	t.superSeeder = nil
+	clear(t.holepunchPending)
 	t.stopPiecedownloaders()
 	t.stopInfoDownloaders()
 	t.stopWebseedDownloads()
@@ -180,7 +185,7 @@ func (t *torrent) stopAcceptor() {
 func (t *torrent) stopPeers() {
 	t.log.Debugln("closing peer connections")
 	for p := range t.peers {
-		t.closePeer(p)
+		t.closePeer(p, closeStopped, "torrent stopped")
 	}
 }
 
@@ -0,0 +1,82 @@
+package torrent
+
+import (
+	"github.com/cenkalti/rain/internal/cachedpiece"
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/cenkalti/rain/internal/piece"
+)
+
+// suggestPieces sends suggest piece messages to unchoked peers for the pieces of the torrent in the read cache.
+func (t *torrent) suggestPieces() {
+	cached := t.cachedPieces()
+	if len(cached) == 0 {
+		return
+	}
+	for pe := range t.peers {
+		t.suggestPiecesTo(pe, cached)
+	}
+}
+
+// suggestPiecesToPeer is called when a single peer is unchoked.
+func (t *torrent) suggestPiecesToPeer(pe *peer.Peer) {
+	cached := t.cachedPieces()
+	if len(cached) == 0 {
+		return
+	}
+	t.suggestPiecesTo(pe, cached)
+}
+
+// cachedPieces returns the pieces in the read cache, most recently read first.
+// The cache is scanned again only if items are added to or removed from it since the last scan,
+// so the order is not updated when cached blocks are read again.
+func (t *torrent) cachedPieces() []*piece.Piece {
+	// Suggestions would reveal the pieces that are not advertised while super-seeding.
+	if t.session.config.SuggestPieces <= 0 || t.pieces == nil || t.superSeeder != nil {
+		return nil
+	}
+	if gen := t.session.pieceCache.Generation(); gen != t.cachedPiecesGeneration {
+		t.cachedPieceIndexes = cachedpiece.CachedPieces(t.session.pieceCache, t.peerID)
+		t.cachedPiecesGeneration = gen
+	}
+	ret := make([]*piece.Piece, 0, len(t.cachedPieceIndexes))
+	for _, i := range t.cachedPieceIndexes {
+		if i < uint32(len(t.pieces)) && t.pieces[i].Done {
+			ret = append(ret, &t.pieces[i])
+		}
+	}
+	return ret
+}
+
+func (t *torrent) suggestPiecesTo(pe *peer.Peer, cached []*piece.Piece) {
+	if !pe.FastEnabled || pe.ClientChoking || !pe.PeerInterested || pe.Bitfield == nil {
+		return
+	}
+	// Forget the suggestions that are downloaded by the peer or evicted from the cache.
+	items := pe.SentSuggested.Items[:0]
+	for _, pi := range pe.SentSuggested.Items {
+		if !pe.Bitfield.Test(pi.Index) && containsPiece(cached, pi) {
+			items = append(items, pi)
+		}
+	}
+	pe.SentSuggested.Items = items
+	for _, pi := range cached {
+		if pe.SentSuggested.Len() >= t.session.config.SuggestPieces {
+			return
+		}
+		if pe.Bitfield.Test(pi.Index) || !pe.SentSuggested.Add(pi) {
+			continue
+		}
+		pe.SendMessage(peerprotocol.SuggestPieceMessage{HaveMessage: peerprotocol.HaveMessage{Index: pi.Index}})
+		t.session.metrics.SuggestsSent.Mark(1)
+	}
+}
+
+func containsPiece(pieces []*piece.Piece, pi *piece.Piece) bool {
+	for _, p := range pieces {
+		if p == pi {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,177 @@
+package torrent
+
+import (
+	"github.com/cenkalti/rain/internal/bitfield"
+	"github.com/cenkalti/rain/internal/peer"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+)
+
+// superSeeder keeps the state of BEP 16 super-seeding.
+// We pretend to have no pieces and advertise a single piece to each peer with a have message.
+// Another piece is advertised to the peer after the piece is seen at another peer.
+type superSeeder struct {
+	// Peers that have connected while super-seeding.
+	peers map[*peer.Peer]*superSeedPeer
+	// Number of times each piece is advertised.
+	offerCount []int
+	// IDs of the peers that each piece is fully uploaded to.
+	sentTo []map[[20]byte]struct{}
+	// Pieces that are announced by a peer other than the ones we have uploaded them to.
+	distributed *bitfield.Bitfield
+}
+
+type superSeedPeer struct {
+	// Pieces advertised to the peer. Peer can request only these pieces.
+	offered map[uint32]struct{}
+	// Last advertised piece that must be seen at another peer before advertising the next one.
+	current uint32
+	// Offsets of the blocks of the current piece that are uploaded to the peer.
+	uploaded map[uint32]struct{}
+	// Number of bytes of the current piece that are uploaded to the peer.
+	uploadedBytes uint32
+	// False if there is no piece left to advertise to the peer.
+	waiting bool
+}
+
+// startSuperSeeding is called when the torrent starts seeding, either on completion or on start of a completed torrent.
+func (t *torrent) startSuperSeeding() {
+	if !t.superSeeding || t.superSeeder != nil || !t.completed {
+		return
+	}
+	t.log.Info("starting super-seeding")
+	t.superSeeder = &superSeeder{
+		peers:       make(map[*peer.Peer]*superSeedPeer),
+		offerCount:  make([]int, t.info.NumPieces),
+		sentTo:      make([]map[[20]byte]struct{}, t.info.NumPieces),
+		distributed: bitfield.New(t.info.NumPieces),
+	}
+}
+
+// stopSuperSeeding switches back to normal seeding and sends the pieces that are not advertised yet to the peers.
+func (t *torrent) stopSuperSeeding() {
+	ss := t.superSeeder
+	t.superSeeder = nil
+	for pe, sp := range ss.peers {
+		for i := uint32(0); i < t.info.NumPieces; i++ {
+			if _, ok := sp.offered[i]; ok || pe.Bitfield.Test(i) {
+				continue
+			}
+			pe.SendMessage(peerprotocol.HaveMessage{Index: i})
+		}
+	}
+}
+
+// superSeedPeerStarted is called instead of sending our bitfield to a new peer.
+func (t *torrent) superSeedPeerStarted(pe *peer.Peer) {
+	if pe.FastEnabled {
+		pe.SendMessage(peerprotocol.HaveNoneMessage{})
+	}
+	sp := &superSeedPeer{offered: make(map[uint32]struct{})}
+	t.superSeeder.peers[pe] = sp
+	t.superSeedOffer(pe, sp)
+}
+
+// superSeedOffer advertises the least advertised piece that is not distributed and the peer does not have.
+func (t *torrent) superSeedOffer(pe *peer.Peer, sp *superSeedPeer) {
+	ss := t.superSeeder
+	var found bool
+	var index uint32
+	for i := uint32(0); i < t.info.NumPieces; i++ {
+		if ss.distributed.Test(i) || pe.Bitfield.Test(i) {
+			continue
+		}
+		if !found || ss.offerCount[i] < ss.offerCount[index] {
+			found = true
+			index = i
+		}
+	}
+	sp.waiting = found
+	if !found {
+		return
+	}
+	ss.offerCount[index]++
+	sp.offered[index] = struct{}{}
+	sp.current = index
+	sp.uploaded = make(map[uint32]struct{})
+	sp.uploadedBytes = 0
+	pe.SendMessage(peerprotocol.HaveMessage{Index: index})
+}
+
+// superSeedHandleHave is called when a peer announces a piece with a have message.
+// Bitfields are not counted because a piece in a bitfield may not be downloaded from the peers we have uploaded it to.
+// A piece is distributed when it is announced by a peer that we have not uploaded it to.
+func (t *torrent) superSeedHandleHave(pe *peer.Peer, index uint32) {
+	ss := t.superSeeder
+	if _, ok := ss.sentTo[index][pe.ID]; ok {
+		return
+	}
+	if len(ss.sentTo[index]) > 0 {
+		ss.distributed.Set(index)
+	}
+	for pe2, sp := range ss.peers {
+		if !sp.waiting || sp.current != index {
+			continue
+		}
+		if pe2 == pe || ss.distributed.Test(index) {
+			t.superSeedOffer(pe2, sp)
+		}
+	}
+	if ss.distributed.All() {
+		t.log.Info("all pieces are distributed, stopping super-seeding")
+		t.stopSuperSeeding()
+		t.superSeeding = false
+		err := t.session.resumer.WriteSuperSeeding(t.id, false)
+		if err != nil {
+			t.log.Errorf("cannot write super-seeding status to resume db: %s", err)
+		}
+	}
+}
+
+// superSeedHandleUpload is called when a block is uploaded to the peer.
+// Peers do not send have messages for the pieces that they know we have,
+// so a peer is assumed to have the advertised piece after all of its blocks are uploaded.
+func (t *torrent) superSeedHandleUpload(pe *peer.Peer, msg peerprotocol.RequestMessage) {
+	ss := t.superSeeder
+	sp, ok := ss.peers[pe]
+	if !ok || !sp.waiting || msg.Index != sp.current {
+		return
+	}
+	if _, ok = sp.uploaded[msg.Begin]; ok {
+		return
+	}
+	sp.uploaded[msg.Begin] = struct{}{}
+	sp.uploadedBytes += msg.Length
+	if sp.uploadedBytes < t.pieces[msg.Index].Length {
+		return
+	}
+	t.handleHave(pe, msg.Index)
+	if ss.sentTo[msg.Index] == nil {
+		ss.sentTo[msg.Index] = make(map[[20]byte]struct{})
+	}
+	ss.sentTo[msg.Index][pe.ID] = struct{}{}
+	// Wait until the piece appears at another peer unless nobody else can get it.
+	if !t.canRedistribute(pe, msg.Index) {
+		t.superSeedOffer(pe, sp)
+	}
+}
+
+// canRedistribute returns true if there is another peer that does not have the piece.
+func (t *torrent) canRedistribute(pe *peer.Peer, index uint32) bool {
+	for pe2 := range t.peers {
+		if pe2 != pe && !pe2.Bitfield.Test(index) {
+			return true
+		}
+	}
+	return false
+}
+
+// superSeedAllowed returns false if the peer has requested a piece that we have not advertised.
+func (t *torrent) superSeedAllowed(pe *peer.Peer, index uint32) bool {
+	sp, ok := t.superSeeder.peers[pe]
+	if !ok {
+		// Peer has connected before super-seeding is started and got the full bitfield.
+		return true
+	}
+	_, ok = sp.offered[index]
+	return ok
+}
@@ -0,0 +1,123 @@
+package torrent
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSuperSeeding(t *testing.T) {
+	s1, closeSession1 := newTestSession(t)
+	defer closeSession1()
+	seed, port := startSeeding(t, s1, &AddTorrentOptions{SuperSeeding: true})
+	assert.True(t, seed.Stats().SuperSeeding)
+
+	// Super-seeding continues after restarting the completed torrent.
+	seed.Stop()
+	assert.Eventually(t, func() bool { return seed.Stats().Status == Stopped }, timeout, 10*time.Millisecond)
+	assert.False(t, seed.Stats().SuperSeeding)
+	require.NoError(t, seed.Start())
+	select {
+	case port = <-seed.torrent.NotifyListen():
+	case err := <-seed.torrent.NotifyError():
+		t.Fatal(err)
+	case <-time.After(timeout):
+		t.Fatal("seeder is not ready")
+	}
+	assert.Eventually(t, func() bool { return seed.Stats().SuperSeeding }, timeout, 10*time.Millisecond)
+
+	s2, closeSession2 := newTestSession(t)
+	defer closeSession2()
+	tor, err := s2.AddURI(torrentMagnetLink+"&x.pe=127.0.0.1:"+strconv.Itoa(port), nil)
+	require.NoError(t, err)
+	assertCompleted(t, tor)
+
+	// Pieces are not distributed until they are seen at another peer.
+	assert.True(t, seed.Stats().SuperSeeding)
+}
+
+func TestSuperSeedingDistribution(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	seed, port := startSeeding(t, s, &AddTorrentOptions{SuperSeeding: true})
+	stats := seed.Stats()
+	numPieces := stats.Pieces.Total
+	totalLength := stats.Bytes.Total
+	const pieceLength = 1 << 20
+	const blockSize = 16 << 10
+
+	// The only peer gets the next piece after downloading the advertised one.
+	conn1 := dialRawPeer(t, s, seed, port, net.IPv4(127, 0, 0, 2), [20]byte{1})
+	defer conn1.Close()
+	writePeerMessage(t, conn1, peerprotocol.InterestedMessage{})
+	pieceLen := func(index uint32) int64 { return min(pieceLength, totalLength-int64(index)*pieceLength) }
+	var unchoked bool
+	var offered []uint32
+	var requested, downloaded uint32
+	var received int64
+	for downloaded < numPieces {
+		id, payload := readPeerMessage(t, conn1)
+		switch id {
+		case peerprotocol.Unchoke:
+			unchoked = true
+		case peerprotocol.Have:
+			offered = append(offered, binary.BigEndian.Uint32(payload))
+		case peerprotocol.Piece:
+			received += int64(len(payload) - 8)
+			if received == pieceLen(offered[downloaded]) {
+				downloaded++
+				received = 0
+			}
+		}
+		if unchoked && requested == downloaded && int(requested) < len(offered) {
+			index := offered[requested]
+			for begin := int64(0); begin < pieceLen(index); begin += blockSize {
+				writePeerMessage(t, conn1, peerprotocol.RequestMessage{Index: index, Begin: uint32(begin), Length: uint32(min(blockSize, pieceLen(index)-begin))})
+			}
+			requested++
+		}
+	}
+	assert.Len(t, offered, int(numPieces))
+	assert.True(t, seed.Stats().SuperSeeding)
+
+	// A seed does not count as distribution.
+	conn2 := dialRawPeer(t, s, seed, port, net.IPv4(127, 0, 0, 3), [20]byte{2})
+	defer conn2.Close()
+	writePeerMessage(t, conn2, peerprotocol.HaveAllMessage{})
+	assert.Eventually(t, func() bool { return len(seed.DisconnectedPeers()) == 1 }, timeout, 10*time.Millisecond)
+	assert.True(t, seed.Stats().SuperSeeding)
+
+	// Pieces announced by another peer are distributed.
+	conn3 := dialRawPeer(t, s, seed, port, net.IPv4(127, 0, 0, 4), [20]byte{3})
+	defer conn3.Close()
+	for i := uint32(0); i < numPieces; i++ {
+		writePeerMessage(t, conn3, peerprotocol.HaveMessage{Index: i})
+	}
+	assert.Eventually(t, func() bool { return !seed.Stats().SuperSeeding }, timeout, 10*time.Millisecond)
+	spec, err := s.resumer.Read(seed.ID())
+	require.NoError(t, err)
+	assert.False(t, spec.SuperSeeding)
+}
+
+// readPeerMessage skips keep-alive messages and returns the ID and payload of the next message.
+func readPeerMessage(t *testing.T, conn net.Conn) (peerprotocol.MessageID, []byte) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(timeout)))
+	for {
+		var length uint32
+		require.NoError(t, binary.Read(conn, binary.BigEndian, &length))
+		if length == 0 {
+			continue
+		}
+		buf := make([]byte, length)
+		_, err := io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		return peerprotocol.MessageID(buf[0]), buf[1:]
+	}
+}
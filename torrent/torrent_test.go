@@ -1,6 +1,10 @@
 package torrent
 
 import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
 	"net"
 	"net/http"
 	"os"
@@ -11,6 +15,7 @@ import (
 	"time"
 
 	"github.com/cenkalti/rain/internal/logger"
+	"github.com/cenkalti/rain/internal/peerprotocol"
 	"github.com/cenkalti/rain/internal/webseedsource"
 	fhttp "github.com/chihaya/chihaya/frontend/http"
 	"github.com/chihaya/chihaya/middleware"
@@ -19,6 +24,7 @@ import (
 	"github.com/fortytw2/leaktest"
 	cp "github.com/otiai10/copy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var (
@@ -151,6 +157,34 @@ func TestDownloadTorrent(t *testing.T) {
 	assertCompleted(t, tor)
 }
 
+func TestDisconnectedPeers(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+
+	tor, err := s.AddURI(torrentMagnetLink+"&x.pe="+addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCompleted(t, tor)
+
+	// Seeder is disconnected after the download is completed.
+	peers := tor.DisconnectedPeers()
+	if !assert.Len(t, peers, 1) {
+		return
+	}
+	p := peers[0]
+	assert.Equal(t, addr, p.Addr.String())
+	assert.Equal(t, "download completed and peer is not interested", p.DisconnectReason)
+	assert.False(t, p.DisconnectedAt.IsZero())
+	assert.False(t, p.LastMessageAt.IsZero())
+	assert.Equal(t, 100, p.Completion)
+	assert.Equal(t, int64(tor.torrent.info.Length), p.BytesDownloaded)
+	assert.Contains(t, p.Extensions, "ut_metadata")
+	assert.Empty(t, tor.Peers())
+}
+
 func TestTorrentDir(t *testing.T) {
 	defer leaktest.Check(t)()
 	addr, cl := seeder(t, true)
@@ -320,3 +354,103 @@ func waitForStart(t *testing.T, tor *Torrent) {
 		t.Fatal("start dit not finish")
 	}
 }
+
+// startSeeding adds the test torrent to the session with its data and waits until it is seeding.
+func startSeeding(t *testing.T, s *Session, opt *AddTorrentOptions) (tor *Torrent, port int) {
+	var o AddTorrentOptions
+	if opt != nil {
+		o = *opt
+	}
+	o.Stopped = true
+	f, err := os.Open(torrentFile)
+	require.NoError(t, err)
+	defer f.Close()
+	tor, err = s.AddTorrent(f, &o)
+	require.NoError(t, err)
+	err = cp.Copy(filepath.Join(torrentDataDir, torrentName), filepath.Join(s.config.DataDir, tor.ID(), torrentName))
+	require.NoError(t, err)
+	tor.torrent.trackers = nil
+	require.NoError(t, tor.Start())
+	select {
+	case port = <-tor.torrent.NotifyListen():
+	case err = <-tor.torrent.NotifyError():
+		t.Fatal(err)
+	case <-time.After(timeout):
+		t.Fatal("seeder is not ready")
+	}
+	assertCompleted(t, tor)
+	return
+}
+
+// dialRawPeer connects to the torrent from the local IP and completes an unencrypted BitTorrent handshake.
+// Different local IPs must be used for multiple connections because only one connection is allowed from an IP.
+func dialRawPeer(t *testing.T, s *Session, tor *Torrent, port int, localIP net.IP, peerID [20]byte) net.Conn {
+	dialer := net.Dialer{Timeout: timeout, LocalAddr: &net.TCPAddr{IP: localIP}}
+	conn, err := dialer.Dial("tcp", "127.0.0.1:"+strconv.Itoa(port))
+	require.NoError(t, err)
+	var buf bytes.Buffer
+	buf.WriteString("\x13BitTorrent protocol")
+	buf.Write(s.extensions[:])
+	buf.Write(tor.torrent.infoHash[:])
+	buf.Write(peerID[:])
+	_, err = conn.Write(buf.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(timeout)))
+	_, err = io.ReadFull(conn, make([]byte, buf.Len()))
+	require.NoError(t, err)
+	return conn
+}
+
+func writePeerMessage(t *testing.T, conn net.Conn, msg peerprotocol.Message) {
+	var buf bytes.Buffer
+	buf.WriteByte(byte(msg.ID()))
+	var err error
+	if wt, ok := msg.(io.WriterTo); ok {
+		_, err = wt.WriteTo(&buf)
+	} else {
+		_, err = buf.ReadFrom(msg)
+	}
+	require.NoError(t, err)
+	require.NoError(t, binary.Write(conn, binary.BigEndian, uint32(buf.Len())))
+	_, err = conn.Write(buf.Bytes())
+	require.NoError(t, err)
+}
+
+func TestLowDiskSpace(t *testing.T) {
+	addr, cl := seeder(t, true)
+	defer cl()
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.MinFreeDiskSpace = math.MaxInt64
+
+	f, err := os.Open(torrentFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	tor, err := s.AddTorrent(f, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, LowDiskSpace, tor.Stats().Status)
+
+	s.config.MinFreeDiskSpace = 1
+	s.checkDiskSpace()
+	tor.AddPeer(addr)
+	assertCompleted(t, tor)
+}
+
+func TestInvalidDiskSpaceCheckInterval(t *testing.T) {
+	tmp, closeTmp := tempdir(t)
+	defer closeTmp()
+	cfg := DefaultConfig
+	cfg.Database = filepath.Join(tmp, "session.db")
+	cfg.DataDir = tmp
+	cfg.DHTEnabled = false
+	cfg.RPCEnabled = false
+	cfg.MinFreeDiskSpace = 1
+	cfg.DiskSpaceCheckInterval = 0
+	_, err := NewSession(cfg)
+	assert.Error(t, err)
+}
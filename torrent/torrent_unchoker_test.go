@@ -0,0 +1,67 @@
+package torrent
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/bitfield"
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAntiLeechSeeding(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	s.config.UnchokedPeers = 1
+	s.config.OptimisticUnchokedPeers = 0
+	tor, port := startSeeding(t, s, &AddTorrentOptions{ChokingAlgorithm: "anti-leech"})
+	numPieces := tor.torrent.info.NumPieces
+	require.Greater(t, numPieces, uint32(1))
+
+	// Peer with a piece gets the only upload slot because it is the first interested peer.
+	idHasPiece := [20]byte{1}
+	bf := bitfield.New(numPieces)
+	bf.Set(0)
+	conn1 := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 2), idHasPiece)
+	defer conn1.Close()
+	writePeerMessage(t, conn1, &peerprotocol.BitfieldMessage{Data: bf.Bytes()})
+	writePeerMessage(t, conn1, peerprotocol.InterestedMessage{})
+	assert.Eventually(t, func() bool {
+		pe, ok := findPeer(tor, idHasPiece)
+		return ok && !pe.ClientChoking && pe.Completion > 0
+	}, timeout, 10*time.Millisecond)
+
+	// Peer without any pieces is unchoked instead at the next unchoke round.
+	idNoPiece := [20]byte{2}
+	conn2 := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 3), idNoPiece)
+	defer conn2.Close()
+	writePeerMessage(t, conn2, peerprotocol.HaveNoneMessage{})
+	writePeerMessage(t, conn2, peerprotocol.InterestedMessage{})
+	assert.Eventually(t, func() bool {
+		pe, ok := findPeer(tor, idNoPiece)
+		return ok && pe.PeerInterested && pe.ClientChoking
+	}, timeout, 10*time.Millisecond)
+	tor.torrent.unchokeTicker.Reset(10 * time.Millisecond)
+	swapped := func() bool {
+		pe1, ok1 := findPeer(tor, idHasPiece)
+		pe2, ok2 := findPeer(tor, idNoPiece)
+		return ok1 && ok2 && pe1.ClientChoking && !pe2.ClientChoking
+	}
+	assert.Eventually(t, swapped, timeout, 10*time.Millisecond)
+	// The peer without pieces keeps the slot in the following rounds.
+	for i := 0; i < 20; i++ {
+		time.Sleep(10 * time.Millisecond)
+		require.True(t, swapped())
+	}
+}
+
+func findPeer(tor *Torrent, id [20]byte) (Peer, bool) {
+	for _, pe := range tor.Peers() {
+		if pe.ID == id {
+			return pe, true
+		}
+	}
+	return Peer{}, false
+}
@@ -0,0 +1,45 @@
+package torrent
+
+import "github.com/cenkalti/rain/internal/peer"
+
+// uploadOnly returns true if the torrent is not downloading pieces,
+// either because all pieces are downloaded or downloading is paused because of low disk space.
+func (t *torrent) uploadOnly() bool {
+	return t.completed || t.lowDiskSpace
+}
+
+// updateUploadOnly is called when the torrent starts or stops downloading pieces.
+// Connected peers are notified with a new extension handshake containing the upload_only flag (BEP 21).
+func (t *torrent) updateUploadOnly() {
+	t.partialSeed.Store(t.lowDiskSpace && !t.completed)
+	for pe := range t.peers {
+		if pe.ExtensionsEnabled {
+			t.sendExtensionHandshake(pe)
+		}
+	}
+}
+
+// peerUploadOnly returns true if the peer is a seed or it has told that it is not going to download pieces.
+func (t *torrent) peerUploadOnly(pe *peer.Peer) bool {
+	if pe.ExtensionHandshake != nil && pe.ExtensionHandshake.UploadOnly {
+		return true
+	}
+	return pe.Bitfield != nil && pe.Bitfield.All()
+}
+
+// closeIfRedundant disconnects the peer if it is upload only and we cannot download anything from it.
+// Returns true if the peer is closed.
+func (t *torrent) closeIfRedundant(pe *peer.Peer) bool {
+	if t.bitfield == nil || !t.peerUploadOnly(pe) {
+		return false
+	}
+	switch {
+	case t.completed:
+		t.closePeer(pe, closeClean, "peer is upload only and we are seeding")
+	case !pe.ClientInterested:
+		t.closePeer(pe, closeClean, "peer is upload only and we are not interested")
+	default:
+		return false
+	}
+	return true
+}
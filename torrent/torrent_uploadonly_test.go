@@ -0,0 +1,41 @@
+package torrent
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cenkalti/rain/internal/peerprotocol"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadOnlyPeerDisconnectedBySeed(t *testing.T) {
+	s, closeSession := newTestSession(t)
+	defer closeSession()
+	tor, port := startSeeding(t, s, nil)
+
+	// Connect as a partial seed that is not going to download anything.
+	conn := dialRawPeer(t, s, tor, port, net.IPv4(127, 0, 0, 1), [20]byte{1})
+	defer conn.Close()
+	writePeerMessage(t, conn, peerprotocol.ExtensionMessage{
+		ExtendedMessageID: peerprotocol.ExtensionIDHandshake,
+		Payload:           peerprotocol.NewExtensionHandshake(0, "test", nil, 0, true),
+	})
+
+	// Seed closes the connection after receiving upload_only flag.
+	err := conn.SetReadDeadline(time.Now().Add(timeout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = io.Copy(io.Discard, conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peers := tor.DisconnectedPeers()
+	if !assert.Len(t, peers, 1) {
+		return
+	}
+	assert.Equal(t, "peer is upload only and we are seeding", peers[0].DisconnectReason)
+	assert.Empty(t, tor.Peers())
+}
@@ -58,6 +58,7 @@ func (t *torrent) handleVerificationDone(ve *verifier.Verifier) {
 			t.completeC = make(chan struct{})
 		}
 	}
+	t.updateUploadOnly()
 
 	if t.doVerify {
 		// Stop after manual verification command.
@@ -80,6 +81,7 @@ func (t *torrent) handleVerificationDone(ve *verifier.Verifier) {
 	}
 	t.processQueuedMessages()
 	t.addFixedPeers()
+	t.addCachedPeers()
 	t.startAcceptor()
 	t.startAnnouncers()
 	t.startPieceDownloaders()
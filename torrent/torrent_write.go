@@ -23,8 +23,9 @@ func (t *torrent) handlePieceWriteDone(pw *piecewriter.PieceWriter) {
 		switch src := pw.Source.(type) {
 		case *peer.Peer:
 			t.log.Debugln("received corrupt piece from peer", src.String())
-			t.closePeer(src)
-			t.bannedPeerIPs[src.IP()] = struct{}{}
+			t.closePeer(src, closeMisbehaved, "sent corrupt piece")
+			t.session.banIPInTorrent(t, src.IP())
+			t.session.recordHashFailure(src.IP())
 		case *urldownloader.URLDownloader:
 			t.log.Debugln("received corrupt piece from webseed", src.URL)
 			t.disableSource(src.URL, errors.New("corrupt piece"), false)
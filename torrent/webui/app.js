@@ -0,0 +1,342 @@
+"use strict";
+
+// JSON-RPC endpoint is served at the root of the RPC server.
+const rpcURL = new URL("../", location.href).toString();
+const refreshInterval = 2000;
+const listFields = ["Name", "Status", "Error", "Progress", "BytesTotal", "SpeedDownload", "SpeedUpload", "Peers", "ETA"];
+
+let requestID = 0;
+let selectedID = null;
+let activeTab = "stats";
+
+async function call(method, params) {
+  const resp = await fetch(rpcURL, {
+    method: "POST",
+    headers: {"Content-Type": "application/json", "Accept": "application/json"},
+    body: JSON.stringify({jsonrpc: "2.0", id: ++requestID, method: "Session." + method, params: params || {}}),
+  });
+  if (!resp.ok) {
+    throw new Error(resp.status + " " + (await resp.text()).trim());
+  }
+  const body = await resp.json();
+  if (body.error) {
+    throw new Error(body.error.message);
+  }
+  return body.result;
+}
+
+function showMessage(text, info) {
+  const el = document.getElementById("message");
+  el.textContent = text;
+  el.className = info ? "info" : "";
+  el.hidden = false;
+  clearTimeout(showMessage.timer);
+  showMessage.timer = setTimeout(() => { el.hidden = true; }, 5000);
+}
+
+function formatBytes(n) {
+  const units = ["B", "KiB", "MiB", "GiB", "TiB"];
+  let i = 0;
+  while (n >= 1024 && i < units.length - 1) {
+    n /= 1024;
+    i++;
+  }
+  return (i === 0 ? n : n.toFixed(1)) + " " + units[i];
+}
+
+function formatSpeed(n) {
+  return n ? formatBytes(n) + "/s" : "";
+}
+
+function formatDuration(seconds) {
+  if (seconds === undefined || seconds < 0) {
+    return "";
+  }
+  const h = Math.floor(seconds / 3600);
+  const m = Math.floor(seconds % 3600 / 60);
+  const s = seconds % 60;
+  if (h > 0) {
+    return h + "h" + m + "m";
+  }
+  if (m > 0) {
+    return m + "m" + s + "s";
+  }
+  return s + "s";
+}
+
+function cell(row, text, className) {
+  const td = row.insertCell();
+  td.textContent = text;
+  if (className) {
+    td.className = className;
+  }
+  return td;
+}
+
+function renderTorrents(torrents) {
+  const tbody = document.querySelector("#torrents tbody");
+  tbody.textContent = "";
+  for (const t of torrents) {
+    const row = tbody.insertRow();
+    row.dataset.id = t.ID;
+    if (t.ID === selectedID) {
+      row.classList.add("selected");
+    }
+    if (t.Error) {
+      row.classList.add("error");
+      row.title = t.Error;
+    }
+    cell(row, t.Name || t.ID, "name");
+    cell(row, t.Status || "");
+    const progress = cell(row, "", "num");
+    const bar = document.createElement("progress");
+    bar.max = 100;
+    bar.value = t.Progress || 0;
+    progress.append(bar, " " + (t.Progress || 0) + "%");
+    cell(row, t.BytesTotal ? formatBytes(t.BytesTotal) : "", "num");
+    cell(row, formatSpeed(t.SpeedDownload), "num");
+    cell(row, formatSpeed(t.SpeedUpload), "num");
+    cell(row, t.Peers || "", "num");
+    cell(row, formatDuration(t.ETA), "num");
+  }
+  if (selectedID !== null && !torrents.some(t => t.ID === selectedID)) {
+    select(null);
+  }
+}
+
+function select(id) {
+  selectedID = id;
+  for (const row of document.querySelectorAll("#torrents tbody tr")) {
+    row.classList.toggle("selected", row.dataset.id === id);
+  }
+  for (const button of document.querySelectorAll("#actions button")) {
+    button.disabled = id === null;
+  }
+  document.getElementById("details").hidden = id === null;
+  refreshDetails();
+}
+
+function table(headers, rows) {
+  if (rows.length === 0) {
+    const p = document.createElement("p");
+    p.className = "empty";
+    p.textContent = "None";
+    return p;
+  }
+  const t = document.createElement("table");
+  const head = t.createTHead().insertRow();
+  for (const h of headers) {
+    const th = document.createElement("th");
+    th.textContent = h;
+    head.append(th);
+  }
+  const body = t.createTBody();
+  for (const r of rows) {
+    const row = body.insertRow();
+    for (const v of r) {
+      cell(row, v === undefined || v === null ? "" : String(v));
+    }
+  }
+  return t;
+}
+
+const tabs = {
+  async stats(id) {
+    const s = (await call("GetTorrentStats", {ID: id})).Stats;
+    return table(["Property", "Value"], [
+      ["Name", s.Name],
+      ["Info hash", s.InfoHash],
+      ["Status", s.Status],
+      ["Error", s.Error],
+      ["Pieces", s.Pieces.Have + " / " + s.Pieces.Total + " (available " + s.Pieces.Available + ")"],
+      ["Completed", formatBytes(s.Bytes.Completed) + " / " + formatBytes(s.Bytes.Total)],
+      ["Downloaded", formatBytes(s.Bytes.Downloaded)],
+      ["Uploaded", formatBytes(s.Bytes.Uploaded)],
+      ["Wasted", formatBytes(s.Bytes.Wasted)],
+      ["Peers", s.Peers.Total + " (incoming " + s.Peers.Incoming + ", outgoing " + s.Peers.Outgoing + ")"],
+      ["Connection attempts", connectionAttempts(s.ConnectionAttempts)],
+      ["Addresses", s.Addresses.Total + " (tracker " + s.Addresses.Tracker + ", DHT " + s.Addresses.DHT + ", PEX " + s.Addresses.PEX + ", cache " + s.Addresses.Cache + ")"],
+      ["Speed", formatSpeed(s.Speed.Download) + " down, " + formatSpeed(s.Speed.Upload) + " up"],
+      ["ETA", formatDuration(s.ETA)],
+      ["Seeded for", formatDuration(s.SeededFor)],
+      ["Port", s.Port],
+      ["Private", s.Private],
+      ["Last hook", s.LastHook.Event ? s.LastHook.Event + " (exit " + s.LastHook.ExitCode + ") " + (s.LastHook.Error || "") : ""],
+    ]);
+  },
+  async trackers(id) {
+    const trackers = (await call("GetTorrentTrackers", {ID: id})).Trackers || [];
+    return table(["URL", "Status", "Seeders", "Leechers", "Error"],
+      trackers.map(t => [t.URL, t.Status, t.Seeders, t.Leechers, t.Error || t.Warning]));
+  },
+  async peers(id) {
+    const peers = (await call("GetTorrentPeers", {ID: id})).Peers || [];
+    const disconnected = (await call("GetTorrentDisconnectedPeers", {ID: id})).Peers || [];
+    const div = document.createElement("div");
+    const heading = document.createElement("h3");
+    heading.textContent = "Recently disconnected";
+    div.append(
+      table(["Address", "Client", "Source", "Down", "Up", "Received", "Sent", "Requests in/out", "Have", "Flags", "Extensions"],
+        peers.map(p => [p.Addr, p.Client, p.Source, formatSpeed(p.DownloadSpeed), formatSpeed(p.UploadSpeed),
+          formatBytes(p.BytesDownloaded), formatBytes(p.BytesUploaded), p.RequestsIn + " / " + p.RequestsOut,
+          p.Completion + "%", peerFlags(p), (p.Extensions || []).join(", ")])),
+      heading,
+      table(["Address", "Client", "Received", "Sent", "Disconnected", "Reason"],
+        disconnected.reverse().map(p => [p.Addr, p.Client, formatBytes(p.BytesDownloaded), formatBytes(p.BytesUploaded),
+          new Date(p.DisconnectedAt).toLocaleTimeString(), p.DisconnectReason])));
+    return div;
+  },
+  async connections(id) {
+    const attempts = (await call("GetTorrentConnectionAttempts", {ID: id})).ConnectionAttempts || [];
+    return table(["Time", "Address", "Source", "Result", "Error"],
+      attempts.reverse().map(a => [new Date(a.Time).toLocaleTimeString(), a.Addr, a.Source, a.Result, a.Error]));
+  },
+  async files(id) {
+    let files;
+    try {
+      files = (await call("GetTorrentFileStats", {ID: id})).FileStats || [];
+    } catch (e) {
+      // File stats are not available while the torrent is stopped.
+      files = ((await call("GetTorrentFiles", {ID: id})).Files || []).map(f => ({File: f}));
+    }
+    return table(["Path", "Size", "Completed"],
+      files.map(f => [f.File.Path, formatBytes(f.File.Length), f.BytesCompleted === undefined ? "" : formatBytes(f.BytesCompleted)]));
+  },
+  async webseeds(id) {
+    const webseeds = (await call("GetTorrentWebseeds", {ID: id})).Webseeds || [];
+    return table(["URL", "Speed", "Error"], webseeds.map(w => [w.URL, formatSpeed(w.DownloadSpeed), w.Error]));
+  },
+};
+
+function connectionAttempts(a) {
+  const parts = Object.entries(a).filter(([k, v]) => k !== "DialLimitReached" && v > 0).map(([k, v]) => k + " " + v);
+  if (a.DialLimitReached) {
+    parts.push("dial limit reached");
+  }
+  return parts.join(", ");
+}
+
+function peerFlags(p) {
+  let s = "";
+  s += p.ClientInterested ? (p.PeerChoking ? "d" : "D") : "";
+  s += p.PeerInterested ? (p.ClientChoking ? "u" : "U") : "";
+  s += p.OptimisticUnchoked ? "O" : "";
+  s += p.Snubbed ? "S" : "";
+  s += p.EncryptedStream ? "E" : "";
+  return s;
+}
+
+async function refreshDetails() {
+  const content = document.getElementById("tab-content");
+  if (selectedID === null) {
+    content.textContent = "";
+    return;
+  }
+  const id = selectedID;
+  const tab = activeTab;
+  try {
+    const el = await tabs[tab](id);
+    if (id === selectedID && tab === activeTab) {
+      content.replaceChildren(el);
+    }
+  } catch (e) {
+    content.textContent = e.message;
+  }
+}
+
+async function refresh() {
+  try {
+    const torrents = (await call("ListTorrentsWithStats", {Fields: listFields})).Torrents || [];
+    renderTorrents(torrents);
+    const s = (await call("GetSessionStats")).Stats;
+    document.getElementById("session-stats").textContent =
+      s.Torrents + " torrents, " + s.Peers + " peers, " +
+      formatBytes(s.SpeedDownload) + "/s down, " + formatBytes(s.SpeedUpload) + "/s up";
+    await refreshDetails();
+  } catch (e) {
+    showMessage(e.message);
+  }
+}
+
+function readBase64(file) {
+  return new Promise((resolve, reject) => {
+    const reader = new FileReader();
+    reader.onload = () => resolve(reader.result.substring(reader.result.indexOf(",") + 1));
+    reader.onerror = () => reject(reader.error);
+    reader.readAsDataURL(file);
+  });
+}
+
+function addOptions() {
+  return {Stopped: document.getElementById("add-stopped").checked};
+}
+
+document.getElementById("add-magnet").addEventListener("submit", async e => {
+  e.preventDefault();
+  const input = document.getElementById("magnet");
+  const uri = input.value.trim();
+  if (!uri) {
+    return;
+  }
+  try {
+    const t = (await call("AddURI", Object.assign({URI: uri}, addOptions()))).Torrent;
+    input.value = "";
+    showMessage("Added " + t.Name, true);
+    refresh();
+  } catch (err) {
+    showMessage(err.message);
+  }
+});
+
+document.getElementById("torrent-file").addEventListener("change", async e => {
+  for (const file of e.target.files) {
+    try {
+      const torrent = await readBase64(file);
+      const t = (await call("AddTorrent", Object.assign({Torrent: torrent}, addOptions()))).Torrent;
+      showMessage("Added " + t.Name, true);
+    } catch (err) {
+      showMessage(file.name + ": " + err.message);
+    }
+  }
+  e.target.value = "";
+  refresh();
+});
+
+document.getElementById("actions").addEventListener("click", async e => {
+  const action = e.target.dataset.action;
+  if (!action || selectedID === null) {
+    return;
+  }
+  if (action === "RemoveTorrent" && !confirm("Remove the torrent and delete its files?")) {
+    return;
+  }
+  try {
+    await call(action, {ID: selectedID});
+    refresh();
+  } catch (err) {
+    showMessage(err.message);
+  }
+});
+
+document.querySelector("#torrents tbody").addEventListener("click", e => {
+  const row = e.target.closest("tr");
+  if (row) {
+    select(row.dataset.id === selectedID ? null : row.dataset.id);
+  }
+});
+
+document.querySelector("#details .tabs").addEventListener("click", e => {
+  const tab = e.target.dataset.tab;
+  if (!tab) {
+    return;
+  }
+  activeTab = tab;
+  for (const button of document.querySelectorAll("#details .tabs button")) {
+    button.classList.toggle("active", button.dataset.tab === tab);
+  }
+  document.getElementById("tab-content").textContent = "";
+  refreshDetails();
+});
+
+refresh();
+setInterval(refresh, refreshInterval);